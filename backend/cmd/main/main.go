@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	swaggerFiles "github.com/swaggo/files"
@@ -9,15 +12,38 @@ import (
 	"neatly/docs"
 	_ "neatly/docs"
 	"neatly/internal/handlers/account"
+	"neatly/internal/handlers/admin"
+	"neatly/internal/handlers/batch"
+	healthhandler "neatly/internal/handlers/health"
 	"neatly/internal/handlers/middleware"
 	"neatly/internal/handlers/note"
+	"neatly/internal/handlers/privacy"
+	"neatly/internal/handlers/recovery"
+	"neatly/internal/handlers/sessions"
+	"neatly/internal/handlers/sso"
+	"neatly/internal/handlers/stats"
 	"neatly/internal/handlers/tag"
+	"neatly/internal/handlers/template"
+	"neatly/internal/handlers/twofactor"
 	"neatly/internal/mapper"
 	"neatly/internal/repository"
 	"neatly/internal/service"
 	"neatly/internal/session"
 	"neatly/pkg/dbclient"
+	"neatly/pkg/health"
 	"neatly/pkg/logging"
+	"neatly/pkg/mail"
+	"neatly/pkg/metrics"
+	"neatly/pkg/password"
+	"neatly/pkg/ratelimit"
+	"neatly/pkg/shutdown"
+	"neatly/pkg/tracing"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 // @title Neat.ly API
@@ -32,36 +58,205 @@ import (
 // @name Authorization
 func main() {
 	logging.Init()
+	opts, err := session.ParseArgs(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logging.GetLogger().Fatal(err)
+	}
+	cfg, err := session.Load(opts)
+	if err != nil {
+		logging.GetLogger().Fatal(err)
+	}
+	if opts.PrintConfig {
+		if err := session.Print(os.Stdout, cfg); err != nil {
+			logging.GetLogger().Fatal(err)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.GetLogger().Fatal(err)
+	}
+	if opts.PrintConfig {
+		return
+	}
+	session.SetConfig(cfg)
+
+	err = logging.Configure(logging.Config{
+		Level:      cfg.Logging.Level,
+		Format:     cfg.Logging.Format,
+		Outputs:    cfg.Logging.Outputs,
+		File:       cfg.Logging.File,
+		MaxSize:    cfg.Logging.MaxSize,
+		MaxBackups: cfg.Logging.MaxBackups,
+		MaxAge:     cfg.Logging.MaxAge,
+		Compress:   cfg.Logging.Compress,
+	})
+	if err != nil {
+		logging.GetLogger().Fatal(err)
+	}
 	logger := logging.GetLogger()
-	cfg := session.GetConfig()
+	logger.Infof("Application config read from %q, env vars and flags", opts.ConfigFile)
+
+	logger.Info("Configure tracing")
+	flushTraces, err := tracing.Init(tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Fatal(err)
+	}
 
 	client, err := dbclient.NewClient(cfg.DB)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
+	mailer, err := mail.NewMailer(cfg.Mail, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	mailQueue := mail.NewQueue(mailer, cfg.Mail.QueueSize, logger)
+
+	logger.Info("Configure password policy and hashing")
+	password.Configure(
+		password.Policy{
+			MinLength:    cfg.Password.MinLength,
+			MinClasses:   cfg.Password.MinClasses,
+			RejectCommon: cfg.Password.RejectCommon,
+		},
+		password.Params{
+			Memory:      uint32(cfg.Password.Memory),
+			Iterations:  uint32(cfg.Password.Iterations),
+			Parallelism: uint8(cfg.Password.Parallelism),
+		})
+
 	logger.Info("Create new gin router")
 	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.Listen.TrustedProxies); err != nil {
+		logger.Fatal(err)
+	}
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Tracing())
+
+	timeouts := server.Timeouts{
+		Read:  cfg.Listen.ReadTimeout,
+		Write: cfg.Listen.WriteTimeout,
+		Idle:  cfg.Listen.IdleTimeout,
+	}
+
+	// hooks run on shutdown in this order, database goes last so in-flight
+	// requests and workers can finish their queries
+	var shutdownHooks []shutdown.Hook
+
+	if cfg.Metrics.Enabled {
+		logger.Info("Configure metrics")
+		metrics.RegisterDB(client.DB.DB)
+		router.Use(middleware.Metrics())
+		if cfg.Metrics.ListenAddress == "" {
+			router.GET("/metrics", gin.WrapH(metrics.Handler()))
+		} else {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			metricsServer := server.New(cfg.Metrics.ListenAddress, mux, timeouts)
+			go metricsServer.Run(logger)
+			shutdownHooks = append(shutdownHooks, shutdown.Hook{Name: "metrics server", Shutdown: metricsServer.Shutdown})
+		}
+	}
+
+	logger.Info("initializing health checks")
+	healthRegistry := health.NewRegistry(cfg.Health.Timeout, cfg.Health.DrainDelay)
+	healthRegistry.Register("database", client)
+	migrationChecker, err := dbclient.NewMigrationChecker(client, cfg.DB.MigrationsPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	healthRegistry.Register("migrations", migrationChecker)
+	healthHandler := healthhandler.NewHandler(logger, healthRegistry)
+	healthHandler.Register(router)
 
 	logger.Info("Configure CORS")
-	middleware.CorsMiddleware(router)
+	middleware.CorsMiddleware(router, cfg.CORS)
+	middleware.UseAuthCookie(cfg.Cookie)
 
 	docs.SwaggerInfo.Host = cfg.Swagger.Host
 	router.GET("api/v1/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// registered after swagger, its UI needs inline scripts forbidden by CSP
+	if cfg.SecurityHeaders.Enabled {
+		router.Use(middleware.SecurityHeaders(cfg.SecurityHeaders))
+	}
+
 	logger.Info("initializing account repository")
 	accountRepo := repository.NewAccountRepositoryImpl(client, logger)
 	logger.Info("initializing note repository")
 	noteRepo := repository.NewNoteRepositoryImpl(client, logger)
 	logger.Info("initializing tag repository")
 	tagRepo := repository.NewTagRepositoryImpl(client, logger)
+	logger.Info("initializing template repository")
+	templateRepo := repository.NewTemplateRepositoryImpl(client, logger)
+	logger.Info("initializing stats repository")
+	statsRepo := repository.NewStatsRepositoryImpl(client, logger)
+	logger.Info("initializing token repository")
+	tokenRepo := repository.NewTokenRepositoryImpl(client, logger)
+	logger.Info("initializing export repository")
+	exportRepo := repository.NewExportRepositoryImpl(client, logger)
+	logger.Info("initializing two-factor repository")
+	twoFactorRepo := repository.NewTwoFactorRepositoryImpl(client, logger)
+	logger.Info("initializing OIDC repository")
+	oidcRepo := repository.NewOIDCRepositoryImpl(client, logger)
+	logger.Info("initializing session repository")
+	sessionRepo := repository.NewSessionRepositoryImpl(client, logger)
+	logger.Info("initializing admin repository")
+	adminRepo := repository.NewAdminRepositoryImpl(client, logger)
+	logger.Info("initializing lockout repository")
+	lockoutRepo := repository.NewLockoutRepositoryImpl(client, logger)
+	logger.Info("initializing audit repository")
+	auditRepo := repository.NewAuditRepositoryImpl(client, logger)
+	logger.Info("initializing transactor")
+	transactor := repository.NewTransactorImpl(client, logger)
 
+	logger.Info("initializing audit service")
+	auditService := service.NewAuditServiceImpl(auditRepo, logger)
+	logger.Info("initializing session service")
+	sessionService := service.NewSessionServiceImpl(sessionRepo, cfg.Sessions, auditService, logger)
+	middleware.ValidateSessionsWith(sessionService)
 	logger.Info("initializing account service")
-	accountService := service.NewAccountServiceImpl(accountRepo, logger)
+	accountService := service.NewAccountServiceImpl(accountRepo, sessionService, auditService, logger)
+	logger.Info("initializing recovery service")
+	recoveryService := service.NewRecoveryServiceImpl(accountRepo, tokenRepo, transactor, mailQueue, cfg, auditService, logger)
+	logger.Info("initializing admin service")
+	adminService := service.NewAdminServiceImpl(accountRepo, adminRepo, sessionService, recoveryService, auditService, logger)
+	if err := adminService.Bootstrap(context.Background(), cfg.Admin.BootstrapUsernames); err != nil {
+		logger.Fatal(err)
+	}
+	logger.Info("initializing verification service")
+	verificationService := service.NewVerificationServiceImpl(accountRepo, tokenRepo, mailQueue, cfg, auditService, logger)
+	logger.Info("initializing lockout service")
+	lockoutService := service.NewLockoutServiceImpl(lockoutRepo, cfg.Lockout, auditService, logger)
+	logger.Info("initializing two-factor service")
+	twoFactorService := service.NewTwoFactorServiceImpl(accountRepo, twoFactorRepo, sessionService, auditService, logger)
+	logger.Info("initializing privacy service")
+	privacyService := service.NewPrivacyServiceImpl(accountRepo, exportRepo, cfg.Accounts, auditService, logger)
+	workers := shutdown.NewWorkers()
+	workers.Go(mailQueue.Run)
+	workers.Go(func(ctx context.Context) {
+		purgeDeletedAccounts(ctx, privacyService, cfg.Accounts.PurgeInterval, logger)
+	})
 	logger.Info("initializing note service")
-	noteService := service.NewNoteServiceImpl(noteRepo, tagRepo, logger)
+	noteService := service.NewNoteServiceImpl(noteRepo, tagRepo, auditService, logger)
 	logger.Info("initializing tag service")
-	tagService := service.NewTagServiceImpl(noteRepo, tagRepo, logger)
+	tagService := service.NewTagServiceImpl(noteRepo, tagRepo, auditService, logger)
+	logger.Info("initializing template service")
+	templateService := service.NewTemplateServiceImpl(templateRepo, accountRepo, transactor, auditService, logger)
+	logger.Info("initializing batch service")
+	batchService := service.NewBatchServiceImpl(transactor, cfg.Batch.MaxOperations, auditService, logger)
+	logger.Info("initializing stats service")
+	statsService := service.NewStatsServiceImpl(statsRepo, tagRepo, logger)
 
 	logger.Info("initializing account mapper")
 	accountMapper := mapper.NewAccountMapper(logger)
@@ -69,18 +264,182 @@ func main() {
 	noteMapper := mapper.NewNoteMapper(logger)
 	logger.Info("initializing tag mapper")
 	tagMapper := mapper.NewTagMapper(logger)
+	logger.Info("initializing template mapper")
+	templateMapper := mapper.NewTemplateMapper(logger)
+	logger.Info("initializing batch mapper")
+	batchMapper := mapper.NewBatchMapper(logger)
+
+	if cfg.RateLimit.Enabled {
+		logger.Info("Configure rate limits")
+		rules := rateLimitRules(cfg.RateLimit.Groups)
+		var limiter *ratelimit.Limiter
+		if cfg.RateLimit.Store == session.RateLimitStorePostgres {
+			rateLimitRepo := repository.NewRateLimitRepositoryImpl(client, logger)
+			limiter = ratelimit.NewLimiter(rateLimitRepo, rules...)
+			workers.Go(func(ctx context.Context) {
+				purgeRateLimits(ctx, rateLimitRepo, limiter.FullAfter(), cfg.RateLimit.PurgeInterval, logger)
+			})
+		} else {
+			limiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(), rules...)
+		}
+		router.Use(middleware.RateLimit(limiter))
+	}
+
+	if cfg.Verification.UnverifiedAccess == session.UnverifiedAccessReadOnly {
+		logger.Info("Restrict accounts with unverified email to read-only access")
+		router.Use(middleware.RestrictUnverified(verificationService))
+	}
 
 	logger.Info("initializing account handler")
-	accountHandler := account.NewHandler(logger, *accountService, *accountMapper)
+	accountHandler := account.NewHandler(logger, *accountService, verificationService, lockoutService, auditService, *accountMapper)
 	accountHandler.Register(router)
 
+	logger.Info("initializing recovery handler")
+	recoveryHandler := recovery.NewHandler(logger, recoveryService)
+	recoveryHandler.Register(router)
+
+	logger.Info("initializing session handler")
+	sessionHandler := sessions.NewHandler(logger, sessionService)
+	sessionHandler.Register(router)
+
+	logger.Info("initializing two-factor handler")
+	twoFactorHandler := twofactor.NewHandler(logger, twoFactorService, lockoutService)
+	twoFactorHandler.Register(router)
+
+	if cfg.OIDC.Enabled {
+		logger.Info("initializing single sign-on service")
+		ssoService := service.NewSSOServiceImpl(accountRepo, oidcRepo, sessionService, cfg.OIDC, auditService, logger)
+		logger.Info("initializing single sign-on handler")
+		ssoHandler := sso.NewHandler(logger, ssoService, cfg.OIDC.FrontendURL, cfg.OIDC.FlowTTL)
+		ssoHandler.Register(router)
+	}
+
+	logger.Info("initializing admin handler")
+	adminHandler := admin.NewHandler(logger, adminService, auditService)
+	adminHandler.Register(router)
+
+	logger.Info("initializing privacy handler")
+	privacyHandler := privacy.NewHandler(logger, privacyService)
+	privacyHandler.Register(router)
+
 	logger.Info("initializing note handler")
-	noteHandler := note.NewHandler(logger, *noteService, *noteMapper)
+	noteHandler := note.NewHandler(logger, *noteService, templateService, *noteMapper)
 	noteHandler.Register(router)
 
+	logger.Info("initializing batch handler")
+	batchHandler := batch.NewHandler(logger, batchService, *batchMapper)
+	batchHandler.Register(router)
+
 	logger.Info("initializing tag handler")
 	tagHandler := tag.NewHandler(logger, tagService, *tagMapper)
 	tagHandler.Register(router)
 
-	server.Run(cfg, router, logger)
+	logger.Info("initializing template handler")
+	templateHandler := template.NewHandler(logger, templateService, *templateMapper)
+	templateHandler.Register(router)
+
+	logger.Info("initializing stats handler")
+	statsHandler := stats.NewHandler(logger, statsService)
+	statsHandler.Register(router)
+
+	apiServer := server.New(net.JoinHostPort(cfg.Listen.BindIP, cfg.Listen.Port), router, timeouts)
+	if cfg.Listen.TLS.Enabled {
+		logger.Info("Configure TLS")
+		if err := apiServer.EnableTLS(cfg.Listen.TLS.CertFile, cfg.Listen.TLS.KeyFile); err != nil {
+			logger.Fatal(err)
+		}
+		if cfg.Listen.TLS.RedirectPort != "" {
+			redirectServer := server.New(net.JoinHostPort(cfg.Listen.BindIP, cfg.Listen.TLS.RedirectPort),
+				server.RedirectHandler(cfg.Listen.Port), timeouts)
+			go redirectServer.Run(logger)
+			shutdownHooks = append(shutdownHooks, shutdown.Hook{Name: "redirect server", Shutdown: redirectServer.Shutdown})
+		}
+	}
+	workers.Go(func(ctx context.Context) {
+		reloadCertificates(ctx, apiServer, logger)
+	})
+	go apiServer.Run(logger)
+	logger.Println("application initialized and started")
+
+	shutdownHooks = append([]shutdown.Hook{
+		{Name: "readiness", Shutdown: healthRegistry.Drain},
+		{Name: "API server", Shutdown: apiServer.Shutdown},
+	}, shutdownHooks...)
+	shutdownHooks = append(shutdownHooks,
+		shutdown.Hook{Name: "background workers", Shutdown: workers.Shutdown},
+		shutdown.Hook{Name: "database", Shutdown: func(context.Context) error { return client.DB.Close() }},
+		shutdown.Hook{Name: "tracing", Shutdown: flushTraces},
+	)
+	shutdown.Graceful([]os.Signal{syscall.SIGABRT, syscall.SIGQUIT, os.Interrupt, syscall.SIGTERM},
+		cfg.Shutdown.Timeout, shutdownHooks...)
+}
+
+// reloadCertificates rereads TLS certificate of s on every SIGHUP, until ctx
+// is cancelled. SIGHUP is caught even without TLS, so it doesn't kill server.
+func reloadCertificates(ctx context.Context, s *server.Server, logger logging.Logger) {
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGHUP)
+	defer signal.Stop(sigc)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigc:
+			logger.Info("Caught SIGHUP, reloading TLS certificate")
+			if err := s.ReloadTLS(); err != nil {
+				logger.Errorf("Can't reload TLS certificate: %v", err)
+			}
+		}
+	}
+}
+
+// purgeDeletedAccounts periodically removes accounts whose deletion grace
+// period is over, until ctx is cancelled
+func purgeDeletedAccounts(ctx context.Context, s *service.PrivacyServiceImpl, interval time.Duration, logger logging.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.PurgeDeleted(ctx); err != nil {
+				logger.Errorf("Can't purge deleted accounts: %v", err)
+			}
+		}
+	}
+}
+
+func rateLimitRules(groups []session.RateLimitGroup) []ratelimit.Rule {
+	rules := make([]ratelimit.Rule, 0, len(groups))
+	for _, g := range groups {
+		rules = append(rules, ratelimit.Rule{
+			Name:    g.Name,
+			Path:    g.Path,
+			Methods: g.Methods,
+			Limit:   ratelimit.PerPeriod(g.Requests, g.Period, g.Burst),
+			KeyBy:   g.Key,
+		})
+	}
+	return rules
+}
+
+// purgeRateLimits periodically removes buckets which are full again, until
+// ctx is cancelled
+func purgeRateLimits(ctx context.Context, r *repository.RateLimitRepositoryImpl, idle, interval time.Duration, logger logging.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.PurgeIdle(ctx, time.Now().Add(-idle)); err != nil {
+				logger.Errorf("Can't purge rate limits: %v", err)
+			}
+		}
+	}
 }
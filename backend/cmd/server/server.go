@@ -1,51 +1,95 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
-	"fmt"
-	"neatly/internal/session"
 	"neatly/pkg/logging"
-	"neatly/pkg/shutdown"
 	"net"
 	"net/http"
-	"os"
-	"syscall"
 	"time"
 )
 
+// Timeouts of connection, zero means no timeout
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+	Idle  time.Duration
+}
+
 type Server struct {
 	httpServer *http.Server
+	certs      *certReloader
+}
+
+func New(address string, handler http.Handler, timeouts Timeouts) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:         address,
+			Handler:      handler,
+			ReadTimeout:  timeouts.Read,
+			WriteTimeout: timeouts.Write,
+			IdleTimeout:  timeouts.Idle,
+		},
+	}
 }
 
-func Run(cfg *session.Config, handler http.Handler, logger logging.Logger) {
-	var (
-		s        Server
-		listener net.Listener
-	)
+// EnableTLS makes server serve HTTPS with certificate from files. HTTP/2 is
+// negotiated with clients supporting it.
+func (s *Server) EnableTLS(certFile, keyFile string) error {
+	certs, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	s.certs = certs
+	s.httpServer.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+	}
+	return nil
+}
 
-	s.httpServer = &http.Server{
-		Handler:      handler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+// ReloadTLS rereads certificate files, new connections use new certificate.
+// Current certificate is kept if files can't be loaded.
+func (s *Server) ReloadTLS() error {
+	if s.certs == nil {
+		return nil
 	}
+	return s.certs.Reload()
+}
 
-	logger.Infof("trying to listen to %s:%s", cfg.Listen.BindIP, cfg.Listen.Port)
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.Listen.Port))
+// Run serves requests until Shutdown is called
+func (s *Server) Run(logger logging.Logger) {
+	logger.Infof("trying to listen to %s", s.httpServer.Addr)
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	go shutdown.Graceful([]os.Signal{syscall.SIGABRT, syscall.SIGQUIT, syscall.SIGHUP, os.Interrupt, syscall.SIGTERM},
-		s.httpServer)
-
-	logger.Println("application initialized and started")
+	if s.certs != nil {
+		logger.Printf("server on %s started with TLS", s.httpServer.Addr)
+		err = s.httpServer.ServeTLS(listener, "", "")
+	} else {
+		logger.Printf("server on %s started", s.httpServer.Addr)
+		err = s.httpServer.Serve(listener)
+	}
 
-	if err := s.httpServer.Serve(listener); err != nil {
+	if err != nil {
 		switch {
 		case errors.Is(err, http.ErrServerClosed):
-			logger.Warn("server shutdown")
+			logger.Warnf("server on %s shutdown", s.httpServer.Addr)
 		default:
 			logger.Fatal(err)
 		}
 	}
 }
+
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish until ctx expires, then remaining connections are closed
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.httpServer.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.httpServer.Close()
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+//go:build unit
+// +build unit
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/go-playground/assert/v2"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCert(t *testing.T, dir, commonName string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, r *certReloader) string {
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "old.neat.ly")
+
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "old.neat.ly", commonName(t, r))
+
+	writeCert(t, dir, "new.neat.ly")
+	assert.Equal(t, nil, r.Reload())
+	assert.Equal(t, "new.neat.ly", commonName(t, r))
+
+	if err := os.WriteFile(keyFile, []byte("broken"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, nil, r.Reload())
+	assert.Equal(t, "new.neat.ly", commonName(t, r))
+}
+
+func TestRedirectHandler(t *testing.T) {
+	testSuites := []struct {
+		testName  string
+		httpsPort string
+		target    string
+		expected  string
+	}{
+		{
+			testName:  "DefaultPort",
+			httpsPort: "443",
+			target:    "http://neat.ly:80/api/v1/notes?archived=true",
+			expected:  "https://neat.ly/api/v1/notes?archived=true",
+		},
+		{
+			testName:  "CustomPort",
+			httpsPort: "8443",
+			target:    "http://neat.ly/api/v1/notes",
+			expected:  "https://neat.ly:8443/api/v1/notes",
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			RedirectHandler(testSuite.httpsPort).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, testSuite.target, nil))
+
+			assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+			assert.Equal(t, testSuite.expected, rec.Header().Get("Location"))
+		})
+	}
+}
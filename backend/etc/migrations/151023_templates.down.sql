@@ -0,0 +1,3 @@
+DROP TABLE users_templates CASCADE;
+
+DROP TABLE templates CASCADE;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE templates (
+    id SERIAL NOT NULL UNIQUE,
+    header VARCHAR(255) NOT NULL,
+    body TEXT,
+    color VARCHAR(6) NOT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE TABLE users_templates (
+    id SERIAL NOT NULL UNIQUE,
+    users_id INT REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    templates_id INT REFERENCES templates(id) ON DELETE CASCADE NOT NULL
+);
@@ -0,0 +1,5 @@
+ALTER TABLE notes DROP COLUMN favourite;
+
+ALTER TABLE notes DROP COLUMN archived;
+
+ALTER TABLE notes DROP COLUMN pinned;
\ No newline at end of file
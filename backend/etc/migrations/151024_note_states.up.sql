@@ -0,0 +1,5 @@
+ALTER TABLE notes ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE notes ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE notes ADD COLUMN favourite BOOLEAN NOT NULL DEFAULT FALSE;
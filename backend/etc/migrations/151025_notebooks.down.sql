@@ -0,0 +1,3 @@
+DROP INDEX notes_notebook_idx;
+
+ALTER TABLE notes DROP COLUMN notebook;
@@ -0,0 +1,3 @@
+ALTER TABLE notes ADD COLUMN notebook TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX notes_notebook_idx ON notes (notebook);
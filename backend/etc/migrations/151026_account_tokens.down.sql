@@ -0,0 +1 @@
+DROP TABLE account_tokens CASCADE;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE account_tokens (
+    id SERIAL NOT NULL UNIQUE,
+    users_id INT REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE
+);
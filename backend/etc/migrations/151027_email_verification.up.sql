@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- accounts registered before verification was introduced are trusted
+UPDATE users SET email_verified = TRUE;
\ No newline at end of file
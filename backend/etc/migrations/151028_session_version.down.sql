@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN session_version;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN delete_after TIMESTAMP WITH TIME ZONE;
\ No newline at end of file
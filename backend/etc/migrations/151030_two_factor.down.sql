@@ -0,0 +1,7 @@
+DROP TABLE recovery_codes CASCADE;
+
+ALTER TABLE users DROP COLUMN totp_last_step;
+
+ALTER TABLE users DROP COLUMN totp_enabled;
+
+ALTER TABLE users DROP COLUMN totp_secret;
\ No newline at end of file
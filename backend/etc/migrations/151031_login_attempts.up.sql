@@ -0,0 +1,6 @@
+CREATE TABLE login_attempts (
+    key VARCHAR(320) NOT NULL PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE oidc_flows;
+
+DROP TABLE external_identities CASCADE;
\ No newline at end of file
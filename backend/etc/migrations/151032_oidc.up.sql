@@ -0,0 +1,14 @@
+CREATE TABLE external_identities (
+    id SERIAL NOT NULL UNIQUE,
+    users_id INT REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    UNIQUE (issuer, subject)
+);
+
+CREATE TABLE oidc_flows (
+    state VARCHAR(64) NOT NULL PRIMARY KEY,
+    verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
\ No newline at end of file
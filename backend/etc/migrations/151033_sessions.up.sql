@@ -0,0 +1,11 @@
+CREATE TABLE sessions (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    users_id INT REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX sessions_users_id_idx ON sessions (users_id);
\ No newline at end of file
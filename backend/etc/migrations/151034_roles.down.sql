@@ -0,0 +1,5 @@
+ALTER TABLE sessions DROP COLUMN impersonator_id;
+
+ALTER TABLE users DROP COLUMN disabled;
+
+ALTER TABLE users DROP COLUMN role;
\ No newline at end of file
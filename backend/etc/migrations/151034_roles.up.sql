@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user';
+
+ALTER TABLE users ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE sessions ADD COLUMN impersonator_id INT REFERENCES users(id) ON DELETE CASCADE;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE rate_limits (
+    key VARCHAR(320) NOT NULL PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX rate_limits_updated_at_idx ON rate_limits (updated_at);
\ No newline at end of file
@@ -2,6 +2,7 @@ package e2e_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/assert/v2"
@@ -11,16 +12,18 @@ import (
 	"neatly/internal/model"
 	"neatly/internal/repository"
 	"neatly/internal/service"
+	"neatly/internal/session"
 	"neatly/pkg/dbclient"
 	"neatly/pkg/logging"
+	"neatly/pkg/mail"
 	"neatly/pkg/testutils"
 	"net/http/httptest"
 	"testing"
 )
 
 var (
-	registerAccountRequestBody = `{"name": "TestUser", "username": "testuser", "email": "test@user.com", "password": "testusertestuser"}`
-	testAccount                = model.Account{Name: "TestUser", Username: "testuser", Email: "test@user.com", Password: "testusertestuser"}
+	registerAccountRequestBody = `{"name": "TestUser", "username": "testuser", "email": "test@user.com", "password": "testuser-testuser"}`
+	testAccount                = model.Account{Name: "TestUser", Username: "testuser", Email: "test@user.com", Password: "testuser-testuser"}
 )
 
 func TestE2E_AccountCreateAndAuthorize(t *testing.T) {
@@ -28,7 +31,7 @@ func TestE2E_AccountCreateAndAuthorize(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
-	middleware.CorsMiddleware(router)
+	middleware.CorsMiddleware(router, session.CORS{AllowOrigins: []string{"*"}})
 
 	logging.Init()
 	logger := logging.GetLogger()
@@ -39,10 +42,17 @@ func TestE2E_AccountCreateAndAuthorize(t *testing.T) {
 	}
 
 	repo := repository.NewAccountRepositoryImpl(client, logger)
-	serv := service.NewAccountServiceImpl(repo, logger)
+	audit := service.NewAuditServiceImpl(repository.NewAuditRepositoryImpl(client, logger), logger)
+	sessions := service.NewSessionServiceImpl(repository.NewSessionRepositoryImpl(client, logger), session.Sessions{}, audit, logger)
+	serv := service.NewAccountServiceImpl(repo, sessions, audit, logger)
 	mppr := mapper.NewAccountMapper(logger)
 
-	handler := account.NewHandler(logger, *serv, *mppr)
+	tokens := repository.NewTokenRepositoryImpl(client, logger)
+	verification := service.NewVerificationServiceImpl(repo, tokens, mail.NewLogMailer("", logger), &session.Config{}, audit, logger)
+
+	lockout := service.NewLockoutServiceImpl(repository.NewLockoutRepositoryImpl(client, logger), session.Lockout{}, audit, logger)
+
+	handler := account.NewHandler(logger, *serv, verification, lockout, audit, *mppr)
 	handler.Register(router)
 
 	expectedUserID := 1
@@ -61,7 +71,7 @@ func TestE2E_AccountCreateAndAuthorize(t *testing.T) {
 		assert.Equal(t, w.Code, 201)
 		assert.Equal(t, w.Body.String(), fmt.Sprintf(`"/api/v1/accounts/%v"`, expectedUserID))
 
-		canAuth := repo.AuthorizeAccount(&testAccount)
+		canAuth := repo.AuthorizeAccount(context.Background(), &testAccount)
 		if canAuth != nil {
 			t.Fatalf("[repository] Can't authorize test account: %s", err)
 		}
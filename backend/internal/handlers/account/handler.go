@@ -1,34 +1,57 @@
 package account
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"io"
+	"math"
+	"neatly/internal/handlers/middleware"
 	"neatly/internal/mapper"
 	"neatly/internal/model"
 	"neatly/internal/model/dto"
 	"neatly/internal/service"
 	"neatly/pkg/e"
 	"neatly/pkg/logging"
+	"neatly/pkg/password"
 	"net/http"
+	"strconv"
 )
 
 const (
 	accountsURLGroup = "/accounts"
 	registerURL      = "/register"
 	loginURL         = "/login"
+	verifyURL        = "/verify"
+	resendURL        = "/verify/resend"
+	meURL            = "/me"
+	passwordURL      = "/me/password"
+	auditURL         = "/me/audit"
 	apiURLGroup      = "/api"
 	apiVersion       = "1"
 )
 
 type Handler struct {
-	logger  logging.Logger
-	service service.AccountServiceImpl
-	mapper  mapper.AccountMapper
+	logger              logging.Logger
+	service             service.AccountServiceImpl
+	verificationService *service.VerificationServiceImpl
+	lockoutService      *service.LockoutServiceImpl
+	auditService        *service.AuditServiceImpl
+	mapper              mapper.AccountMapper
 }
 
-func NewHandler(logger logging.Logger, service service.AccountServiceImpl, mapper mapper.AccountMapper) *Handler {
-	return &Handler{logger: logger, service: service, mapper: mapper}
+func NewHandler(logger logging.Logger, service service.AccountServiceImpl, verificationService *service.VerificationServiceImpl,
+	lockoutService *service.LockoutServiceImpl, auditService *service.AuditServiceImpl, mapper mapper.AccountMapper) *Handler {
+	return &Handler{
+		logger:              logger,
+		service:             service,
+		verificationService: verificationService,
+		lockoutService:      lockoutService,
+		auditService:        auditService,
+		mapper:              mapper,
+	}
 }
 
 func (h *Handler) Register(router *gin.Engine) {
@@ -40,18 +63,25 @@ func (h *Handler) Register(router *gin.Engine) {
 	{
 		auth.POST(registerURL, h.RegisterAccount)
 		auth.POST(loginURL, h.Login)
+		auth.GET(verifyURL, h.VerifyEmail)
+		auth.POST(resendURL, middleware.Authenticate, h.ResendVerification)
+		auth.GET(meURL, middleware.Authenticate, h.GetMe)
+		auth.PATCH(meURL, middleware.Authenticate, h.UpdateMe)
+		auth.POST(passwordURL, middleware.Authenticate, middleware.ForbidImpersonation, h.ChangePassword)
+		auth.GET(auditURL, middleware.Authenticate, h.GetAudit)
 	}
 }
 
 // RegisterAccount creates account
 // @Summary RegisterAccount
 // @Tags account
-// @Description create account
+// @Description create account and send verification link to its email
 // @ID create-account
 // @Accept  json
 // @Produce  json
 // @Param dto body dto.RegisterAccountDTO true "account info"
 // @Success 201 {string} string 1
+// @Failure 400 {object} e.ErrorResponse
 // @Failure 500 {object} e.ErrorResponse
 // @Failure 409 {object} e.ErrorResponse
 // @Failure default {object} e.ErrorResponse
@@ -62,24 +92,28 @@ func (h *Handler) RegisterAccount(ctx *gin.Context) {
 		in  dto.RegisterAccountDTO
 		a   model.Account
 	)
-	h.logger.Info("Got registration request")
+	h.logger.WithContext(ctx.Request.Context()).Info("Got registration request")
 
 	if err = ctx.BindJSON(&in); err != nil {
-		h.logger.Error(err)
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
 		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
 		return
 	}
 
 	a, err = h.mapper.MapRegisterAccountDTO(in)
 	if err != nil {
-		h.logger.Error(err)
-		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
+		if errors.Is(err, e.ClientEmailError) || errors.Is(err, e.ClientPasswordPolicyError) {
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
 		return
 	}
 
-	h.logger.Infof("CreateAccountDTO mapped: %v", a)
+	h.logger.WithContext(ctx.Request.Context()).Infof("Registration request mapped: %v", a)
 
-	err = h.service.CreateAccount(&a)
+	err = h.service.CreateAccount(ctx.Request.Context(), &a)
 	if err != nil {
 		if errors.Is(err, e.ClientAccountError) {
 			e.NewErrorResponse(ctx, http.StatusConflict, err)
@@ -89,7 +123,11 @@ func (h *Handler) RegisterAccount(ctx *gin.Context) {
 		return
 	}
 
-	h.logger.Infof("Inserted into database successfully: account id is %v", a.ID)
+	h.logger.WithContext(ctx.Request.Context()).Infof("Inserted into database successfully: account id is %v", a.ID)
+
+	if err = h.verificationService.Send(ctx.Request.Context(), a); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Errorf("Can't issue verification token for account %v: %v", a.ID, err)
+	}
 
 	ctx.JSON(http.StatusCreated, fmt.Sprintf(
 		"%v/v%v%v/%v",
@@ -103,12 +141,16 @@ func (h *Handler) RegisterAccount(ctx *gin.Context) {
 // Login
 // @Summary Login
 // @Tags account
-// @Description login
+// @Description login, if two-factor authentication is enabled challenge token is returned with 202
 // @ID login
 // @Accept  json
 // @Produce  json
 // @Param dto body dto.LoginAccountDTO true "credentials"
 // @Success 200 {object} dto.WithTokenDTO
+// @Success 202 {object} dto.TwoFactorChallengeDTO
+// @Failure 401 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 429 {object} e.ErrorResponse
 // @Failure 500 {object} e.ErrorResponse
 // @Failure default {object} e.ErrorResponse
 // @Router /api/v1/accounts/login [post]
@@ -116,21 +158,316 @@ func (h *Handler) Login(ctx *gin.Context) {
 	var loginDto dto.LoginAccountDTO
 
 	if err := ctx.BindJSON(&loginDto); err != nil {
-		h.logger.Error(err)
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
 		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
 		return
 	}
 
 	a := h.mapper.MapLogInAccountDTO(loginDto)
+	username, ip := a.Username, ctx.ClientIP()
 
-	token, err := h.service.GenerateJWT(&a)
+	retryAfter, err := h.lockoutService.Check(ctx.Request.Context(), username, ip)
 	if err != nil {
+		if errors.Is(err, e.LoginLockedError) {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			e.NewErrorResponse(ctx, http.StatusTooManyRequests, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	token, err := h.service.GenerateJWT(ctx.Request.Context(), &a, middleware.GetClient(ctx))
+	if errors.Is(err, e.AccountDisabledError) {
+		e.NewErrorResponse(ctx, http.StatusForbidden, err)
+		return
+	}
+	// only wrong credentials count as failed attempt, outage must not lock
+	// users out
+	if errors.Is(err, password.ErrMismatch) {
+		// not bound to request, otherwise client could dodge lockout by
+		// disconnecting right after failed attempt
+		failCtx := logging.WithRequestID(context.Background(), logging.RequestID(ctx.Request.Context()))
+		if err := h.lockoutService.Fail(failCtx, username, ip); err != nil {
+			h.logger.WithContext(ctx.Request.Context()).Error(err)
+		}
 		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
 		return
 	}
-	ctx.SetCookie("token", token, 36000, "/", "localhost", false, true)
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	// failures are forgotten only after second factor, if account has one
+	if a.TOTPEnabled {
+		ctx.JSON(http.StatusAccepted, dto.TwoFactorChallengeDTO{ChallengeToken: token})
+		return
+	}
+	if err := h.lockoutService.Succeed(ctx.Request.Context(), username); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
+	}
+	middleware.SetAuthCookie(ctx, token)
 
 	loginWithTokenDto := h.mapper.MapAccountWithTokenDTO(token, a)
 
 	ctx.JSON(http.StatusOK, loginWithTokenDto)
 }
+
+// VerifyEmail
+// @Summary VerifyEmail
+// @Tags account
+// @Description verify account email using token from verification link
+// @ID verify-email
+// @Produce  json
+// @Param token query string true "verification token"
+// @Success 204
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/verify [get]
+func (h *Handler) VerifyEmail(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		e.NewErrorResponse(ctx, http.StatusBadRequest, e.ClientTokenError)
+		return
+	}
+
+	err := h.verificationService.Verify(ctx.Request.Context(), token)
+	if err != nil {
+		if errors.Is(err, e.ClientTokenError) {
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Writer.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerification
+// @Summary ResendVerification
+// @Security ApiKeyAuth
+// @Tags account
+// @Description send new verification link, links sent earlier stop working
+// @ID resend-verification
+// @Produce  json
+// @Success 202
+// @Failure 409 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/verify/resend [post]
+func (h *Handler) ResendVerification(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	err = h.verificationService.Resend(ctx.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, e.ClientVerifiedError) {
+			e.NewErrorResponse(ctx, http.StatusConflict, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Writer.WriteHeader(http.StatusAccepted)
+}
+
+// GetMe
+// @Summary GetMe
+// @Security ApiKeyAuth
+// @Tags account
+// @Description get profile of current account
+// @ID get-me
+// @Produce  json
+// @Success 200 {object} dto.GetAccountDTO
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me [get]
+func (h *Handler) GetMe(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	a, err := h.service.GetOne(ctx.Request.Context(), userID)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, h.mapper.MapAccountDTO(a))
+}
+
+// UpdateMe
+// @Summary UpdateMe
+// @Security ApiKeyAuth
+// @Tags account
+// @Description partially update profile of current account, changed email has to be verified again
+// @ID update-me
+// @Accept  json
+// @Produce  json
+// @Param dto body dto.UpdateAccountDTO true "profile fields to change"
+// @Success 200 {object} dto.GetAccountDTO
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 409 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me [patch]
+func (h *Handler) UpdateMe(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	bodyBytes, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var (
+		in   dto.UpdateAccountDTO
+		data map[string]interface{}
+		mask model.AccountUpdateMask
+	)
+	if err := json.Unmarshal(bodyBytes, &in); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if err := json.Unmarshal(bodyBytes, &data); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	_, mask.Name = data["name"]
+	_, mask.Username = data["username"]
+	_, mask.Email = data["email"]
+
+	a, err := h.mapper.MapUpdateAccountDTO(in, mask)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	a, err = h.service.Update(ctx.Request.Context(), userID, a, mask)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ClientProfileError):
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		case errors.Is(err, e.ClientAccountError):
+			e.NewErrorResponse(ctx, http.StatusConflict, err)
+		default:
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if mask.Email && !a.Verified {
+		if err = h.verificationService.Send(ctx.Request.Context(), a); err != nil {
+			h.logger.WithContext(ctx.Request.Context()).Errorf("Can't issue verification token for account %v: %v", a.ID, err)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, h.mapper.MapAccountDTO(a))
+}
+
+// ChangePassword
+// @Summary ChangePassword
+// @Security ApiKeyAuth
+// @Tags account
+// @Description change password of current account, other sessions are revoked
+// @ID change-password
+// @Accept  json
+// @Produce  json
+// @Param dto body dto.ChangePasswordDTO true "current and new password"
+// @Success 200 {object} dto.TokenDTO
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me/password [post]
+func (h *Handler) ChangePassword(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	var in dto.ChangePasswordDTO
+	if err := ctx.BindJSON(&in); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	token, err := h.service.ChangePassword(ctx.Request.Context(), userID, in.CurrentPassword, in.NewPassword, middleware.GetClient(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ClientPasswordError):
+			e.NewErrorResponse(ctx, http.StatusForbidden, err)
+		case errors.Is(err, e.ClientPasswordPolicyError):
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		default:
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	middleware.SetAuthCookie(ctx, token)
+
+	ctx.JSON(http.StatusOK, dto.TokenDTO{Token: token})
+}
+
+// GetAudit
+// @Summary GetAudit
+// @Security ApiKeyAuth
+// @Tags account
+// @Description get audit log of current account, newest entries first: logins, password changes, issued tokens, changes of notes and tags and actions of admins
+// @ID get-me-audit
+// @Produce  json
+// @Param limit query int false "page size, 50 by default and 100 at most"
+// @Param offset query int false "page offset"
+// @Success 200 {array} model.AuditEntry
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me/audit [get]
+func (h *Handler) GetAudit(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	var page model.AuditPage
+	if limit := ctx.Query("limit"); limit != "" {
+		if page.Limit, err = strconv.Atoi(limit); err != nil {
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if offset := ctx.Query("offset"); offset != "" {
+		if page.Offset, err = strconv.Atoi(offset); err != nil {
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	entries, err := h.auditService.GetByUser(ctx.Request.Context(), userID, page)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,79 @@
+//go:build unit
+// +build unit
+
+package account
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"neatly/internal/mapper"
+	"neatly/internal/model"
+	"neatly/internal/service"
+	"neatly/pkg/logging"
+	"neatly/pkg/password"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// accountServiceStub fails every login with err
+type accountServiceStub struct {
+	service.AccountService
+	err error
+}
+
+func (s accountServiceStub) GenerateJWT(context.Context, *model.Account, model.Client) (string, error) {
+	return "", s.err
+}
+
+// lockoutStub never locks and counts failed attempts
+type lockoutStub struct {
+	service.LockoutService
+	failed int
+}
+
+func (l *lockoutStub) Check(context.Context, string, string) (time.Duration, error) {
+	return 0, nil
+}
+
+func (l *lockoutStub) Fail(context.Context, string, string) error {
+	l.failed++
+	return nil
+}
+
+func TestHandler_Login_Errors(t *testing.T) {
+	logging.Configure(logging.Config{Level: "error", Format: logging.FormatText, Outputs: []string{logging.OutputStderr}})
+	gin.SetMode(gin.TestMode)
+
+	testSuites := []struct {
+		testName       string
+		err            error
+		expectedCode   int
+		expectedFailed int
+	}{
+		{"WrongCredentials", password.ErrMismatch, http.StatusUnauthorized, 1},
+		{"DatabaseError", errors.New("connection refused"), http.StatusInternalServerError, 0},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			lockout := &lockoutStub{}
+			logger := logging.GetLogger()
+			h := NewHandler(logger, service.AccountServiceImpl{AccountService: accountServiceStub{err: testSuite.err}},
+				nil, &service.LockoutServiceImpl{LockoutService: lockout}, nil, *mapper.NewAccountMapper(logger))
+			router := gin.New()
+			h.Register(router)
+
+			body := strings.NewReader(`{"username":"user","password":"password"}`)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/accounts/login", body))
+
+			assert.Equal(t, testSuite.expectedCode, rec.Code)
+			assert.Equal(t, testSuite.expectedFailed, lockout.failed)
+		})
+	}
+}
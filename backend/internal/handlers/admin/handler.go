@@ -0,0 +1,308 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"neatly/internal/handlers/middleware"
+	"neatly/internal/model"
+	"neatly/internal/model/dto"
+	"neatly/internal/service"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"net/http"
+	"strconv"
+)
+
+const (
+	adminURLGroup  = "/admin"
+	usersURL       = "/users"
+	userURL        = "/users/:id"
+	disableURL     = "/users/:id/disable"
+	enableURL      = "/users/:id/enable"
+	resetURL       = "/users/:id/password/reset"
+	impersonateURL = "/users/:id/impersonate"
+	auditURL       = "/audit"
+	apiURLGroup    = "/api"
+	apiVersion     = "1"
+)
+
+type Handler struct {
+	logger       logging.Logger
+	service      *service.AdminServiceImpl
+	auditService *service.AuditServiceImpl
+}
+
+func NewHandler(logger logging.Logger, service *service.AdminServiceImpl, auditService *service.AuditServiceImpl) *Handler {
+	return &Handler{logger: logger, service: service, auditService: auditService}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	groupName := fmt.Sprintf("%v/v%v%v", apiURLGroup, apiVersion, adminURLGroup)
+
+	h.logger.Tracef("Register route: %v", groupName)
+
+	group := router.Group(groupName, middleware.Authenticate, middleware.RequireAdmin(h.service))
+	{
+		group.GET(usersURL, h.search)              // /api/v1/admin/users
+		group.GET(userURL, h.getOne)               // /api/v1/admin/users/:id
+		group.POST(disableURL, h.disable)          // /api/v1/admin/users/:id/disable
+		group.POST(enableURL, h.enable)            // /api/v1/admin/users/:id/enable
+		group.POST(resetURL, h.forcePasswordReset) // /api/v1/admin/users/:id/password/reset
+		group.POST(impersonateURL, h.impersonate)  // /api/v1/admin/users/:id/impersonate
+		group.GET(auditURL, h.audit)               // /api/v1/admin/audit
+	}
+}
+
+// @Summary List users
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description list users with note and tag counts, q searches name, username and email
+// @ID admin-search-users
+// @Produce  json
+// @Param q query string false "search query"
+// @Param limit query int false "page size, 50 by default and 100 at most"
+// @Param offset query int false "page offset"
+// @Success 200 {array} model.AdminAccount
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/admin/users [get]
+func (h *Handler) search(ctx *gin.Context) {
+	var (
+		search = model.AdminSearch{Query: ctx.Query("q")}
+		err    error
+	)
+
+	if limit := ctx.Query("limit"); limit != "" {
+		if search.Limit, err = strconv.Atoi(limit); err != nil {
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if offset := ctx.Query("offset"); offset != "" {
+		if search.Offset, err = strconv.Atoi(offset); err != nil {
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	accounts, err := h.service.Search(ctx.Request.Context(), search)
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accounts)
+}
+
+// @Summary Get user
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description get user with note and tag counts
+// @ID admin-get-user
+// @Produce  json
+// @Param id path int true "user id"
+// @Success 200 {object} model.AdminAccount
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/admin/users/{id} [get]
+func (h *Handler) getOne(ctx *gin.Context) {
+	_, userID, ok := h.ids(ctx)
+	if !ok {
+		return
+	}
+
+	a, err := h.service.GetOne(ctx.Request.Context(), userID)
+	if err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, a)
+}
+
+// @Summary Disable user
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description disable account, it is signed out everywhere and can not log in
+// @ID admin-disable-user
+// @Param id path int true "user id"
+// @Success 204
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure 409 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/admin/users/{id}/disable [post]
+func (h *Handler) disable(ctx *gin.Context) {
+	h.setDisabled(ctx, true)
+}
+
+// @Summary Enable user
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description enable previously disabled account
+// @ID admin-enable-user
+// @Param id path int true "user id"
+// @Success 204
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure 409 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/admin/users/{id}/enable [post]
+func (h *Handler) enable(ctx *gin.Context) {
+	h.setDisabled(ctx, false)
+}
+
+func (h *Handler) setDisabled(ctx *gin.Context, disabled bool) {
+	adminID, userID, ok := h.ids(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetDisabled(ctx.Request.Context(), adminID, userID, disabled); err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Force password reset
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description replace password of user with random one and mail password reset link
+// @ID admin-reset-password
+// @Param id path int true "user id"
+// @Success 204
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure 409 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/admin/users/{id}/password/reset [post]
+func (h *Handler) forcePasswordReset(ctx *gin.Context) {
+	adminID, userID, ok := h.ids(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.service.ForcePasswordReset(ctx.Request.Context(), adminID, userID); err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// @Summary Impersonate user
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description get access token of user for support, session is marked with admin id and audited
+// @ID admin-impersonate-user
+// @Produce  json
+// @Param id path int true "user id"
+// @Success 200 {object} dto.TokenDTO
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure 409 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/admin/users/{id}/impersonate [post]
+func (h *Handler) impersonate(ctx *gin.Context) {
+	adminID, userID, ok := h.ids(ctx)
+	if !ok {
+		return
+	}
+
+	token, err := h.service.Impersonate(ctx.Request.Context(), adminID, userID, middleware.GetClient(ctx))
+	if err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.TokenDTO{Token: token})
+}
+
+// @Summary Audit log
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description list audit log of all accounts, newest entries first, including events not bound to any account such as lockouts
+// @ID admin-audit
+// @Produce  json
+// @Param limit query int false "page size, 50 by default and 100 at most"
+// @Param offset query int false "page offset"
+// @Success 200 {array} model.AuditEntry
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/admin/audit [get]
+func (h *Handler) audit(ctx *gin.Context) {
+	var (
+		page model.AuditPage
+		err  error
+	)
+
+	if limit := ctx.Query("limit"); limit != "" {
+		if page.Limit, err = strconv.Atoi(limit); err != nil {
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if offset := ctx.Query("offset"); offset != "" {
+		if page.Offset, err = strconv.Atoi(offset); err != nil {
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	entries, err := h.auditService.GetAll(ctx.Request.Context(), page)
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
+
+// ids returns ID of admin who sent request and ID of user from path
+func (h *Handler) ids(ctx *gin.Context) (int, int, bool) {
+	adminID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return 0, 0, false
+	}
+
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return 0, 0, false
+	}
+
+	return adminID, userID, true
+}
+
+func (h *Handler) respondError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, e.ClientUserError), errors.Is(err, e.ClientAuthorizeError):
+		e.NewErrorResponse(ctx, http.StatusNotFound, e.ClientUserError)
+	case errors.Is(err, e.ClientAdminError), errors.Is(err, e.AccountDisabledError):
+		e.NewErrorResponse(ctx, http.StatusConflict, err)
+	default:
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,178 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"neatly/internal/handlers/middleware"
+	"neatly/internal/mapper"
+	"neatly/internal/model/dto"
+	"neatly/internal/service"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"net/http"
+	"strconv"
+)
+
+const (
+	notesURLGroup = "/notes"
+	batchURL      = "/batch"
+	mergeURL      = "/merge"
+	duplicateURL  = "/:id/duplicate"
+	apiURLGroup   = "/api"
+	apiVersion    = "1"
+)
+
+type Handler struct {
+	logger  logging.Logger
+	service *service.BatchServiceImpl
+	mapper  mapper.BatchMapper
+}
+
+func NewHandler(logger logging.Logger, service *service.BatchServiceImpl, mapper mapper.BatchMapper) *Handler {
+	return &Handler{logger: logger, service: service, mapper: mapper}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	groupName := fmt.Sprintf("%v/v%v%v", apiURLGroup, apiVersion, notesURLGroup)
+
+	h.logger.Tracef("Register route: %v%v", groupName, batchURL)
+	h.logger.Tracef("Register route: %v%v", groupName, mergeURL)
+	h.logger.Tracef("Register route: %v%v", groupName, duplicateURL)
+
+	group := router.Group(groupName, middleware.Authenticate)
+	{
+		group.POST(batchURL, h.applyBatch)        // /api/v1/notes/batch
+		group.POST(mergeURL, h.mergeNotes)        // /api/v1/notes/merge
+		group.POST(duplicateURL, h.duplicateNote) // /api/v1/notes/:id/duplicate
+	}
+}
+
+// @Summary Apply batch of note operations
+// @Security ApiKeyAuth
+// @Tags notes
+// @Description run delete, recolor, add_tag, remove_tag, move (to notebook) and archive operations over many notes in one transaction
+// @ID batch-notes
+// @Accept  json
+// @Produce json
+// @Param dto body dto.BatchDTO true "operations"
+// @Success 200 {object} dto.BatchResultDTO
+// @Failure 422 {object} dto.BatchResultDTO
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/notes/batch [post]
+func (h *Handler) applyBatch(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var batchDTO dto.BatchDTO
+	if err := ctx.BindJSON(&batchDTO); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := h.service.Apply(ctx.Request.Context(), userID, batchDTO.Operations)
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ClientBatchError):
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		case errors.Is(err, e.BatchAbortedError):
+			ctx.JSON(http.StatusUnprocessableEntity, h.mapper.MapBatchResultDTO(false, results))
+		default:
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, h.mapper.MapBatchResultDTO(true, results))
+}
+
+// @Summary Duplicate note
+// @Security ApiKeyAuth
+// @Tags notes
+// @Description copy note header, body, color and tags into a new note
+// @ID duplicate-note
+// @Accept  json
+// @Produce json
+// @Param   id   path string  true  "id"
+// @Success 201 {string} string 1
+// @Failure 404 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/notes/{id}/duplicate [post]
+func (h *Handler) duplicateNote(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	noteID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	n, err := h.service.Duplicate(ctx.Request.Context(), userID, noteID)
+	if err != nil {
+		if errors.Is(err, e.ClientNoteError) {
+			e.NewErrorResponse(ctx, http.StatusNotFound, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, fmt.Sprintf(
+		"%s/v%v%s/%v", apiURLGroup, apiVersion, notesURLGroup, n.ID))
+}
+
+// @Summary Merge notes
+// @Security ApiKeyAuth
+// @Tags notes
+// @Description concatenate bodies of notes in given order into a new note with union of their tags, then delete or archive source notes
+// @ID merge-notes
+// @Accept  json
+// @Produce json
+// @Param dto body dto.MergeNotesDTO true "notes to merge"
+// @Success 201 {string} string 1
+// @Failure 400,404 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/notes/merge [post]
+func (h *Handler) mergeNotes(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var mergeDTO dto.MergeNotesDTO
+	if err := ctx.BindJSON(&mergeDTO); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	n, err := h.service.Merge(ctx.Request.Context(), userID, h.mapper.MapMergeNotesDTO(mergeDTO))
+	if err != nil {
+		switch {
+		case errors.Is(err, e.ClientBatchError):
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		case errors.Is(err, e.ClientNoteError):
+			e.NewErrorResponse(ctx, http.StatusNotFound, err)
+		default:
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, fmt.Sprintf(
+		"%s/v%v%s/%v", apiURLGroup, apiVersion, notesURLGroup, n.ID))
+}
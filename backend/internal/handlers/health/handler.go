@@ -0,0 +1,70 @@
+package health
+
+import (
+	"github.com/gin-gonic/gin"
+	"neatly/internal/model"
+	"neatly/pkg/health"
+	"neatly/pkg/logging"
+	"net/http"
+)
+
+const (
+	livenessURL  = "/healthz"
+	readinessURL = "/readyz"
+)
+
+type Handler struct {
+	logger   logging.Logger
+	registry *health.Registry
+}
+
+func NewHandler(logger logging.Logger, registry *health.Registry) *Handler {
+	return &Handler{logger: logger, registry: registry}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	h.logger.Tracef("Register route: %v", livenessURL)
+	router.GET(livenessURL, h.live)
+	h.logger.Tracef("Register route: %v", readinessURL)
+	router.GET(readinessURL, h.ready)
+}
+
+// @Summary Liveness probe
+// @Tags health
+// @Description reports that process is up, it doesn't check dependencies
+// @ID get-healthz
+// @Produce json
+// @Success 200 {object} model.Health
+// @Router /healthz [get]
+func (h *Handler) live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, model.Health{Status: model.HealthOK})
+}
+
+// @Summary Readiness probe
+// @Tags health
+// @Description reports whether instance can serve traffic: database is reachable, schema is migrated and other dependencies are healthy. Fails while instance is shutting down.
+// @ID get-readyz
+// @Produce json
+// @Success 200 {object} model.Health
+// @Failure 503 {object} model.Health
+// @Router /readyz [get]
+func (h *Handler) ready(ctx *gin.Context) {
+	results, ready := h.registry.Ready(ctx.Request.Context())
+
+	response := model.Health{Status: model.HealthOK, Checks: make(map[string]string, len(results))}
+	for name, err := range results {
+		if err != nil {
+			h.logger.WithContext(ctx.Request.Context()).Warnf("Readiness check %v failed: %v", name, err)
+			response.Checks[name] = err.Error()
+			continue
+		}
+		response.Checks[name] = model.HealthOK
+	}
+
+	if !ready {
+		response.Status = model.HealthFail
+		ctx.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
@@ -1,51 +1,219 @@
 package middleware
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"math"
+	"neatly/internal/model"
+	"neatly/internal/session"
 	"neatly/pkg/e"
 	"neatly/pkg/jwt"
 	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"neatly/pkg/ratelimit"
+	"neatly/pkg/tracing"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
 
 const (
 	authorizationHeader = "Authorization"
+	requestIDHeader     = "X-Request-ID"
+	csrfHeader          = "X-CSRF-Token"
+	csrfCookie          = "csrf_token"
+	maxRequestIDLength  = 128
 	userCtx             = "user_id"
+	sessionCtx          = "session_id"
+	impersonatorCtx     = "impersonator_id"
+	accountsPath        = "/api/v1/accounts"
+	unmatchedRoute      = "unmatched"
+
+	rateLimitLimitHeader     = "RateLimit-Limit"
+	rateLimitRemainingHeader = "RateLimit-Remaining"
+	rateLimitResetHeader     = "RateLimit-Reset"
+	retryAfterHeader         = "Retry-After"
 )
 
-func CorsMiddleware(router *gin.Engine) {
+type Verifier interface {
+	IsVerified(ctx context.Context, userID int) (bool, error)
+}
+
+type RoleChecker interface {
+	GetRole(ctx context.Context, userID int) (string, error)
+}
+
+type SessionValidator interface {
+	Validate(ctx context.Context, userID int, sessionID string) error
+}
+
+var sessionValidator SessionValidator
+
+// ValidateSessionsWith makes Authenticate reject tokens whose session has
+// been revoked, e.g. signed out remotely or by password change
+func ValidateSessionsWith(v SessionValidator) {
+	sessionValidator = v
+}
+
+var authCookie = session.Cookie{
+	Name:     "token",
+	Domain:   "localhost",
+	SameSite: session.SameSiteLax,
+	MaxAge:   10 * time.Hour,
+}
+
+// UseAuthCookie defines cookie set by SetAuthCookie and read by Authenticate
+func UseAuthCookie(c session.Cookie) {
+	authCookie = c
+}
+
+// SetAuthCookie puts access token in HttpOnly cookie. CSRF token of session
+// is put in cookie readable by scripts and in X-CSRF-Token header, client
+// has to send it back in the header with every request changing data.
+func SetAuthCookie(ctx *gin.Context, token string) {
+	maxAge := int(authCookie.MaxAge.Seconds())
+	ctx.SetSameSite(sameSite(authCookie.SameSite))
+	ctx.SetCookie(authCookie.Name, token, maxAge, "/", authCookie.Domain, authCookie.Secure, true)
+
+	claims, err := jwt.ParseAccessToken(token)
+	if err != nil {
+		logging.GetLogger().WithContext(ctx.Request.Context()).Error(err)
+		return
+	}
+	csrfToken := jwt.CSRFToken(claims.SessionID)
+	ctx.SetCookie(csrfCookie, csrfToken, maxAge, "/", authCookie.Domain, authCookie.Secure, false)
+	ctx.Header(csrfHeader, csrfToken)
+}
+
+// SetFlowCookie binds login flow to browser which started it. Cookie is
+// always SameSite=Lax, so it is sent along with redirect back from identity
+// provider, negative maxAge removes it.
+func SetFlowCookie(ctx *gin.Context, name, value, path string, maxAge time.Duration) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(name, value, int(maxAge.Seconds()), path, authCookie.Domain, authCookie.Secure, true)
+}
+
+func sameSite(mode string) http.SameSite {
+	switch mode {
+	case session.SameSiteStrict:
+		return http.SameSiteStrictMode
+	case session.SameSiteNone:
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+func CorsMiddleware(router *gin.Engine, cfg session.CORS) {
 	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"*"}
-	config.AllowHeaders = []string{"Authorization", "Origin", "Content-Length", "Content-Type"}
+	config.AllowOrigins = cfg.AllowOrigins
+	config.AllowMethods = cfg.AllowMethods
+	config.AllowHeaders = cfg.AllowHeaders
+	config.AllowCredentials = cfg.AllowCredentials
+	config.ExposeHeaders = []string{requestIDHeader, csrfHeader, rateLimitLimitHeader, rateLimitRemainingHeader, rateLimitResetHeader, retryAfterHeader}
 
-	config.MaxAge = 12 * time.Hour
+	config.MaxAge = cfg.MaxAge
 
 	router.Use(cors.New(config))
 }
 
-func Authenticate(ctx *gin.Context) {
-	header := ctx.GetHeader(authorizationHeader)
-	if header == "" {
-		e.NewErrorResponse(ctx, http.StatusUnauthorized, errors.New("unauthorized"))
-		return
+// SecurityHeaders tells browsers to not sniff content type, not send
+// referrer, stick to HTTPS and what content is allowed to load
+func SecurityHeaders(cfg session.SecurityHeaders) gin.HandlerFunc {
+	var hsts string
+	if cfg.HSTSMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d", int(cfg.HSTSMaxAge.Seconds()))
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return func(ctx *gin.Context) {
+		header := ctx.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		if cfg.ContentSecurityPolicy != "" {
+			header.Set("Content-Security-Policy", cfg.ContentSecurityPolicy)
+		}
+		if cfg.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", cfg.ReferrerPolicy)
+		}
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+	}
+}
+
+// accessToken takes token from Authorization header or, if there is none,
+// from auth cookie
+func accessToken(ctx *gin.Context) (token string, fromCookie bool, err error) {
+	if header := ctx.GetHeader(authorizationHeader); header != "" {
+		headerParts := strings.Split(header, " ")
+		if len(headerParts) != 2 {
+			return "", false, errors.New("malformed token")
+		}
+		return headerParts[1], false, nil
+	}
+
+	cookie, err := ctx.Cookie(authCookie.Name)
+	if err != nil || cookie == "" {
+		return "", false, errors.New("unauthorized")
+	}
+	return cookie, true, nil
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
 	}
-	headerParts := strings.Split(header, " ")
-	if len(headerParts) != 2 {
-		e.NewErrorResponse(ctx, http.StatusUnauthorized, errors.New("malformed token"))
+	return false
+}
+
+// Authenticate accepts access token in Authorization header or in auth
+// cookie. Browsers send cookie along with requests of other sites, so
+// cookie authenticated requests changing data need CSRF token in header too.
+func Authenticate(ctx *gin.Context) {
+	token, fromCookie, err := accessToken(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
 		return
 	}
-	userID, err := jwt.GetIdFromToken(headerParts[1])
+	claims, err := jwt.ParseAccessToken(token)
 	if err != nil {
 		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+	if fromCookie && !safeMethod(ctx.Request.Method) && !jwt.ValidCSRFToken(claims.SessionID, ctx.GetHeader(csrfHeader)) {
+		e.NewErrorResponse(ctx, http.StatusForbidden, e.CSRFError)
+		return
 	}
 
-	logging.GetLogger().Info("authorized")
+	if sessionValidator != nil {
+		if err := sessionValidator.Validate(ctx.Request.Context(), claims.UserID, claims.SessionID); err != nil {
+			e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+			return
+		}
+	}
+
+	ctx.Request = ctx.Request.WithContext(logging.WithUserID(ctx.Request.Context(), claims.UserID))
+	logging.GetLogger().WithContext(ctx.Request.Context()).Info("authorized")
 
-	ctx.Set(userCtx, userID)
+	ctx.Set(userCtx, claims.UserID)
+	ctx.Set(sessionCtx, claims.SessionID)
+	if claims.ImpersonatorID != 0 {
+		ctx.Set(impersonatorCtx, claims.ImpersonatorID)
+	}
 }
 
 func GetUserID(ctx *gin.Context) (int, error) {
@@ -61,3 +229,214 @@ func GetUserID(ctx *gin.Context) (int, error) {
 
 	return idNum, nil
 }
+
+// RequireAdmin lets through only accounts with admin role, it is expected to
+// run after Authenticate
+func RequireAdmin(checker RoleChecker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, err := GetUserID(ctx)
+		if err != nil {
+			e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+			return
+		}
+
+		role, err := checker.GetRole(ctx.Request.Context(), userID)
+		if err != nil {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		if role != model.RoleAdmin {
+			e.NewErrorResponse(ctx, http.StatusForbidden, e.AdminRequiredError)
+		}
+	}
+}
+
+// ForbidImpersonation rejects request made in session admin got by
+// impersonating account, so support can't take over account by changing
+// its credentials. It is expected to run after Authenticate.
+func ForbidImpersonation(ctx *gin.Context) {
+	if _, ok := ctx.Get(impersonatorCtx); ok {
+		e.NewErrorResponse(ctx, http.StatusForbidden, e.ImpersonationError)
+	}
+}
+
+// GetSessionID returns ID of session which authenticated request
+func GetSessionID(ctx *gin.Context) string {
+	return ctx.GetString(sessionCtx)
+}
+
+// GetClient describes device which sent request
+func GetClient(ctx *gin.Context) model.Client {
+	return model.Client{UserAgent: ctx.Request.UserAgent(), IP: ctx.ClientIP()}
+}
+
+// RestrictUnverified makes API read-only for accounts with unverified email.
+// Account routes stay available, so user can still verify email or ask for
+// new link. Requests without valid token are left to Authenticate.
+func RestrictUnverified(verifier Verifier) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if safeMethod(ctx.Request.Method) {
+			return
+		}
+		if strings.HasPrefix(ctx.FullPath(), accountsPath) {
+			return
+		}
+
+		token, _, err := accessToken(ctx)
+		if err != nil {
+			return
+		}
+		userID, err := jwt.GetIdFromToken(token)
+		if err != nil {
+			return
+		}
+
+		verified, err := verifier.IsVerified(ctx.Request.Context(), userID)
+		if err != nil {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		if !verified {
+			e.NewErrorResponse(ctx, http.StatusForbidden, e.UnverifiedError)
+		}
+	}
+}
+
+// Metrics counts requests and their latency per route and status. Requests
+// not matching any route are grouped, so scanners can't blow up label count.
+func Metrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(ctx.Request.Method, route, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(ctx.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RequestID takes request ID from X-Request-ID header set by proxy or
+// client, or generates new one. ID is sent back in response and is added to
+// every entry logged with request context. Client IP is put in request
+// context as well, both are recorded in audit log.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		ctx.Header(requestIDHeader, id)
+		reqCtx := logging.WithRequestID(ctx.Request.Context(), id)
+		ctx.Request = ctx.Request.WithContext(logging.WithClientIP(reqCtx, ctx.ClientIP()))
+	}
+}
+
+// validRequestID accepts only IDs which can't forge log lines or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Tracing starts server span for every request, continuing trace from W3C
+// traceparent header if caller sent one
+func Tracing() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		parent := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		spanCtx, span := tracing.Start(parent, fmt.Sprintf("%s %s", ctx.Request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(ctx.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.HTTPTarget(ctx.Request.URL.Path),
+				semconv.NetSockPeerAddr(ctx.ClientIP()),
+			))
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// RateLimit takes token for every request to route covered by rule of
+// limiter and rejects request when there is none left. Client IP is taken
+// from X-Forwarded-For only if request came from trusted proxy, see
+// gin.Engine.SetTrustedProxies. Requests pass if store fails, so limiter
+// can't take API down.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		rule, ok := limiter.Match(ctx.Request.Method, ctx.FullPath())
+		if !ok {
+			return
+		}
+
+		res, err := limiter.Take(ctx.Request.Context(), rule, rateLimitKey(ctx, rule.KeyBy))
+		if err != nil {
+			logging.GetLogger().WithContext(ctx.Request.Context()).Warnf("rate limit of %s not applied: %v", rule.Name, err)
+			return
+		}
+
+		ctx.Header(rateLimitLimitHeader, strconv.Itoa(res.Limit))
+		ctx.Header(rateLimitRemainingHeader, strconv.Itoa(res.Remaining))
+		ctx.Header(rateLimitResetHeader, seconds(res.Reset))
+		if !res.Allowed {
+			metrics.RateLimited.WithLabelValues(rule.Name).Inc()
+			ctx.Header(retryAfterHeader, seconds(res.RetryAfter))
+			e.NewErrorResponse(ctx, http.StatusTooManyRequests, e.RateLimitedError)
+		}
+	}
+}
+
+// rateLimitKey identifies client by ID of user in valid access token, falling
+// back to IP for anonymous requests. Token is checked here, as Authenticate of
+// route group runs after global middlewares.
+func rateLimitKey(ctx *gin.Context, keyBy string) string {
+	if keyBy == ratelimit.KeyByUser {
+		if token, _, err := accessToken(ctx); err == nil {
+			if userID, err := jwt.GetIdFromToken(token); err == nil {
+				return fmt.Sprintf("user:%d", userID)
+			}
+		}
+	}
+	return "ip:" + ctx.ClientIP()
+}
+
+// seconds rounds d up, so client retrying after that long won't be rejected
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
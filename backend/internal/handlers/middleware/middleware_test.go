@@ -0,0 +1,177 @@
+//go:build unit
+// +build unit
+
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"neatly/internal/session"
+	"neatly/pkg/jwt"
+	"neatly/pkg/logging"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func newAuthRouter() *gin.Engine {
+	logging.Configure(logging.Config{Level: "error", Format: logging.FormatText, Outputs: []string{logging.OutputStderr}})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/login", func(ctx *gin.Context) {
+		token, _ := jwt.GenerateAccessToken(1, 0, "session-1")
+		SetAuthCookie(ctx, token)
+	})
+	handler := func(ctx *gin.Context) {
+		userID, _ := GetUserID(ctx)
+		ctx.JSON(http.StatusOK, userID)
+	}
+	router.GET("/notes", Authenticate, handler)
+	router.POST("/notes", Authenticate, handler)
+	return router
+}
+
+func TestAuthenticate_Cookie(t *testing.T) {
+	if err := os.Setenv("CONF_FILE", "../../service/etc/test.yml"); err != nil {
+		t.Fatal(err)
+	}
+	UseAuthCookie(session.Cookie{Name: "token", SameSite: session.SameSiteStrict, Secure: true, MaxAge: time.Hour})
+	router := newAuthRouter()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, 2, len(cookies))
+	assert.Equal(t, true, cookies[0].HttpOnly)
+	assert.Equal(t, true, cookies[0].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+	assert.Equal(t, false, cookies[1].HttpOnly)
+	csrfToken := rec.Header().Get(csrfHeader)
+	assert.Equal(t, csrfToken, cookies[1].Value)
+
+	testSuites := []struct {
+		testName     string
+		method       string
+		csrfToken    string
+		expectedCode int
+	}{
+		{
+			testName:     "SafeMethodWithoutCSRFToken",
+			method:       http.MethodGet,
+			expectedCode: http.StatusOK,
+		},
+		{
+			testName:     "UnsafeMethodWithCSRFToken",
+			method:       http.MethodPost,
+			csrfToken:    csrfToken,
+			expectedCode: http.StatusOK,
+		},
+		{
+			testName:     "UnsafeMethodWithoutCSRFToken",
+			method:       http.MethodPost,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			testName:     "UnsafeMethodWithForeignCSRFToken",
+			method:       http.MethodPost,
+			csrfToken:    jwt.CSRFToken("session-2"),
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			req := httptest.NewRequest(testSuite.method, "/notes", nil)
+			req.AddCookie(cookies[0])
+			if testSuite.csrfToken != "" {
+				req.Header.Set(csrfHeader, testSuite.csrfToken)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, testSuite.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestAuthenticate_HeaderSkipsCSRF(t *testing.T) {
+	if err := os.Setenv("CONF_FILE", "../../service/etc/test.yml"); err != nil {
+		t.Fatal(err)
+	}
+	router := newAuthRouter()
+	token, err := jwt.GenerateAccessToken(1, 0, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/notes", nil)
+	req.Header.Set(authorizationHeader, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Body.String())
+}
+
+func TestForbidImpersonation(t *testing.T) {
+	if err := os.Setenv("CONF_FILE", "../../service/etc/test.yml"); err != nil {
+		t.Fatal(err)
+	}
+	router := newAuthRouter()
+	router.POST("/password", Authenticate, ForbidImpersonation, func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	own, err := jwt.GenerateAccessToken(1, 0, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	impersonated, err := jwt.GenerateImpersonationToken(1, 0, "session-2", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testSuites := []struct {
+		testName     string
+		path         string
+		token        string
+		expectedCode int
+	}{
+		{"OwnSession", "/password", own, http.StatusOK},
+		{"ImpersonatedSession", "/password", impersonated, http.StatusForbidden},
+		{"ImpersonatedSessionOnOtherRoute", "/notes", impersonated, http.StatusOK},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, testSuite.path, nil)
+			req.Header.Set(authorizationHeader, "Bearer "+testSuite.token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, testSuite.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(SecurityHeaders(session.SecurityHeaders{
+		ContentSecurityPolicy: "default-src 'none'",
+		HSTSMaxAge:            24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		ReferrerPolicy:        "no-referrer",
+	}))
+	router.GET("/healthz", func(ctx *gin.Context) {})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "default-src 'none'", rec.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "max-age=86400; includeSubDomains", rec.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "no-referrer", rec.Header().Get("Referrer-Policy"))
+}
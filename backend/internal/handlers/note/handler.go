@@ -22,16 +22,25 @@ const (
 	apiURLGroup   = "/api"
 	apiVersion    = "1"
 	tagSearchKey  = "tag"
+	templateKey   = "template"
+	archivedKey   = "archived"
 )
 
 type Handler struct {
-	logger  logging.Logger
-	service service.NoteServiceImpl
-	mapper  mapper.NoteMapper
+	logger          logging.Logger
+	service         service.NoteServiceImpl
+	templateService *service.TemplateServiceImpl
+	mapper          mapper.NoteMapper
 }
 
-func NewHandler(logger logging.Logger, service service.NoteServiceImpl, mapper mapper.NoteMapper) *Handler {
-	return &Handler{logger: logger, service: service, mapper: mapper}
+func NewHandler(logger logging.Logger, service service.NoteServiceImpl, templateService *service.TemplateServiceImpl,
+	mapper mapper.NoteMapper) *Handler {
+	return &Handler{
+		logger:          logger,
+		service:         service,
+		templateService: templateService,
+		mapper:          mapper,
+	}
 }
 
 func (h *Handler) Register(router *gin.Engine) {
@@ -46,16 +55,21 @@ func (h *Handler) Register(router *gin.Engine) {
 		group.GET("/:id", h.getOneNote)    // /api/v1/notes/:id
 		group.PATCH("/:id", h.updateNote)  // /api/v1/notes/:id
 		group.DELETE("/:id", h.deleteNote) // /api/v1/notes/:id
+
+		group.POST("/:id/pin", h.togglePinned)          // /api/v1/notes/:id/pin
+		group.POST("/:id/archive", h.toggleArchived)    // /api/v1/notes/:id/archive
+		group.POST("/:id/favourite", h.toggleFavourite) // /api/v1/notes/:id/favourite
 	}
 }
 
 // @Summary Create note
 // @Security ApiKeyAuth
 // @Tags notes
-// @Description create note
+// @Description create note, when template is set note is created from template and request body is ignored
 // @Accept  json
 // @Produce  json
-// @Param dto body dto.CreateNoteDTO true "note content"
+// @Param   template query  int  false  "template id"
+// @Param dto body dto.CreateNoteDTO false "note content"
 // @Success 201 {string} string 1
 // @Failure 500 {object}  e.ErrorResponse
 // @Failure 400,404 {object} e.ErrorResponse
@@ -65,21 +79,24 @@ func (h *Handler) createNote(ctx *gin.Context) {
 	userID, err := middleware.GetUserID(ctx)
 	if err != nil {
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		return
 	}
 
-	//h.logger.Info(ctx.G)
+	if _, ok := ctx.GetQuery(templateKey); ok {
+		h.createNoteFromTemplate(ctx, userID)
+		return
+	}
 
 	var createNoteDTO dto.CreateNoteDTO
 	if err := ctx.BindJSON(&createNoteDTO); err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
 		return
 	}
 
 	n := h.mapper.MapCreateNoteDTO(createNoteDTO)
-	err = h.service.Create(userID, &n)
+	err = h.service.Create(ctx.Request.Context(), userID, &n)
 	if err != nil {
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
@@ -89,13 +106,36 @@ func (h *Handler) createNote(ctx *gin.Context) {
 		"%s/v%v%s/%v", apiURLGroup, apiVersion, notesURLGroup, n.ID))
 }
 
+func (h *Handler) createNoteFromTemplate(ctx *gin.Context, userID int) {
+	templateID, err := strconv.Atoi(ctx.Query(templateKey))
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting template id from request")
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	n, err := h.templateService.Instantiate(ctx.Request.Context(), userID, templateID)
+	if err != nil {
+		if errors.Is(err, e.ClientTemplateError) {
+			e.NewErrorResponse(ctx, http.StatusNotFound, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, fmt.Sprintf(
+		"%s/v%v%s/%v", apiURLGroup, apiVersion, notesURLGroup, n.ID))
+}
+
 // @Summary Get all notes from user filter by tag
 // @Security ApiKeyAuth
 // @Tags notes
-// @Description create note
+// @Description get all notes, pinned notes go first and archived notes are hidden unless archived is set
 // @Accept  json
 // @Produce  json
 // @Param   tag query  string  false  "notes search by tag"
+// @Param   archived query  bool  false  "include archived notes"
 // @Success 200 {object} dto.GetAllNotesDTO
 // @Failure 500 {object}  e.ErrorResponse
 // @Failure 400,404 {object} e.ErrorResponse
@@ -110,18 +150,27 @@ func (h *Handler) getAllNotes(ctx *gin.Context) {
 
 	var ns []model.Note
 
+	withArchived := false
+	if archived, ok := ctx.GetQuery(archivedKey); ok {
+		withArchived, err = strconv.ParseBool(archived)
+		if err != nil {
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+
 	keys := ctx.Request.URL.Query()
 	values := keys[tagSearchKey]
 	if values == nil {
-		ns, err = h.service.GetAll(userID)
+		ns, err = h.service.GetAll(ctx.Request.Context(), userID, withArchived)
 		if err != nil {
 			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 			return
 		}
 	} else {
-		ns, err = h.service.FindByTags(userID, values)
+		ns, err = h.service.FindByTags(ctx.Request.Context(), userID, values, withArchived)
 		if err != nil {
-			h.logger.Info(err)
+			h.logger.WithContext(ctx.Request.Context()).Info(err)
 			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 			return
 		}
@@ -154,12 +203,12 @@ func (h *Handler) getOneNote(ctx *gin.Context) {
 
 	noteID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		h.logger.Info("error while getting id from request")
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	n, err := h.service.GetOne(userID, noteID)
+	n, err := h.service.GetOne(ctx.Request.Context(), userID, noteID)
 	if err != nil {
 		if errors.Is(err, e.ClientNoteError) {
 			e.NewErrorResponse(ctx, http.StatusNotFound, err)
@@ -194,42 +243,45 @@ func (h *Handler) updateNote(ctx *gin.Context) {
 
 	noteID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		h.logger.Info("error while getting id from request")
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
 	bodyBytes, err := ioutil.ReadAll(ctx.Request.Body)
 	if err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
-	h.logger.Debug("unmarshal body bytes")
+	h.logger.WithContext(ctx.Request.Context()).Debug("unmarshal body bytes")
 	var (
-		updateNoteDTO  dto.UpdateNoteDTO
-		data           map[string]interface{}
-		needBodyUpdate bool
+		updateNoteDTO dto.UpdateNoteDTO
+		data          map[string]interface{}
+		mask          model.NoteUpdateMask
 	)
-	h.logger.Infof("NOTE ID: %v", noteID)
+	h.logger.WithContext(ctx.Request.Context()).Infof("NOTE ID: %v", noteID)
 	updateNoteDTO.ID = noteID
 	if err := json.Unmarshal(bodyBytes, &updateNoteDTO); err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
 	if err := json.Unmarshal(bodyBytes, &data); err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	_, needBodyUpdate = data["body"]
-	h.logger.Infof("Need body update: %v", needBodyUpdate)
+	_, mask.Body = data["body"]
+	_, mask.Pinned = data["pinned"]
+	_, mask.Archived = data["archived"]
+	_, mask.Favourite = data["favourite"]
+	h.logger.WithContext(ctx.Request.Context()).Infof("Need body update: %v", mask.Body)
 
 	n := h.mapper.MapUpdateNoteDTO(updateNoteDTO)
-	err = h.service.Update(userID, n, needBodyUpdate)
+	err = h.service.Update(ctx.Request.Context(), userID, n, mask)
 
 	if err != nil {
 		if errors.Is(err, e.ClientNoteError) {
@@ -264,12 +316,12 @@ func (h *Handler) deleteNote(ctx *gin.Context) {
 
 	noteID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		h.logger.Info("error while getting id from request")
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	err = h.service.Delete(userID, noteID)
+	err = h.service.Delete(ctx.Request.Context(), userID, noteID)
 	if err != nil {
 		if errors.Is(err, e.ClientNoteError) {
 			e.NewErrorResponse(ctx, http.StatusNotFound, err)
@@ -281,3 +333,81 @@ func (h *Handler) deleteNote(ctx *gin.Context) {
 
 	ctx.Writer.WriteHeader(http.StatusNoContent)
 }
+
+// @Summary Toggle pinned state
+// @Security ApiKeyAuth
+// @Tags notes
+// @Description pin or unpin note
+// @ID toggle-note-pinned
+// @Accept  json
+// @Produce json
+// @Param   id   path string  true  "id"
+// @Success 200 {object} dto.NoteStateDTO
+// @Failure 500 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/notes/{id}/pin [post]
+func (h *Handler) togglePinned(ctx *gin.Context) {
+	h.toggleState(ctx, model.NoteStatePinned)
+}
+
+// @Summary Toggle archived state
+// @Security ApiKeyAuth
+// @Tags notes
+// @Description archive or unarchive note
+// @ID toggle-note-archived
+// @Accept  json
+// @Produce json
+// @Param   id   path string  true  "id"
+// @Success 200 {object} dto.NoteStateDTO
+// @Failure 500 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/notes/{id}/archive [post]
+func (h *Handler) toggleArchived(ctx *gin.Context) {
+	h.toggleState(ctx, model.NoteStateArchived)
+}
+
+// @Summary Toggle favourite state
+// @Security ApiKeyAuth
+// @Tags notes
+// @Description star or unstar note
+// @ID toggle-note-favourite
+// @Accept  json
+// @Produce json
+// @Param   id   path string  true  "id"
+// @Success 200 {object} dto.NoteStateDTO
+// @Failure 500 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/notes/{id}/favourite [post]
+func (h *Handler) toggleFavourite(ctx *gin.Context) {
+	h.toggleState(ctx, model.NoteStateFavourite)
+}
+
+func (h *Handler) toggleState(ctx *gin.Context, state model.NoteState) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	noteID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	n, err := h.service.ToggleState(ctx.Request.Context(), userID, noteID, state)
+	if err != nil {
+		if errors.Is(err, e.ClientNoteError) {
+			e.NewErrorResponse(ctx, http.StatusNotFound, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, h.mapper.MapNoteStateDTO(n))
+}
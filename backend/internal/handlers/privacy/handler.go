@@ -0,0 +1,110 @@
+package privacy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"neatly/internal/handlers/middleware"
+	"neatly/internal/model/dto"
+	"neatly/internal/service"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"net/http"
+)
+
+const (
+	meURLGroup  = "/accounts/me"
+	exportURL   = "/export"
+	apiURLGroup = "/api"
+	apiVersion  = "1"
+)
+
+type Handler struct {
+	logger  logging.Logger
+	service *service.PrivacyServiceImpl
+}
+
+func NewHandler(logger logging.Logger, service *service.PrivacyServiceImpl) *Handler {
+	return &Handler{logger: logger, service: service}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	groupName := fmt.Sprintf("%v/v%v%v", apiURLGroup, apiVersion, meURLGroup)
+
+	h.logger.Tracef("Register route: %v", groupName)
+
+	group := router.Group(groupName, middleware.Authenticate)
+	{
+		group.DELETE("", middleware.ForbidImpersonation, h.deleteAccount) // /api/v1/accounts/me
+		group.GET(exportURL, h.exportData)                                // /api/v1/accounts/me/export
+	}
+}
+
+// @Summary Delete account
+// @Security ApiKeyAuth
+// @Tags account
+// @Description schedule account deletion, all sessions are revoked and logging in during grace period restores the account
+// @ID delete-account
+// @Accept  json
+// @Produce  json
+// @Param dto body dto.DeleteAccountDTO true "password confirmation"
+// @Success 202 {object} dto.DeletionScheduledDTO
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me [delete]
+func (h *Handler) deleteAccount(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	var in dto.DeleteAccountDTO
+	if err := ctx.BindJSON(&in); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	deleteAfter, err := h.service.ScheduleDeletion(ctx.Request.Context(), userID, in.Password)
+	if err != nil {
+		if errors.Is(err, e.ClientPasswordError) {
+			e.NewErrorResponse(ctx, http.StatusForbidden, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, dto.DeletionScheduledDTO{DeleteAfter: deleteAfter})
+}
+
+// @Summary Export account data
+// @Security ApiKeyAuth
+// @Tags account
+// @Description download JSON archive with profile, notes with bodies, tags, tag assignments and templates
+// @ID export-account
+// @Produce  json
+// @Success 200 {object} model.Export
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me/export [get]
+func (h *Handler) exportData(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	ex, err := h.service.Export(ctx.Request.Context(), userID)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	filename := fmt.Sprintf("neatly-export-%v.json", ex.ExportedAt.Format("20060102-150405"))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.IndentedJSON(http.StatusOK, ex)
+}
@@ -0,0 +1,104 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"neatly/internal/model/dto"
+	"neatly/internal/service"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"net/http"
+)
+
+const (
+	passwordURLGroup = "/accounts/password"
+	forgotURL        = "/forgot"
+	resetURL         = "/reset"
+	apiURLGroup      = "/api"
+	apiVersion       = "1"
+
+	forgotResponse = "if account with this email exists, reset instructions have been sent to it"
+)
+
+type Handler struct {
+	logger  logging.Logger
+	service *service.RecoveryServiceImpl
+}
+
+func NewHandler(logger logging.Logger, service *service.RecoveryServiceImpl) *Handler {
+	return &Handler{logger: logger, service: service}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	groupName := fmt.Sprintf("%v/v%v%v", apiURLGroup, apiVersion, passwordURLGroup)
+
+	h.logger.Tracef("Register route: %v", groupName)
+
+	group := router.Group(groupName)
+	{
+		group.POST(forgotURL, h.forgotPassword) // /api/v1/accounts/password/forgot
+		group.POST(resetURL, h.resetPassword)   // /api/v1/accounts/password/reset
+	}
+}
+
+// @Summary Forgot password
+// @Tags account
+// @Description send password reset token to email, response does not depend on whether account exists
+// @ID forgot-password
+// @Accept  json
+// @Produce  json
+// @Param dto body dto.ForgotPasswordDTO true "account email"
+// @Success 202 {string} string 1
+// @Failure 400 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/password/forgot [post]
+func (h *Handler) forgotPassword(ctx *gin.Context) {
+	var in dto.ForgotPasswordDTO
+
+	if err := ctx.BindJSON(&in); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.RequestReset(ctx.Request.Context(), in.Email); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
+	}
+
+	ctx.JSON(http.StatusAccepted, forgotResponse)
+}
+
+// @Summary Reset password
+// @Tags account
+// @Description set new password using token from email
+// @ID reset-password
+// @Accept  json
+// @Produce  json
+// @Param dto body dto.ResetPasswordDTO true "token and new password"
+// @Success 204
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/password/reset [post]
+func (h *Handler) resetPassword(ctx *gin.Context) {
+	var in dto.ResetPasswordDTO
+
+	if err := ctx.BindJSON(&in); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err := h.service.Reset(ctx.Request.Context(), in.Token, in.Password)
+	if err != nil {
+		if errors.Is(err, e.ClientTokenError) || errors.Is(err, e.ClientPasswordPolicyError) {
+			e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Writer.WriteHeader(http.StatusNoContent)
+}
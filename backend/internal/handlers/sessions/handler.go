@@ -0,0 +1,102 @@
+package sessions
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"neatly/internal/handlers/middleware"
+	"neatly/internal/service"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"net/http"
+)
+
+const (
+	accountsURLGroup = "/accounts"
+	sessionsURL      = "/me/sessions"
+	sessionURL       = "/me/sessions/:id"
+	apiURLGroup      = "/api"
+	apiVersion       = "1"
+)
+
+type Handler struct {
+	logger  logging.Logger
+	service *service.SessionServiceImpl
+}
+
+func NewHandler(logger logging.Logger, service *service.SessionServiceImpl) *Handler {
+	return &Handler{logger: logger, service: service}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	groupName := fmt.Sprintf("%v/v%v%v", apiURLGroup, apiVersion, accountsURLGroup)
+
+	h.logger.Tracef("Register route: %v", groupName)
+
+	group := router.Group(groupName, middleware.Authenticate)
+	{
+		group.GET(sessionsURL, h.getAll)   // /api/v1/accounts/me/sessions
+		group.DELETE(sessionURL, h.revoke) // /api/v1/accounts/me/sessions/:id
+	}
+}
+
+// @Summary List sessions
+// @Security ApiKeyAuth
+// @Tags account
+// @Description list active sessions of current account, session of request is marked as current
+// @ID get-sessions
+// @Produce  json
+// @Success 200 {array} model.Session
+// @Failure 401 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me/sessions [get]
+func (h *Handler) getAll(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	sessions, err := h.service.GetAll(ctx.Request.Context(), userID, middleware.GetSessionID(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// @Summary Revoke session
+// @Security ApiKeyAuth
+// @Tags account
+// @Description sign out device of the session, token of the session stops working
+// @ID revoke-session
+// @Param id path string true "session id"
+// @Success 204
+// @Failure 401 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me/sessions/{id} [delete]
+func (h *Handler) revoke(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	err = h.service.Revoke(ctx.Request.Context(), userID, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, e.ClientSessionError) {
+			e.NewErrorResponse(ctx, http.StatusNotFound, err)
+		} else {
+			h.logger.WithContext(ctx.Request.Context()).Error(err)
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,124 @@
+package sso
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"neatly/internal/handlers/middleware"
+	"neatly/internal/service"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	accountsURLGroup = "/accounts"
+	loginURL         = "/oidc/login"
+	callbackURL      = "/oidc/callback"
+	apiURLGroup      = "/api"
+	apiVersion       = "1"
+	stateCookie      = "oidc_state"
+)
+
+type Handler struct {
+	logger      logging.Logger
+	service     *service.SSOServiceImpl
+	frontendURL string
+	flowTTL     time.Duration
+}
+
+func NewHandler(logger logging.Logger, service *service.SSOServiceImpl, frontendURL string, flowTTL time.Duration) *Handler {
+	return &Handler{logger: logger, service: service, frontendURL: frontendURL, flowTTL: flowTTL}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	groupName := fmt.Sprintf("%v/v%v%v", apiURLGroup, apiVersion, accountsURLGroup)
+
+	h.logger.Tracef("Register route: %v", groupName)
+
+	group := router.Group(groupName)
+	{
+		group.GET(loginURL, h.login)       // /api/v1/accounts/oidc/login
+		group.GET(callbackURL, h.callback) // /api/v1/accounts/oidc/callback
+	}
+}
+
+// @Summary Single sign-on login
+// @Tags account
+// @Description redirect to OpenID Connect provider
+// @ID oidc-login
+// @Success 302
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/oidc/login [get]
+func (h *Handler) login(ctx *gin.Context) {
+	authURL, state, err := h.service.AuthURL(ctx.Request.Context())
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	middleware.SetFlowCookie(ctx, stateCookie, state, callbackPath(), h.flowTTL)
+
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// @Summary Single sign-on callback
+// @Tags account
+// @Description finish OpenID Connect login and redirect to frontend with token in URL fragment,
+// @Description challenge_token is passed instead if account has 2FA enabled
+// @Description state has to match cookie set by login in the same browser
+// @ID oidc-callback
+// @Param state query string true "flow state"
+// @Param code query string true "authorization code"
+// @Success 302
+// @Router /api/v1/accounts/oidc/callback [get]
+func (h *Handler) callback(ctx *gin.Context) {
+	state := ctx.Query("state")
+	boundState, _ := ctx.Cookie(stateCookie)
+	middleware.SetFlowCookie(ctx, stateCookie, "", callbackPath(), -1)
+
+	if msg := ctx.Query("error"); msg != "" {
+		h.logger.WithContext(ctx.Request.Context()).Infof("OIDC provider returned error: %v %v", msg, ctx.Query("error_description"))
+		h.redirect(ctx, "error", e.ClientOIDCError.Error())
+		return
+	}
+
+	// flow started in another browser must not log this one in
+	if boundState == "" || subtle.ConstantTimeCompare([]byte(boundState), []byte(state)) != 1 {
+		h.logger.WithContext(ctx.Request.Context()).Info("OIDC state does not match flow cookie")
+		h.redirect(ctx, "error", e.ClientOIDCError.Error())
+		return
+	}
+
+	token, twoFactor, err := h.service.Callback(ctx.Request.Context(), state, ctx.Query("code"), middleware.GetClient(ctx))
+	if err != nil {
+		if !errors.Is(err, e.ClientOIDCError) && !errors.Is(err, e.AccountDisabledError) {
+			h.logger.WithContext(ctx.Request.Context()).Error(err)
+			err = e.ClientOIDCError
+		}
+		h.redirect(ctx, "error", err.Error())
+		return
+	}
+
+	if twoFactor {
+		h.redirect(ctx, "challenge_token", token)
+		return
+	}
+	middleware.SetAuthCookie(ctx, token)
+
+	h.redirect(ctx, "token", token)
+}
+
+func callbackPath() string {
+	return fmt.Sprintf("%v/v%v%v%v", apiURLGroup, apiVersion, accountsURLGroup, callbackURL)
+}
+
+// redirect passes value in fragment, so it is not sent to servers or logged
+func (h *Handler) redirect(ctx *gin.Context, key, value string) {
+	fragment := url.Values{key: []string{value}}.Encode()
+	ctx.Redirect(http.StatusFound, h.frontendURL+"#"+fragment)
+}
@@ -0,0 +1,94 @@
+//go:build unit
+// +build unit
+
+package sso
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"neatly/internal/model"
+	"neatly/internal/service"
+	"neatly/pkg/logging"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testState = "state"
+
+// ssoServiceStub starts flow with testState and counts finished callbacks
+type ssoServiceStub struct {
+	service.SSOService
+	callbacks int
+}
+
+func (s *ssoServiceStub) AuthURL(context.Context) (string, string, error) {
+	return "https://idp.example.com/auth?state=" + testState, testState, nil
+}
+
+func (s *ssoServiceStub) Callback(context.Context, string, string, model.Client) (string, bool, error) {
+	s.callbacks++
+	return "challenge", true, nil
+}
+
+func newTestRouter(stub *ssoServiceStub) *gin.Engine {
+	logging.Configure(logging.Config{Level: "error", Format: logging.FormatText, Outputs: []string{logging.OutputStderr}})
+	gin.SetMode(gin.TestMode)
+
+	h := NewHandler(logging.GetLogger(), &service.SSOServiceImpl{SSOService: stub}, "http://localhost/oidc", time.Minute)
+	router := gin.New()
+	h.Register(router)
+	return router
+}
+
+func TestHandler_Login(t *testing.T) {
+	router := newTestRouter(&ssoServiceStub{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/accounts/oidc/login", nil))
+
+	assert.Equal(t, http.StatusFound, rec.Code)
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, 1, len(cookies))
+	assert.Equal(t, stateCookie, cookies[0].Name)
+	assert.Equal(t, testState, cookies[0].Value)
+	assert.Equal(t, true, cookies[0].HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	assert.Equal(t, callbackPath(), cookies[0].Path)
+}
+
+func TestHandler_Callback(t *testing.T) {
+	testSuites := []struct {
+		testName          string
+		cookie            string
+		expectedCallbacks int
+		expectedFragment  string
+	}{
+		{"MatchingCookie", testState, 1, "challenge_token=challenge"},
+		{"NoCookie", "", 0, "error="},
+		{"OtherCookie", "state of another browser", 0, "error="},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			stub := &ssoServiceStub{}
+			router := newTestRouter(stub)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/accounts/oidc/callback?state="+testState+"&code=code", nil)
+			if testSuite.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: stateCookie, Value: url.QueryEscape(testSuite.cookie)})
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusFound, rec.Code)
+			assert.Equal(t, testSuite.expectedCallbacks, stub.callbacks)
+			fragment := strings.SplitN(rec.Header().Get("Location"), "#", 2)[1]
+			assert.Equal(t, true, strings.HasPrefix(fragment, testSuite.expectedFragment))
+		})
+	}
+}
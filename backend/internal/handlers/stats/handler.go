@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"neatly/internal/handlers/middleware"
+	"neatly/internal/service"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"net/http"
+	"strconv"
+)
+
+const (
+	statsURLGroup = "/stats"
+	apiURLGroup   = "/api"
+	apiVersion    = "1"
+	daysKey       = "days"
+	limitKey      = "limit"
+)
+
+type Handler struct {
+	logger  logging.Logger
+	service *service.StatsServiceImpl
+}
+
+func NewHandler(logger logging.Logger, service *service.StatsServiceImpl) *Handler {
+	return &Handler{logger: logger, service: service}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	groupName := fmt.Sprintf("%v/v%v%v", apiURLGroup, apiVersion, statsURLGroup)
+
+	h.logger.Tracef("Register route: %v", groupName)
+
+	group := router.Group(groupName, middleware.Authenticate)
+	{
+		group.GET("", h.getStats) // /api/v1/stats
+	}
+}
+
+// @Summary Get statistics
+// @Security ApiKeyAuth
+// @Tags stats
+// @Description get totals of notes, words and characters, notes edited per day, most used tags, colors and last edited notes
+// @ID get-stats
+// @Accept  json
+// @Produce json
+// @Param   days  query  int  false  "days of activity, 30 by default"
+// @Param   limit query  int  false  "amount of top tags and last edited notes, 5 by default"
+// @Success 200 {object} model.Stats
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/stats [get]
+func (h *Handler) getStats(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	days, err := intQuery(ctx, daysKey)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := intQuery(ctx, limitKey)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	st, err := h.service.Get(ctx.Request.Context(), userID, days, limit)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, st)
+}
+
+func intQuery(ctx *gin.Context, key string) (int, error) {
+	v, ok := ctx.GetQuery(key)
+	if !ok {
+		return 0, nil
+	}
+	return strconv.Atoi(v)
+}
@@ -73,13 +73,13 @@ func (h *Handler) Register(router *gin.Engine) {
 func (h *Handler) createTag(ctx *gin.Context) {
 	userID, err := middleware.GetUserID(ctx)
 	if err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		return
 	}
 
 	noteID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		h.logger.Info("error while getting id from request")
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
@@ -90,13 +90,13 @@ func (h *Handler) createTag(ctx *gin.Context) {
 	)
 
 	if err := ctx.BindJSON(&createTagDTO); err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
 		return
 	}
 
 	t = h.mapper.MapCreateTagDTO(createTagDTO)
-	modified, err := h.service.Create(userID, noteID, &t)
+	modified, err := h.service.Create(ctx.Request.Context(), userID, noteID, &t)
 
 	if err != nil {
 		if errors.Is(err, e.ClientTagError) || errors.Is(err, e.ClientNoteError) {
@@ -132,21 +132,21 @@ func (h *Handler) createTag(ctx *gin.Context) {
 func (h *Handler) getAllTagsOnNote(ctx *gin.Context) {
 	userID, err := middleware.GetUserID(ctx)
 	if err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		return
 	}
 
 	noteID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		h.logger.Info("error while getting id from request")
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	tags, err := h.service.GetAllByNote(userID, noteID)
+	tags, err := h.service.GetAllByNote(ctx.Request.Context(), userID, noteID)
 
 	if err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
@@ -170,14 +170,14 @@ func (h *Handler) getAllTagsOnNote(ctx *gin.Context) {
 func (h *Handler) getAllTags(ctx *gin.Context) {
 	userID, err := middleware.GetUserID(ctx)
 	if err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		return
 	}
 
-	tags, err := h.service.GetAll(userID)
+	tags, err := h.service.GetAll(ctx.Request.Context(), userID)
 
 	if err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
@@ -202,18 +202,18 @@ func (h *Handler) getAllTags(ctx *gin.Context) {
 func (h *Handler) getOneTag(ctx *gin.Context) {
 	userID, err := middleware.GetUserID(ctx)
 	if err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		return
 	}
 
 	tagID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		h.logger.Info("error while getting id from request")
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	t, err := h.service.GetOne(userID, tagID)
+	t, err := h.service.GetOne(ctx.Request.Context(), userID, tagID)
 
 	if err != nil {
 		if errors.Is(err, e.ClientTagError) {
@@ -248,7 +248,7 @@ func (h *Handler) updateTag(ctx *gin.Context) {
 
 	tagID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		h.logger.Info("error while getting id from request")
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
@@ -257,13 +257,13 @@ func (h *Handler) updateTag(ctx *gin.Context) {
 		updateTagDTO dto.UpdateTagDTO
 	)
 	if err := ctx.BindJSON(&updateTagDTO); err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
 		return
 	}
 
 	t := h.mapper.MapUpdateTagDTO(updateTagDTO)
-	err = h.service.Update(userID, tagID, t)
+	err = h.service.Update(ctx.Request.Context(), userID, tagID, t)
 	if err != nil {
 		if errors.Is(err, e.ClientTagError) {
 			e.NewErrorResponse(ctx, http.StatusNotFound, err)
@@ -291,18 +291,18 @@ func (h *Handler) updateTag(ctx *gin.Context) {
 func (h *Handler) deleteTag(ctx *gin.Context) {
 	userID, err := middleware.GetUserID(ctx)
 	if err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		return
 	}
 
 	tagID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		h.logger.Info("error while getting id from request")
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	err = h.service.Delete(userID, tagID)
+	err = h.service.Delete(ctx.Request.Context(), userID, tagID)
 
 	if err != nil {
 		if errors.Is(err, e.ClientTagError) || errors.Is(err, e.ClientNoteError) {
@@ -332,25 +332,25 @@ func (h *Handler) deleteTag(ctx *gin.Context) {
 func (h *Handler) detachTag(ctx *gin.Context) {
 	userID, err := middleware.GetUserID(ctx)
 	if err != nil {
-		h.logger.Info(err)
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
 		return
 	}
 
 	noteID, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		h.logger.Info("error while getting id from request")
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
 	tagID, err := strconv.Atoi(ctx.Param("tag_id"))
 	if err != nil {
-		h.logger.Info("error while getting id from request")
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
 		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	err = h.service.Detach(userID, tagID, noteID)
+	err = h.service.Detach(ctx.Request.Context(), userID, tagID, noteID)
 
 	if err != nil {
 		if errors.Is(err, e.ClientTagError) || errors.Is(err, e.ClientNoteError) {
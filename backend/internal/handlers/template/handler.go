@@ -0,0 +1,235 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"neatly/internal/handlers/middleware"
+	"neatly/internal/mapper"
+	"neatly/internal/model/dto"
+	"neatly/internal/service"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"net/http"
+	"strconv"
+)
+
+const (
+	templatesURLGroup = "/templates"
+	apiURLGroup       = "/api"
+	apiVersion        = "1"
+)
+
+type Handler struct {
+	logger  logging.Logger
+	service *service.TemplateServiceImpl
+	mapper  mapper.TemplateMapper
+}
+
+func NewHandler(logger logging.Logger, service *service.TemplateServiceImpl, mapper mapper.TemplateMapper) *Handler {
+	return &Handler{logger: logger, service: service, mapper: mapper}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	groupName := fmt.Sprintf("%v/v%v%v", apiURLGroup, apiVersion, templatesURLGroup)
+
+	h.logger.Tracef("Register route: %v", groupName)
+
+	group := router.Group(groupName, middleware.Authenticate)
+	{
+		group.GET("", h.getAllTemplates)       // /api/v1/templates
+		group.POST("", h.createTemplate)       // /api/v1/templates
+		group.GET("/:id", h.getOneTemplate)    // /api/v1/templates/:id
+		group.PATCH("/:id", h.updateTemplate)  // /api/v1/templates/:id
+		group.DELETE("/:id", h.deleteTemplate) // /api/v1/templates/:id
+	}
+}
+
+// @Summary Create template
+// @Security ApiKeyAuth
+// @Tags templates
+// @Description create note template, header and body may contain date, time, weekday, username and name placeholders
+// @Accept  json
+// @Produce  json
+// @Param dto body dto.CreateTemplateDTO true "template content"
+// @Success 201 {string} string 1
+// @Failure 500 {object}  e.ErrorResponse
+// @Failure 400 {object} e.ErrorResponse
+// @Failure default {object}  e.ErrorResponse
+// @Router /api/v1/templates [post]
+func (h *Handler) createTemplate(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var createTemplateDTO dto.CreateTemplateDTO
+	if err := ctx.BindJSON(&createTemplateDTO); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	t := h.mapper.MapCreateTemplateDTO(createTemplateDTO)
+	err = h.service.Create(ctx.Request.Context(), userID, &t)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, fmt.Sprintf(
+		"%s/v%v%s/%v", apiURLGroup, apiVersion, templatesURLGroup, t.ID))
+}
+
+// @Summary Get all templates
+// @Security ApiKeyAuth
+// @Tags templates
+// @Description get all templates of user
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} dto.GetAllTemplatesDTO
+// @Failure 500 {object}  e.ErrorResponse
+// @Failure default {object}  e.ErrorResponse
+// @Router /api/v1/templates [get]
+func (h *Handler) getAllTemplates(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ts, err := h.service.GetAll(ctx.Request.Context(), userID)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, h.mapper.MapGetAllTemplatesDTO(ts))
+}
+
+// @Summary Get template by id
+// @Security ApiKeyAuth
+// @Tags templates
+// @Description get template by id
+// @Accept  json
+// @Produce json
+// @Param   id  path  string  true  "id"
+// @Success 200 {object} model.Template
+// @Failure 500 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/templates/{id} [get]
+func (h *Handler) getOneTemplate(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	templateID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	t, err := h.service.GetOne(ctx.Request.Context(), userID, templateID)
+	if err != nil {
+		if errors.Is(err, e.ClientTemplateError) {
+			e.NewErrorResponse(ctx, http.StatusNotFound, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, t)
+}
+
+// @Summary Update template
+// @Security ApiKeyAuth
+// @Tags templates
+// @Description update template
+// @Accept  json
+// @Produce json
+// @Param   id   path  string  true  "id"
+// @Param dto body dto.UpdateTemplateDTO true "template content"
+// @Success 204
+// @Failure 500 {object} e.ErrorResponse
+// @Failure 400,404 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/templates/{id} [patch]
+func (h *Handler) updateTemplate(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	templateID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	var updateTemplateDTO dto.UpdateTemplateDTO
+	if err := ctx.BindJSON(&updateTemplateDTO); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	t, needBodyUpdate := h.mapper.MapUpdateTemplateDTO(updateTemplateDTO)
+	err = h.service.Update(ctx.Request.Context(), userID, templateID, t, needBodyUpdate)
+	if err != nil {
+		if errors.Is(err, e.ClientTemplateError) {
+			e.NewErrorResponse(ctx, http.StatusNotFound, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Writer.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Delete template
+// @Security ApiKeyAuth
+// @Tags templates
+// @Description delete template
+// @Accept  json
+// @Produce json
+// @Param   id   path string  true  "id"
+// @Success 204
+// @Failure 500 {object} e.ErrorResponse
+// @Failure 404 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/templates/{id} [delete]
+func (h *Handler) deleteTemplate(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	templateID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info("error while getting id from request")
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.service.Delete(ctx.Request.Context(), userID, templateID)
+	if err != nil {
+		if errors.Is(err, e.ClientTemplateError) {
+			e.NewErrorResponse(ctx, http.StatusNotFound, err)
+		} else {
+			e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Writer.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,237 @@
+package twofactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"math"
+	"neatly/internal/handlers/middleware"
+	"neatly/internal/model/dto"
+	"neatly/internal/service"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"net/http"
+	"strconv"
+)
+
+const (
+	accountsURLGroup = "/accounts"
+	loginURL         = "/login/2fa"
+	twoFactorURL     = "/me/2fa"
+	confirmURL       = "/me/2fa/confirm"
+	apiURLGroup      = "/api"
+	apiVersion       = "1"
+)
+
+type Handler struct {
+	logger         logging.Logger
+	service        *service.TwoFactorServiceImpl
+	lockoutService *service.LockoutServiceImpl
+}
+
+func NewHandler(logger logging.Logger, service *service.TwoFactorServiceImpl, lockoutService *service.LockoutServiceImpl) *Handler {
+	return &Handler{logger: logger, service: service, lockoutService: lockoutService}
+}
+
+func (h *Handler) Register(router *gin.Engine) {
+	groupName := fmt.Sprintf("%v/v%v%v", apiURLGroup, apiVersion, accountsURLGroup)
+
+	h.logger.Tracef("Register route: %v", groupName)
+
+	group := router.Group(groupName)
+	{
+		group.POST(loginURL, h.login)                                                                  // /api/v1/accounts/login/2fa
+		group.POST(twoFactorURL, middleware.Authenticate, middleware.ForbidImpersonation, h.enrol)     // /api/v1/accounts/me/2fa
+		group.POST(confirmURL, middleware.Authenticate, middleware.ForbidImpersonation, h.confirm)     // /api/v1/accounts/me/2fa/confirm
+		group.DELETE(twoFactorURL, middleware.Authenticate, middleware.ForbidImpersonation, h.disable) // /api/v1/accounts/me/2fa
+	}
+}
+
+// @Summary Two-factor login
+// @Tags account
+// @Description exchange challenge token from login and TOTP or recovery code for access token, challenge stops working after a few wrong codes
+// @ID login-2fa
+// @Accept  json
+// @Produce  json
+// @Param dto body dto.TwoFactorLoginDTO true "challenge token and code"
+// @Success 200 {object} dto.TokenDTO
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 401 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 429 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/login/2fa [post]
+func (h *Handler) login(ctx *gin.Context) {
+	var in dto.TwoFactorLoginDTO
+
+	if err := ctx.BindJSON(&in); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	challenge, err := h.service.Challenge(ctx.Request.Context(), in.ChallengeToken)
+	if err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+	ip := ctx.ClientIP()
+
+	retryAfter, err := h.lockoutService.Check(ctx.Request.Context(), challenge.Username, ip)
+	if err != nil {
+		if errors.Is(err, e.LoginLockedError) {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			e.NewErrorResponse(ctx, http.StatusTooManyRequests, err)
+		} else {
+			h.respondError(ctx, err)
+		}
+		return
+	}
+	if err := h.lockoutService.CheckChallenge(ctx.Request.Context(), challenge); err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+
+	token, err := h.service.Login(ctx.Request.Context(), in.ChallengeToken, in.Code, middleware.GetClient(ctx))
+	if err != nil {
+		if errors.Is(err, e.ClientTwoFactorCodeError) {
+			// not bound to request, so client can't dodge counting by
+			// disconnecting right after wrong code
+			failCtx := logging.WithRequestID(context.Background(), logging.RequestID(ctx.Request.Context()))
+			if err := h.lockoutService.Fail(failCtx, challenge.Username, ip); err != nil {
+				h.logger.WithContext(ctx.Request.Context()).Error(err)
+			}
+			if err := h.lockoutService.FailChallenge(failCtx, challenge, ip); err != nil {
+				h.logger.WithContext(ctx.Request.Context()).Error(err)
+			}
+		}
+		h.respondError(ctx, err)
+		return
+	}
+	if err := h.lockoutService.Succeed(ctx.Request.Context(), challenge.Username); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Error(err)
+	}
+	middleware.SetAuthCookie(ctx, token)
+
+	ctx.JSON(http.StatusOK, dto.TokenDTO{Token: token})
+}
+
+// @Summary Enrol two-factor authentication
+// @Security ApiKeyAuth
+// @Tags account
+// @Description generate TOTP secret and otpauth URI, 2FA is enabled after confirmation
+// @ID enrol-2fa
+// @Produce  json
+// @Success 200 {object} model.TwoFactorEnrolment
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 409 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me/2fa [post]
+func (h *Handler) enrol(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	enrolment, err := h.service.Enrol(ctx.Request.Context(), userID)
+	if err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrolment)
+}
+
+// @Summary Confirm two-factor authentication
+// @Security ApiKeyAuth
+// @Tags account
+// @Description enable 2FA with a code from authenticator app, recovery codes are returned only once
+// @ID confirm-2fa
+// @Accept  json
+// @Produce  json
+// @Param dto body dto.TwoFactorCodeDTO true "TOTP code"
+// @Success 200 {object} dto.RecoveryCodesDTO
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 409 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me/2fa/confirm [post]
+func (h *Handler) confirm(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	var in dto.TwoFactorCodeDTO
+	if err := ctx.BindJSON(&in); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	codes, err := h.service.Confirm(ctx.Request.Context(), userID, in.Code)
+	if err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.RecoveryCodesDTO{RecoveryCodes: codes})
+}
+
+// @Summary Disable two-factor authentication
+// @Security ApiKeyAuth
+// @Tags account
+// @Description disable 2FA, TOTP or recovery code is required
+// @ID disable-2fa
+// @Accept  json
+// @Produce  json
+// @Param dto body dto.TwoFactorCodeDTO true "TOTP or recovery code"
+// @Success 204
+// @Failure 400 {object} e.ErrorResponse
+// @Failure 403 {object} e.ErrorResponse
+// @Failure 409 {object} e.ErrorResponse
+// @Failure 500 {object} e.ErrorResponse
+// @Failure default {object} e.ErrorResponse
+// @Router /api/v1/accounts/me/2fa [delete]
+func (h *Handler) disable(ctx *gin.Context) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+		return
+	}
+
+	var in dto.TwoFactorCodeDTO
+	if err := ctx.BindJSON(&in); err != nil {
+		h.logger.WithContext(ctx.Request.Context()).Info(err)
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Disable(ctx.Request.Context(), userID, in.Code); err != nil {
+		h.respondError(ctx, err)
+		return
+	}
+
+	ctx.Writer.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) respondError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, e.ClientTwoFactorCodeError), errors.Is(err, e.ClientTwoFactorEnrolError):
+		e.NewErrorResponse(ctx, http.StatusBadRequest, err)
+	case errors.Is(err, e.ClientTokenError), errors.Is(err, e.SessionRevokedError):
+		e.NewErrorResponse(ctx, http.StatusUnauthorized, err)
+	case errors.Is(err, e.AccountDisabledError):
+		e.NewErrorResponse(ctx, http.StatusForbidden, err)
+	case errors.Is(err, e.ClientTwoFactorEnabledError), errors.Is(err, e.ClientTwoFactorDisabledError):
+		e.NewErrorResponse(ctx, http.StatusConflict, err)
+	default:
+		e.NewErrorResponse(ctx, http.StatusInternalServerError, err)
+	}
+}
@@ -3,7 +3,11 @@ package mapper
 import (
 	"neatly/internal/model"
 	"neatly/internal/model/dto"
+	"neatly/pkg/e"
 	"neatly/pkg/logging"
+	"neatly/pkg/password"
+	"net/mail"
+	"strings"
 )
 
 type AccountMapper struct {
@@ -15,6 +19,17 @@ func NewAccountMapper(logger logging.Logger) *AccountMapper {
 }
 
 func (m *AccountMapper) MapRegisterAccountDTO(dto dto.RegisterAccountDTO) (model.Account, error) {
+	email, err := NormaliseEmail(dto.Email)
+	if err != nil {
+		m.logger.Info(err)
+		return model.Account{}, err
+	}
+
+	if err := password.Validate(dto.Password); err != nil {
+		m.logger.Info(err)
+		return model.Account{}, err
+	}
+
 	phash, err := model.GeneratePasswordHash(dto.Password)
 	if err != nil {
 		m.logger.Info(err)
@@ -26,7 +41,7 @@ func (m *AccountMapper) MapRegisterAccountDTO(dto dto.RegisterAccountDTO) (model
 		ID:           0,
 		Name:         dto.Name,
 		Username:     dto.Username,
-		Email:        dto.Email,
+		Email:        email,
 		Password:     dto.Password,
 		PasswordHash: phash,
 	}, nil
@@ -57,5 +72,37 @@ func (m *AccountMapper) MapAccountDTO(a model.Account) dto.GetAccountDTO {
 		Name:     a.Name,
 		Username: a.Username,
 		Email:    a.Email,
+		Verified: a.Verified,
+	}
+}
+
+func (m *AccountMapper) MapUpdateAccountDTO(dto dto.UpdateAccountDTO, mask model.AccountUpdateMask) (model.Account, error) {
+	a := model.Account{
+		Name:     strings.TrimSpace(dto.Name),
+		Username: strings.TrimSpace(dto.Username),
+	}
+
+	if mask.Email {
+		email, err := NormaliseEmail(dto.Email)
+		if err != nil {
+			m.logger.Info(err)
+			return model.Account{}, err
+		}
+		a.Email = email
+	}
+
+	return a, nil
+}
+
+// NormaliseEmail checks that email is a bare address without display name
+// and brings it to lower case
+func NormaliseEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return "", e.ClientEmailError
 	}
+
+	return strings.ToLower(email), nil
 }
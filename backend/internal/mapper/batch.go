@@ -0,0 +1,41 @@
+package mapper
+
+import (
+	"neatly/internal/model"
+	"neatly/internal/model/dto"
+	"neatly/pkg/logging"
+)
+
+type BatchMapper struct {
+	logger logging.Logger
+}
+
+func NewBatchMapper(logger logging.Logger) *BatchMapper {
+	return &BatchMapper{logger: logger}
+}
+
+func (m *BatchMapper) MapBatchResultDTO(applied bool, results []model.BatchResult) dto.BatchResultDTO {
+	return dto.BatchResultDTO{
+		Applied: applied,
+		Results: results,
+	}
+}
+
+func (m *BatchMapper) MapMergeNotesDTO(dto dto.MergeNotesDTO) model.MergeOptions {
+	opts := model.MergeOptions{
+		NoteIDs:   dto.NoteIDs,
+		Header:    dto.Header,
+		Color:     dto.Color,
+		Separator: model.DefaultMergeSeparator,
+		Sources:   model.MergeSources(dto.Sources),
+	}
+
+	if dto.Separator != nil {
+		opts.Separator = *dto.Separator
+	}
+	if opts.Sources == "" {
+		opts.Sources = model.MergeSourcesDelete
+	}
+
+	return opts
+}
@@ -47,6 +47,9 @@ func (m *NoteMapper) MapUpdateNoteDTO(dto dto.UpdateNoteDTO) model.Note {
 		Body:      dto.Body,
 		ShortBody: "",
 		Color:     dto.Color,
+		Pinned:    dto.Pinned,
+		Archived:  dto.Archived,
+		Favourite: dto.Favourite,
 	}
 
 	n.GenerateShortBody()
@@ -54,3 +57,11 @@ func (m *NoteMapper) MapUpdateNoteDTO(dto dto.UpdateNoteDTO) model.Note {
 
 	return n
 }
+
+func (m *NoteMapper) MapNoteStateDTO(n model.Note) dto.NoteStateDTO {
+	return dto.NoteStateDTO{
+		Pinned:    n.Pinned,
+		Archived:  n.Archived,
+		Favourite: n.Favourite,
+	}
+}
@@ -0,0 +1,54 @@
+package mapper
+
+import (
+	"neatly/internal/model"
+	"neatly/internal/model/dto"
+	"neatly/pkg/logging"
+)
+
+type TemplateMapper struct {
+	logger logging.Logger
+}
+
+func NewTemplateMapper(logger logging.Logger) *TemplateMapper {
+	return &TemplateMapper{logger: logger}
+}
+
+func (m *TemplateMapper) MapCreateTemplateDTO(dto dto.CreateTemplateDTO) model.Template {
+	if dto.Color == "" {
+		dto.Color = model.DefaultNoteColor
+	}
+	if dto.Tags == nil {
+		dto.Tags = make([]string, 0)
+	}
+
+	return model.Template{
+		ID:     0,
+		Header: dto.Header,
+		Body:   dto.Body,
+		Color:  dto.Color,
+		Tags:   dto.Tags,
+	}
+}
+
+// MapUpdateTemplateDTO returns template with new values and flag whether body should be updated
+func (m *TemplateMapper) MapUpdateTemplateDTO(dto dto.UpdateTemplateDTO) (model.Template, bool) {
+	t := model.Template{
+		ID:     0,
+		Header: dto.Header,
+		Color:  dto.Color,
+		Tags:   dto.Tags,
+	}
+
+	if dto.Body != nil {
+		t.Body = *dto.Body
+	}
+
+	return t, dto.Body != nil
+}
+
+func (m *TemplateMapper) MapGetAllTemplatesDTO(ts []model.Template) dto.GetAllTemplatesDTO {
+	return dto.GetAllTemplatesDTO{
+		Templates: ts,
+	}
+}
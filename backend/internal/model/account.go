@@ -1,17 +1,44 @@
 package model
 
 import (
-	"errors"
-	"golang.org/x/crypto/bcrypt"
+	"fmt"
+	"neatly/pkg/password"
+	"time"
 )
 
 type Account struct {
-	ID           int    `json:"-" db:"id" gorm:"column:id" dbq:"id"`
-	Name         string `json:"name" binding:"required" gorm:"column:name" dbq:"name"`
-	Username     string `json:"username" binding:"required" gorm:"column:username" dbq:"username"`
-	Email        string `json:"email" binding:"required" gorm:"column:email" dbq:"email"`
-	Password     string `json:"-"`
-	PasswordHash string `json:"password" binding:"required" db:"password_hash" gorm:"column:password_hash" dbq:"password_hash"`
+	ID             int        `json:"-" db:"id" gorm:"column:id" dbq:"id"`
+	Name           string     `json:"name" binding:"required" gorm:"column:name" dbq:"name"`
+	Username       string     `json:"username" binding:"required" gorm:"column:username" dbq:"username"`
+	Email          string     `json:"email" binding:"required" gorm:"column:email" dbq:"email"`
+	Password       string     `json:"-"`
+	PasswordHash   string     `json:"password" binding:"required" db:"password_hash" gorm:"column:password_hash" dbq:"password_hash"`
+	Verified       bool       `json:"-" db:"email_verified"`
+	SessionVersion int        `json:"-" db:"session_version"`
+	DeleteAfter    *time.Time `json:"-" db:"delete_after"`
+	TOTPSecret     string     `json:"-" db:"totp_secret"`
+	TOTPEnabled    bool       `json:"-" db:"totp_enabled"`
+	Role           string     `json:"-" db:"role"`
+	Disabled       bool       `json:"-" db:"disabled"`
+}
+
+// String leaves out password, its hash and TOTP secret, so account can be
+// logged safely
+func (a Account) String() string {
+	return fmt.Sprintf("{ID:%v Name:%v Username:%v Email:%v Verified:%v Role:%v Disabled:%v}",
+		a.ID, a.Name, a.Username, a.Email, a.Verified, a.Role, a.Disabled)
+}
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// AccountUpdateMask marks fields which are present in partial profile update
+type AccountUpdateMask struct {
+	Name     bool
+	Username bool
+	Email    bool
 }
 
 // Recommended by dbq
@@ -24,18 +51,16 @@ func (Account) TableName() string {
 	return "users"
 }
 
-func (a *Account) CheckPassword(password string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(a.PasswordHash), []byte(password))
-	if err != nil {
-		return errors.New("password does not match")
-	}
-	return nil
+func (a *Account) CheckPassword(pass string) error {
+	return password.Compare(a.PasswordHash, pass)
+}
+
+// PasswordNeedsRehash reports whether stored hash is legacy one or was
+// created with outdated parameters
+func (a *Account) PasswordNeedsRehash() bool {
+	return password.NeedsRehash(a.PasswordHash)
 }
 
-func GeneratePasswordHash(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hash), nil
+func GeneratePasswordHash(pass string) (string, error) {
+	return password.Hash(pass)
 }
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// AdminAccount is account as seen by admins, with usage counters
+type AdminAccount struct {
+	ID          int        `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Username    string     `json:"username" db:"username"`
+	Email       string     `json:"email" db:"email"`
+	Verified    bool       `json:"verified" db:"email_verified"`
+	Role        string     `json:"role" db:"role"`
+	Disabled    bool       `json:"disabled" db:"disabled"`
+	TOTPEnabled bool       `json:"two_factor_enabled" db:"totp_enabled"`
+	DeleteAfter *time.Time `json:"delete_after,omitempty" db:"delete_after"`
+	NoteCount   int        `json:"note_count" db:"note_count"`
+	TagCount    int        `json:"tag_count" db:"tag_count"`
+}
+
+// AdminSearch is filter and page of admin user listing, query matches
+// name, username and email
+type AdminSearch struct {
+	Query  string
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditLoginSucceeded      AuditAction = "login.succeeded"
+	AuditLoginFailed         AuditAction = "login.failed"
+	AuditLoginLocked         AuditAction = "login.locked"
+	AuditPasswordChanged     AuditAction = "password.changed"
+	AuditTokenCreated        AuditAction = "token.created"
+	AuditNoteCreated         AuditAction = "note.created"
+	AuditNoteUpdated         AuditAction = "note.updated"
+	AuditNoteDeleted         AuditAction = "note.deleted"
+	AuditTagCreated          AuditAction = "tag.created"
+	AuditTagUpdated          AuditAction = "tag.updated"
+	AuditTagDeleted          AuditAction = "tag.deleted"
+	AuditAccountDisabled     AuditAction = "account.disabled"
+	AuditAccountEnabled      AuditAction = "account.enabled"
+	AuditPasswordResetForced AuditAction = "password.reset_forced"
+	AuditImpersonated        AuditAction = "account.impersonated"
+	AuditAdminGranted        AuditAction = "account.admin_granted"
+	AuditDeletionScheduled   AuditAction = "account.deletion_scheduled"
+	AuditDeletionCancelled   AuditAction = "account.deletion_cancelled"
+)
+
+// AuditEntry records who did what to which object of which account. Entries
+// are never changed, UserID is empty for events not bound to known account,
+// e.g. lockout of IP, and once account is deleted.
+type AuditEntry struct {
+	ID        int64        `json:"id" db:"id"`
+	ActorID   *int         `json:"actor_id,omitempty" db:"actor_id"`
+	UserID    *int         `json:"user_id,omitempty" db:"users_id"`
+	Action    AuditAction  `json:"action" db:"action"`
+	Target    string       `json:"target,omitempty" db:"target"`
+	IP        string       `json:"ip,omitempty" db:"ip"`
+	RequestID string       `json:"request_id,omitempty" db:"request_id"`
+	Before    AuditSummary `json:"before,omitempty" db:"before" swaggertype:"object"`
+	After     AuditSummary `json:"after,omitempty" db:"after" swaggertype:"object"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+// NewAuditEntry makes entry of action done to target of account userID
+func NewAuditEntry(userID int, action AuditAction, target string) AuditEntry {
+	return AuditEntry{UserID: &userID, Action: action, Target: target}
+}
+
+// AuditTarget names audited object, e.g. note:12
+func AuditTarget(kind string, id interface{}) string {
+	return fmt.Sprintf("%s:%v", kind, id)
+}
+
+// AuditSummary holds fields of object which are worth keeping in audit log.
+// Note bodies and secrets are never put there.
+type AuditSummary map[string]interface{}
+
+func (s AuditSummary) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *AuditSummary) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return errors.New("unsupported type of audit summary")
+	}
+}
+
+// AuditPage is page of audit log, newest entries come first
+type AuditPage struct {
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,62 @@
+package model
+
+type BatchAction string
+
+const (
+	BatchActionDelete    BatchAction = "delete"
+	BatchActionRecolor   BatchAction = "recolor"
+	BatchActionAddTag    BatchAction = "add_tag"
+	BatchActionRemoveTag BatchAction = "remove_tag"
+	BatchActionMove      BatchAction = "move"
+	BatchActionArchive   BatchAction = "archive"
+)
+
+type BatchStatus string
+
+const (
+	BatchStatusDone       BatchStatus = "done"
+	BatchStatusFailed     BatchStatus = "failed"
+	BatchStatusRolledBack BatchStatus = "rolled_back"
+	BatchStatusSkipped    BatchStatus = "skipped"
+)
+
+type BatchOperation struct {
+	Action   BatchAction `json:"action"`
+	NoteIDs  []int       `json:"note_ids"`
+	Color    string      `json:"color,omitempty"`
+	Tag      string      `json:"tag,omitempty"`
+	Notebook string      `json:"notebook,omitempty"`
+}
+
+type BatchResult struct {
+	Action  BatchAction `json:"action"`
+	NoteIDs []int       `json:"note_ids"`
+	Status  BatchStatus `json:"status"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// BatchSize returns amount of single note operations in batch
+func BatchSize(ops []BatchOperation) int {
+	size := 0
+	for _, op := range ops {
+		size += len(op.NoteIDs)
+	}
+	return size
+}
+
+type MergeSources string
+
+const (
+	MergeSourcesDelete  MergeSources = "delete"
+	MergeSourcesArchive MergeSources = "archive"
+
+	DefaultMergeSeparator = "\n\n"
+)
+
+type MergeOptions struct {
+	NoteIDs   []int
+	Header    string
+	Color     string
+	Separator string
+	Sources   MergeSources
+}
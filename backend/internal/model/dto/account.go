@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type RegisterAccountDTO struct {
 	Name     string `json:"name"`
 	Username string `json:"username"`
@@ -23,4 +25,54 @@ type GetAccountDTO struct {
 	Name     string `json:"name"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	Verified bool   `json:"verified"`
+}
+
+type UpdateAccountDTO struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type DeleteAccountDTO struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type DeletionScheduledDTO struct {
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
+type TokenDTO struct {
+	Token string `json:"token"`
+}
+
+type ForgotPasswordDTO struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordDTO struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type TwoFactorChallengeDTO struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+type TwoFactorLoginDTO struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorCodeDTO struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"neatly/internal/model"
+)
+
+type BatchDTO struct {
+	Operations []model.BatchOperation `json:"operations" binding:"required"`
+}
+
+type BatchResultDTO struct {
+	Applied bool                `json:"applied"`
+	Results []model.BatchResult `json:"results"`
+}
+
+type MergeNotesDTO struct {
+	NoteIDs   []int   `json:"note_ids" binding:"required"`
+	Header    string  `json:"header"`
+	Color     string  `json:"color"`
+	Separator *string `json:"separator"`
+	Sources   string  `json:"sources" enums:"delete,archive"`
+}
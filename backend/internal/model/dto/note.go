@@ -11,10 +11,19 @@ type CreateNoteDTO struct {
 }
 
 type UpdateNoteDTO struct {
-	ID     int
-	Header string `json:"header"`
-	Body   string `json:"body"`
-	Color  string `json:"color"`
+	ID        int
+	Header    string `json:"header"`
+	Body      string `json:"body"`
+	Color     string `json:"color"`
+	Pinned    bool   `json:"pinned"`
+	Archived  bool   `json:"archived"`
+	Favourite bool   `json:"favourite"`
+}
+
+type NoteStateDTO struct {
+	Pinned    bool `json:"pinned"`
+	Archived  bool `json:"archived"`
+	Favourite bool `json:"favourite"`
 }
 
 type GetAllNotesDTO struct {
@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"neatly/internal/model"
+)
+
+type CreateTemplateDTO struct {
+	Header string   `json:"header" binding:"required"`
+	Body   string   `json:"body"`
+	Color  string   `json:"color"`
+	Tags   []string `json:"tags"`
+}
+
+type UpdateTemplateDTO struct {
+	Header string   `json:"header"`
+	Body   *string  `json:"body"`
+	Color  string   `json:"color"`
+	Tags   []string `json:"tags"`
+}
+
+type GetAllTemplatesDTO struct {
+	Templates []model.Template `json:"templates"`
+}
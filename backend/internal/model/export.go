@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+const ExportFormatVersion = 1
+
+type ExportProfile struct {
+	Name     string `json:"name" db:"name"`
+	Username string `json:"username" db:"username"`
+	Email    string `json:"email" db:"email"`
+	Verified bool   `json:"verified" db:"email_verified"`
+}
+
+type ExportNote struct {
+	ID        int        `json:"id" db:"id"`
+	Header    string     `json:"header" db:"header"`
+	Body      string     `json:"body" db:"body"`
+	Color     string     `json:"color" db:"color"`
+	Edited    *time.Time `json:"edited" db:"edited"`
+	Pinned    bool       `json:"pinned" db:"pinned"`
+	Archived  bool       `json:"archived" db:"archived"`
+	Favourite bool       `json:"favourite" db:"favourite"`
+	Notebook  string     `json:"notebook,omitempty" db:"notebook"`
+}
+
+type TagAssignment struct {
+	TagID  int `json:"tag_id" db:"tags_id"`
+	NoteID int `json:"note_id" db:"notes_id"`
+}
+
+// Export is an archive of everything stored about account
+type Export struct {
+	Version     int             `json:"version"`
+	ExportedAt  time.Time       `json:"exported_at"`
+	Profile     ExportProfile   `json:"profile"`
+	Notes       []ExportNote    `json:"notes"`
+	Tags        []Tag           `json:"tags"`
+	Assignments []TagAssignment `json:"assignments"`
+	Templates   []Template      `json:"templates"`
+}
@@ -0,0 +1,13 @@
+package model
+
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+// Health is result of readiness probe, Checks holds "ok" or error message of
+// every registered dependency
+type Health struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// LoginAttempt counts failed logins made for one username or from one IP
+type LoginAttempt struct {
+	Key         string     `db:"key"`
+	Failures    int        `db:"failures"`
+	LastFailure time.Time  `db:"last_failure"`
+	LockedUntil *time.Time `db:"locked_until"`
+}
+
+func UsernameAttemptKey(username string) string {
+	return "username:" + strings.ToLower(username)
+}
+
+func IPAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+func ChallengeAttemptKey(id string) string {
+	return "challenge:" + id
+}
+
+// LockoutDelay returns how long login is locked after given amount of
+// failures. Delay doubles with every failure over the threshold and never
+// exceeds max.
+func LockoutDelay(failures, threshold int, base, max time.Duration) time.Duration {
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+
+	delay := base
+	for i := threshold; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -9,7 +9,7 @@ import (
 
 func TokenMother() string {
 	a := AccountMother()
-	token, err := jwt.GenerateAccessToken(a.ID)
+	token, err := jwt.GenerateAccessToken(a.ID, a.SessionVersion, SessionMother().ID)
 	if err != nil {
 		log.Fatal("can't create test token")
 	}
@@ -17,6 +17,20 @@ func TokenMother() string {
 	return token
 }
 
+func SessionMother() model.Session {
+	now := time.Now()
+
+	return model.Session{
+		ID:         "test-session",
+		UserID:     AccountMother().ID,
+		UserAgent:  "test",
+		IP:         "127.0.0.1",
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(jwt.AccessTokenTTL),
+	}
+}
+
 func AccountMother() model.Account {
 
 	testHash, _ := model.GeneratePasswordHash("testtest")
@@ -49,3 +63,13 @@ func TagMother() model.Tag {
 		Label: "",
 	}
 }
+
+func TemplateMother() model.Template {
+	return model.Template{
+		ID:     0,
+		Header: "",
+		Body:   "",
+		Color:  model.DefaultNoteColor,
+		Tags:   []string{},
+	}
+}
@@ -18,6 +18,38 @@ type Note struct {
 	Tags      []Tag     `json:"tags" db:"tags"`
 	Color     string    `json:"color" db:"color"`
 	Edited    time.Time `json:"edited"`
+	Pinned    bool      `json:"pinned" db:"pinned"`
+	Archived  bool      `json:"archived" db:"archived"`
+	Favourite bool      `json:"favourite" db:"favourite"`
+	Notebook  string    `json:"notebook,omitempty" db:"notebook"`
+}
+
+type NoteState int
+
+const (
+	NoteStatePinned NoteState = iota
+	NoteStateArchived
+	NoteStateFavourite
+)
+
+// NoteUpdateMask marks fields which are present in partial note update
+type NoteUpdateMask struct {
+	Body      bool
+	Pinned    bool
+	Archived  bool
+	Favourite bool
+}
+
+// Toggle flips one of note state flags
+func (n *Note) Toggle(state NoteState) {
+	switch state {
+	case NoteStatePinned:
+		n.Pinned = !n.Pinned
+	case NoteStateArchived:
+		n.Archived = !n.Archived
+	case NoteStateFavourite:
+		n.Favourite = !n.Favourite
+	}
 }
 
 func (n *Note) GenerateShortBody() {
@@ -51,3 +83,15 @@ func truncate(text string, width int) string {
 	trunc := r[:width]
 	return string(trunc)
 }
+
+// AuditSummary describes note in audit log without its body
+func (n Note) AuditSummary() AuditSummary {
+	return AuditSummary{
+		"header":    n.Header,
+		"color":     n.Color,
+		"pinned":    n.Pinned,
+		"archived":  n.Archived,
+		"favourite": n.Favourite,
+		"notebook":  n.Notebook,
+	}
+}
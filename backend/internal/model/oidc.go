@@ -0,0 +1,63 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+)
+
+const oidcRandomBytes = 32
+
+// OIDCFlow keeps secrets of authorization started by user until provider
+// redirects back with a code
+type OIDCFlow struct {
+	State     string    `db:"state"`
+	Verifier  string    `db:"verifier"`
+	Nonce     string    `db:"nonce"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// OIDCClaims are ID token claims used to find or provision account
+type OIDCClaims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+func NewOIDCFlow(ttl time.Duration) (OIDCFlow, error) {
+	var (
+		f   OIDCFlow
+		err error
+	)
+
+	if f.State, err = RandomString(); err != nil {
+		return f, err
+	}
+	if f.Verifier, err = RandomString(); err != nil {
+		return f, err
+	}
+	if f.Nonce, err = RandomString(); err != nil {
+		return f, err
+	}
+	f.ExpiresAt = time.Now().Add(ttl)
+
+	return f, nil
+}
+
+// Challenge returns PKCE code challenge of flow verifier, S256 method
+func (f OIDCFlow) Challenge() string {
+	sum := sha256.Sum256([]byte(f.Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// RandomString returns url-safe random string suitable for secrets
+func RandomString() (string, error) {
+	buf := make([]byte, oidcRandomBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+const maxUserAgentLength = 512
+
+// Client describes device which logs in
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// Session is server-side record of issued access token, so it can be listed
+// and revoked before it expires. ImpersonatorID is set for sessions started
+// by admin for support.
+type Session struct {
+	ID             string    `json:"id" db:"id"`
+	UserID         int       `json:"-" db:"users_id"`
+	UserAgent      string    `json:"user_agent" db:"user_agent"`
+	IP             string    `json:"ip" db:"ip"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	LastSeenAt     time.Time `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt      time.Time `json:"expires_at" db:"expires_at"`
+	ImpersonatorID *int      `json:"impersonator_id,omitempty" db:"impersonator_id"`
+	Current        bool      `json:"current" db:"-"`
+}
+
+func NewSession(userID int, client Client, ttl time.Duration) (Session, error) {
+	id, err := RandomString()
+	if err != nil {
+		return Session{}, err
+	}
+
+	userAgent := []rune(client.UserAgent)
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	now := time.Now()
+	return Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  string(userAgent),
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}, nil
+}
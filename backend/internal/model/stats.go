@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+const (
+	DefaultStatsDays  = 30
+	MaxStatsDays      = 365
+	DefaultStatsLimit = 5
+	MaxStatsLimit     = 50
+)
+
+type NoteTotals struct {
+	Notes      int `json:"notes" db:"notes"`
+	Words      int `json:"words" db:"words"`
+	Characters int `json:"characters" db:"characters"`
+}
+
+type DayActivity struct {
+	Day    time.Time `json:"day" db:"day"`
+	Edited int       `json:"edited" db:"edited"`
+}
+
+type TagUsage struct {
+	ID    int    `json:"id" db:"id"`
+	Label string `json:"label" db:"label"`
+	Notes int    `json:"notes" db:"notes"`
+}
+
+type ColorUsage struct {
+	Color string `json:"color" db:"color"`
+	Notes int    `json:"notes" db:"notes"`
+}
+
+type Stats struct {
+	NoteTotals
+	EditedPerDay []DayActivity `json:"edited_per_day"`
+	TopTags      []TagUsage    `json:"top_tags"`
+	Colors       []ColorUsage  `json:"colors"`
+	LastEdited   []Note        `json:"last_edited"`
+}
@@ -5,3 +5,7 @@ type Tag struct {
 	Label string `json:"label" db:"label" binding:"required"`
 	//Color string `json:"color" db:"color"`
 }
+
+func (t Tag) AuditSummary() AuditSummary {
+	return AuditSummary{"label": t.Label}
+}
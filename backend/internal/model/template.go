@@ -0,0 +1,43 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	templateDateLayout = "2006-01-02"
+	templateTimeLayout = "15:04"
+)
+
+type Template struct {
+	ID     int      `json:"id" db:"id"`
+	Header string   `json:"header" db:"header"`
+	Body   string   `json:"body" db:"body"`
+	Color  string   `json:"color" db:"color"`
+	Tags   []string `json:"tags" db:"tags"`
+}
+
+// Render fills template placeholders such as {{date}} or {{username}} and
+// returns a note which is ready to be created.
+func (t *Template) Render(a Account, now time.Time) Note {
+	r := strings.NewReplacer(
+		"{{date}}", now.Format(templateDateLayout),
+		"{{time}}", now.Format(templateTimeLayout),
+		"{{weekday}}", now.Weekday().String(),
+		"{{username}}", a.Username,
+		"{{name}}", a.Name,
+	)
+
+	n := Note{
+		Header: r.Replace(t.Header),
+		Body:   r.Replace(t.Body),
+		Color:  t.Color,
+	}
+	if n.Color == "" {
+		n.Color = DefaultNoteColor
+	}
+	n.GenerateShortBody()
+
+	return n
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+const tokenBytes = 32
+
+type TokenKind string
+
+const (
+	TokenKindPasswordReset     TokenKind = "password_reset"
+	TokenKindEmailVerification TokenKind = "email_verification"
+)
+
+// AccountToken is a single-use secret sent to user. Only hash of the token
+// is stored, the token itself is known to its receiver only.
+type AccountToken struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"users_id"`
+	Kind      TokenKind  `db:"kind"`
+	Hash      string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+// NewAccountToken generates random token for user and returns it along with
+// its storable representation
+func NewAccountToken(userID int, kind TokenKind, ttl time.Duration) (string, AccountToken, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", AccountToken{}, err
+	}
+	token := hex.EncodeToString(buf)
+
+	return token, AccountToken{
+		UserID:    userID,
+		Kind:      kind,
+		Hash:      HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditSummary describes token in audit log, the token itself is left out
+func (t AccountToken) AuditSummary() AuditSummary {
+	return AuditSummary{"kind": t.Kind, "expires_at": t.ExpiresAt}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"crypto/rand"
+	"strings"
+	"time"
+)
+
+const (
+	RecoveryCodesCount = 10
+	recoveryCodeLen    = 10
+	recoveryAlphabet   = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// TwoFactorEnrolment is a secret to be added to authenticator app. It starts
+// working only after user confirms it with a valid code.
+type TwoFactorEnrolment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TwoFactorChallenge is second step of login which is waiting for a code
+type TwoFactorChallenge struct {
+	ID        string
+	UserID    int
+	Username  string
+	ExpiresAt time.Time
+}
+
+// NewRecoveryCodes generates single-use codes which replace TOTP when
+// authenticator is lost. Codes are formatted as two groups of five
+// characters.
+func NewRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, RecoveryCodesCount)
+
+	buf := make([]byte, recoveryCodeLen)
+	for i := 0; i < RecoveryCodesCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := make([]byte, recoveryCodeLen)
+		for j, b := range buf {
+			code[j] = recoveryAlphabet[int(b)%len(recoveryAlphabet)]
+		}
+		codes = append(codes, string(code[:recoveryCodeLen/2])+"-"+string(code[recoveryCodeLen/2:]))
+	}
+
+	return codes, nil
+}
+
+// NormaliseRecoveryCode drops separators and case, so code is accepted
+// however user typed it
+func NormaliseRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -5,8 +5,12 @@
 package mock
 
 import (
+	context "context"
 	model "neatly/internal/model"
+	repository "neatly/internal/repository"
+	ratelimit "neatly/pkg/ratelimit"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,46 +39,161 @@ func (m *MockAccountRepository) EXPECT() *MockAccountRepositoryMockRecorder {
 }
 
 // AuthorizeAccount mocks base method.
-func (m *MockAccountRepository) AuthorizeAccount(a *model.Account) error {
+func (m *MockAccountRepository) AuthorizeAccount(ctx context.Context, a *model.Account) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AuthorizeAccount", a)
+	ret := m.ctrl.Call(m, "AuthorizeAccount", ctx, a)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AuthorizeAccount indicates an expected call of AuthorizeAccount.
-func (mr *MockAccountRepositoryMockRecorder) AuthorizeAccount(a interface{}) *gomock.Call {
+func (mr *MockAccountRepositoryMockRecorder) AuthorizeAccount(ctx, a interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAccount", reflect.TypeOf((*MockAccountRepository)(nil).AuthorizeAccount), a)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeAccount", reflect.TypeOf((*MockAccountRepository)(nil).AuthorizeAccount), ctx, a)
+}
+
+// CancelDeletion mocks base method.
+func (m *MockAccountRepository) CancelDeletion(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDeletion", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelDeletion indicates an expected call of CancelDeletion.
+func (mr *MockAccountRepositoryMockRecorder) CancelDeletion(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDeletion", reflect.TypeOf((*MockAccountRepository)(nil).CancelDeletion), ctx, userID)
 }
 
 // CreateAccount mocks base method.
-func (m *MockAccountRepository) CreateAccount(a *model.Account) error {
+func (m *MockAccountRepository) CreateAccount(ctx context.Context, a *model.Account) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAccount", a)
+	ret := m.ctrl.Call(m, "CreateAccount", ctx, a)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateAccount indicates an expected call of CreateAccount.
-func (mr *MockAccountRepositoryMockRecorder) CreateAccount(a interface{}) *gomock.Call {
+func (mr *MockAccountRepositoryMockRecorder) CreateAccount(ctx, a interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockAccountRepository)(nil).CreateAccount), ctx, a)
+}
+
+// GetByEmail mocks base method.
+func (m *MockAccountRepository) GetByEmail(ctx context.Context, email string) ([]model.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByEmail", ctx, email)
+	ret0, _ := ret[0].([]model.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByEmail indicates an expected call of GetByEmail.
+func (mr *MockAccountRepositoryMockRecorder) GetByEmail(ctx, email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockAccountRepository)(nil).CreateAccount), a)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockAccountRepository)(nil).GetByEmail), ctx, email)
 }
 
 // GetOne mocks base method.
-func (m *MockAccountRepository) GetOne(userID int) (model.Account, error) {
+func (m *MockAccountRepository) GetOne(ctx context.Context, userID int) (model.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOne", userID)
+	ret := m.ctrl.Call(m, "GetOne", ctx, userID)
 	ret0, _ := ret[0].(model.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOne indicates an expected call of GetOne.
-func (mr *MockAccountRepositoryMockRecorder) GetOne(userID interface{}) *gomock.Call {
+func (mr *MockAccountRepositoryMockRecorder) GetOne(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockAccountRepository)(nil).GetOne), ctx, userID)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockAccountRepository) PurgeDeleted(ctx context.Context, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockAccountRepositoryMockRecorder) PurgeDeleted(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockAccountRepository)(nil).PurgeDeleted), ctx, now)
+}
+
+// RehashPassword mocks base method.
+func (m *MockAccountRepository) RehashPassword(ctx context.Context, userID int, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RehashPassword", ctx, userID, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RehashPassword indicates an expected call of RehashPassword.
+func (mr *MockAccountRepositoryMockRecorder) RehashPassword(ctx, userID, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RehashPassword", reflect.TypeOf((*MockAccountRepository)(nil).RehashPassword), ctx, userID, passwordHash)
+}
+
+// ScheduleDeletion mocks base method.
+func (m *MockAccountRepository) ScheduleDeletion(ctx context.Context, userID int, deleteAfter time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleDeletion", ctx, userID, deleteAfter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleDeletion indicates an expected call of ScheduleDeletion.
+func (mr *MockAccountRepositoryMockRecorder) ScheduleDeletion(ctx, userID, deleteAfter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleDeletion", reflect.TypeOf((*MockAccountRepository)(nil).ScheduleDeletion), ctx, userID, deleteAfter)
+}
+
+// SetVerified mocks base method.
+func (m *MockAccountRepository) SetVerified(ctx context.Context, userID int, verified bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVerified", ctx, userID, verified)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVerified indicates an expected call of SetVerified.
+func (mr *MockAccountRepositoryMockRecorder) SetVerified(ctx, userID, verified interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVerified", reflect.TypeOf((*MockAccountRepository)(nil).SetVerified), ctx, userID, verified)
+}
+
+// Update mocks base method.
+func (m *MockAccountRepository) Update(ctx context.Context, a model.Account) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, a)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockAccountRepositoryMockRecorder) Update(ctx, a interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockAccountRepository)(nil).Update), ctx, a)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockAccountRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, userID, passwordHash)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockAccountRepositoryMockRecorder) UpdatePassword(ctx, userID, passwordHash interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockAccountRepository)(nil).GetOne), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockAccountRepository)(nil).UpdatePassword), ctx, userID, passwordHash)
 }
 
 // MockNoteRepository is a mock of NoteRepository interface.
@@ -101,75 +220,118 @@ func (m *MockNoteRepository) EXPECT() *MockNoteRepositoryMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockNoteRepository) Create(userID int, note *model.Note) error {
+func (m *MockNoteRepository) Create(ctx context.Context, userID int, note *model.Note) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", userID, note)
+	ret := m.ctrl.Call(m, "Create", ctx, userID, note)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockNoteRepositoryMockRecorder) Create(userID, note interface{}) *gomock.Call {
+func (mr *MockNoteRepositoryMockRecorder) Create(ctx, userID, note interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockNoteRepository)(nil).Create), userID, note)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockNoteRepository)(nil).Create), ctx, userID, note)
 }
 
 // Delete mocks base method.
-func (m *MockNoteRepository) Delete(userID, noteID int) error {
+func (m *MockNoteRepository) Delete(ctx context.Context, userID, noteID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", userID, noteID)
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, noteID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockNoteRepositoryMockRecorder) Delete(userID, noteID interface{}) *gomock.Call {
+func (mr *MockNoteRepositoryMockRecorder) Delete(ctx, userID, noteID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockNoteRepository)(nil).Delete), userID, noteID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockNoteRepository)(nil).Delete), ctx, userID, noteID)
 }
 
 // GetAll mocks base method.
-func (m *MockNoteRepository) GetAll(userID int) ([]model.Note, error) {
+func (m *MockNoteRepository) GetAll(ctx context.Context, userID int) ([]model.Note, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", userID)
+	ret := m.ctrl.Call(m, "GetAll", ctx, userID)
 	ret0, _ := ret[0].([]model.Note)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockNoteRepositoryMockRecorder) GetAll(userID interface{}) *gomock.Call {
+func (mr *MockNoteRepositoryMockRecorder) GetAll(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockNoteRepository)(nil).GetAll), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockNoteRepository)(nil).GetAll), ctx, userID)
 }
 
 // GetOne mocks base method.
-func (m *MockNoteRepository) GetOne(userID, noteID int) (model.Note, error) {
+func (m *MockNoteRepository) GetOne(ctx context.Context, userID, noteID int) (model.Note, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOne", userID, noteID)
+	ret := m.ctrl.Call(m, "GetOne", ctx, userID, noteID)
 	ret0, _ := ret[0].(model.Note)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOne indicates an expected call of GetOne.
-func (mr *MockNoteRepositoryMockRecorder) GetOne(userID, noteID interface{}) *gomock.Call {
+func (mr *MockNoteRepositoryMockRecorder) GetOne(ctx, userID, noteID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockNoteRepository)(nil).GetOne), ctx, userID, noteID)
+}
+
+// Move mocks base method.
+func (m *MockNoteRepository) Move(ctx context.Context, userID, noteID int, notebook string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Move", ctx, userID, noteID, notebook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockNoteRepositoryMockRecorder) Move(ctx, userID, noteID, notebook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockNoteRepository)(nil).Move), ctx, userID, noteID, notebook)
+}
+
+// ToggleState mocks base method.
+func (m *MockNoteRepository) ToggleState(ctx context.Context, userID, noteID int, state model.NoteState) (model.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ToggleState", ctx, userID, noteID, state)
+	ret0, _ := ret[0].(model.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ToggleState indicates an expected call of ToggleState.
+func (mr *MockNoteRepositoryMockRecorder) ToggleState(ctx, userID, noteID, state interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockNoteRepository)(nil).GetOne), userID, noteID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ToggleState", reflect.TypeOf((*MockNoteRepository)(nil).ToggleState), ctx, userID, noteID, state)
 }
 
 // Update mocks base method.
-func (m *MockNoteRepository) Update(userID int, n model.Note) error {
+func (m *MockNoteRepository) Update(ctx context.Context, userID int, n model.Note) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", userID, n)
+	ret := m.ctrl.Call(m, "Update", ctx, userID, n)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockNoteRepositoryMockRecorder) Update(userID, n interface{}) *gomock.Call {
+func (mr *MockNoteRepositoryMockRecorder) Update(ctx, userID, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockNoteRepository)(nil).Update), ctx, userID, n)
+}
+
+// UpdateState mocks base method.
+func (m *MockNoteRepository) UpdateState(ctx context.Context, userID int, n model.Note) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateState", ctx, userID, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateState indicates an expected call of UpdateState.
+func (mr *MockNoteRepositoryMockRecorder) UpdateState(ctx, userID, n interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockNoteRepository)(nil).Update), userID, n)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateState", reflect.TypeOf((*MockNoteRepository)(nil).UpdateState), ctx, userID, n)
 }
 
 // MockTagRepository is a mock of TagRepository interface.
@@ -196,116 +358,1016 @@ func (m *MockTagRepository) EXPECT() *MockTagRepositoryMockRecorder {
 }
 
 // Assign mocks base method.
-func (m *MockTagRepository) Assign(tagID, noteID, userID int) error {
+func (m *MockTagRepository) Assign(ctx context.Context, tagID, noteID, userID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Assign", tagID, noteID, userID)
+	ret := m.ctrl.Call(m, "Assign", ctx, tagID, noteID, userID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Assign indicates an expected call of Assign.
-func (mr *MockTagRepositoryMockRecorder) Assign(tagID, noteID, userID interface{}) *gomock.Call {
+func (mr *MockTagRepositoryMockRecorder) Assign(ctx, tagID, noteID, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assign", reflect.TypeOf((*MockTagRepository)(nil).Assign), tagID, noteID, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assign", reflect.TypeOf((*MockTagRepository)(nil).Assign), ctx, tagID, noteID, userID)
 }
 
 // Create mocks base method.
-func (m *MockTagRepository) Create(userID, noteID int, t *model.Tag) error {
+func (m *MockTagRepository) Create(ctx context.Context, userID, noteID int, t *model.Tag) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", userID, noteID, t)
+	ret := m.ctrl.Call(m, "Create", ctx, userID, noteID, t)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockTagRepositoryMockRecorder) Create(userID, noteID, t interface{}) *gomock.Call {
+func (mr *MockTagRepositoryMockRecorder) Create(ctx, userID, noteID, t interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTagRepository)(nil).Create), userID, noteID, t)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTagRepository)(nil).Create), ctx, userID, noteID, t)
 }
 
 // Delete mocks base method.
-func (m *MockTagRepository) Delete(userID, tagID int) error {
+func (m *MockTagRepository) Delete(ctx context.Context, userID, tagID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", userID, tagID)
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, tagID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockTagRepositoryMockRecorder) Delete(userID, tagID interface{}) *gomock.Call {
+func (mr *MockTagRepositoryMockRecorder) Delete(ctx, userID, tagID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTagRepository)(nil).Delete), userID, tagID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTagRepository)(nil).Delete), ctx, userID, tagID)
 }
 
 // Detach mocks base method.
-func (m *MockTagRepository) Detach(userID, tagID, noteID int) error {
+func (m *MockTagRepository) Detach(ctx context.Context, userID, tagID, noteID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Detach", userID, tagID, noteID)
+	ret := m.ctrl.Call(m, "Detach", ctx, userID, tagID, noteID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Detach indicates an expected call of Detach.
-func (mr *MockTagRepositoryMockRecorder) Detach(userID, tagID, noteID interface{}) *gomock.Call {
+func (mr *MockTagRepositoryMockRecorder) Detach(ctx, userID, tagID, noteID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Detach", reflect.TypeOf((*MockTagRepository)(nil).Detach), userID, tagID, noteID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Detach", reflect.TypeOf((*MockTagRepository)(nil).Detach), ctx, userID, tagID, noteID)
 }
 
 // GetAll mocks base method.
-func (m *MockTagRepository) GetAll(userID int) ([]model.Tag, error) {
+func (m *MockTagRepository) GetAll(ctx context.Context, userID int) ([]model.Tag, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", userID)
+	ret := m.ctrl.Call(m, "GetAll", ctx, userID)
 	ret0, _ := ret[0].([]model.Tag)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockTagRepositoryMockRecorder) GetAll(userID interface{}) *gomock.Call {
+func (mr *MockTagRepositoryMockRecorder) GetAll(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTagRepository)(nil).GetAll), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTagRepository)(nil).GetAll), ctx, userID)
 }
 
 // GetAllByNote mocks base method.
-func (m *MockTagRepository) GetAllByNote(userID, noteID int) ([]model.Tag, error) {
+func (m *MockTagRepository) GetAllByNote(ctx context.Context, userID, noteID int) ([]model.Tag, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllByNote", userID, noteID)
+	ret := m.ctrl.Call(m, "GetAllByNote", ctx, userID, noteID)
 	ret0, _ := ret[0].([]model.Tag)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllByNote indicates an expected call of GetAllByNote.
-func (mr *MockTagRepositoryMockRecorder) GetAllByNote(userID, noteID interface{}) *gomock.Call {
+func (mr *MockTagRepositoryMockRecorder) GetAllByNote(ctx, userID, noteID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByNote", reflect.TypeOf((*MockTagRepository)(nil).GetAllByNote), userID, noteID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByNote", reflect.TypeOf((*MockTagRepository)(nil).GetAllByNote), ctx, userID, noteID)
 }
 
 // GetOne mocks base method.
-func (m *MockTagRepository) GetOne(userID, tagID int) (model.Tag, error) {
+func (m *MockTagRepository) GetOne(ctx context.Context, userID, tagID int) (model.Tag, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOne", userID, tagID)
+	ret := m.ctrl.Call(m, "GetOne", ctx, userID, tagID)
 	ret0, _ := ret[0].(model.Tag)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetOne indicates an expected call of GetOne.
-func (mr *MockTagRepositoryMockRecorder) GetOne(userID, tagID interface{}) *gomock.Call {
+func (mr *MockTagRepositoryMockRecorder) GetOne(ctx, userID, tagID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockTagRepository)(nil).GetOne), ctx, userID, tagID)
+}
+
+// Update mocks base method.
+func (m *MockTagRepository) Update(ctx context.Context, userID, tagID int, t model.Tag) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, userID, tagID, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockTagRepositoryMockRecorder) Update(ctx, userID, tagID, t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTagRepository)(nil).Update), ctx, userID, tagID, t)
+}
+
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// WithinAccountTransaction mocks base method.
+func (m *MockTransactor) WithinAccountTransaction(ctx context.Context, fn func(repository.AccountRepository, repository.TokenRepository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinAccountTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinAccountTransaction indicates an expected call of WithinAccountTransaction.
+func (mr *MockTransactorMockRecorder) WithinAccountTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinAccountTransaction", reflect.TypeOf((*MockTransactor)(nil).WithinAccountTransaction), ctx, fn)
+}
+
+// WithinTransaction mocks base method.
+func (m *MockTransactor) WithinTransaction(ctx context.Context, fn func(repository.NoteRepository, repository.TagRepository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTransaction indicates an expected call of WithinTransaction.
+func (mr *MockTransactorMockRecorder) WithinTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockTransactor)(nil).WithinTransaction), ctx, fn)
+}
+
+// MockTemplateRepository is a mock of TemplateRepository interface.
+type MockTemplateRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTemplateRepositoryMockRecorder
+}
+
+// MockTemplateRepositoryMockRecorder is the mock recorder for MockTemplateRepository.
+type MockTemplateRepositoryMockRecorder struct {
+	mock *MockTemplateRepository
+}
+
+// NewMockTemplateRepository creates a new mock instance.
+func NewMockTemplateRepository(ctrl *gomock.Controller) *MockTemplateRepository {
+	mock := &MockTemplateRepository{ctrl: ctrl}
+	mock.recorder = &MockTemplateRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTemplateRepository) EXPECT() *MockTemplateRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockTemplateRepository) Create(ctx context.Context, userID int, t *model.Template) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, userID, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockTemplateRepositoryMockRecorder) Create(ctx, userID, t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTemplateRepository)(nil).Create), ctx, userID, t)
+}
+
+// Delete mocks base method.
+func (m *MockTemplateRepository) Delete(ctx context.Context, userID, templateID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, templateID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockTemplateRepositoryMockRecorder) Delete(ctx, userID, templateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTemplateRepository)(nil).Delete), ctx, userID, templateID)
+}
+
+// GetAll mocks base method.
+func (m *MockTemplateRepository) GetAll(ctx context.Context, userID int) ([]model.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx, userID)
+	ret0, _ := ret[0].([]model.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockTemplateRepositoryMockRecorder) GetAll(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTemplateRepository)(nil).GetAll), ctx, userID)
+}
+
+// GetOne mocks base method.
+func (m *MockTemplateRepository) GetOne(ctx context.Context, userID, templateID int) (model.Template, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOne", ctx, userID, templateID)
+	ret0, _ := ret[0].(model.Template)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOne indicates an expected call of GetOne.
+func (mr *MockTemplateRepositoryMockRecorder) GetOne(ctx, userID, templateID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockTagRepository)(nil).GetOne), userID, tagID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockTemplateRepository)(nil).GetOne), ctx, userID, templateID)
 }
 
 // Update mocks base method.
-func (m *MockTagRepository) Update(userID, tagID int, t model.Tag) error {
+func (m *MockTemplateRepository) Update(ctx context.Context, userID int, t model.Template) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", userID, tagID, t)
+	ret := m.ctrl.Call(m, "Update", ctx, userID, t)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockTagRepositoryMockRecorder) Update(userID, tagID, t interface{}) *gomock.Call {
+func (mr *MockTemplateRepositoryMockRecorder) Update(ctx, userID, t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTemplateRepository)(nil).Update), ctx, userID, t)
+}
+
+// MockStatsRepository is a mock of StatsRepository interface.
+type MockStatsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatsRepositoryMockRecorder
+}
+
+// MockStatsRepositoryMockRecorder is the mock recorder for MockStatsRepository.
+type MockStatsRepositoryMockRecorder struct {
+	mock *MockStatsRepository
+}
+
+// NewMockStatsRepository creates a new mock instance.
+func NewMockStatsRepository(ctrl *gomock.Controller) *MockStatsRepository {
+	mock := &MockStatsRepository{ctrl: ctrl}
+	mock.recorder = &MockStatsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatsRepository) EXPECT() *MockStatsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetColors mocks base method.
+func (m *MockStatsRepository) GetColors(ctx context.Context, userID int) ([]model.ColorUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetColors", ctx, userID)
+	ret0, _ := ret[0].([]model.ColorUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetColors indicates an expected call of GetColors.
+func (mr *MockStatsRepositoryMockRecorder) GetColors(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetColors", reflect.TypeOf((*MockStatsRepository)(nil).GetColors), ctx, userID)
+}
+
+// GetEditedPerDay mocks base method.
+func (m *MockStatsRepository) GetEditedPerDay(ctx context.Context, userID, days int) ([]model.DayActivity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEditedPerDay", ctx, userID, days)
+	ret0, _ := ret[0].([]model.DayActivity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEditedPerDay indicates an expected call of GetEditedPerDay.
+func (mr *MockStatsRepositoryMockRecorder) GetEditedPerDay(ctx, userID, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEditedPerDay", reflect.TypeOf((*MockStatsRepository)(nil).GetEditedPerDay), ctx, userID, days)
+}
+
+// GetLastEdited mocks base method.
+func (m *MockStatsRepository) GetLastEdited(ctx context.Context, userID, limit int) ([]model.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastEdited", ctx, userID, limit)
+	ret0, _ := ret[0].([]model.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastEdited indicates an expected call of GetLastEdited.
+func (mr *MockStatsRepositoryMockRecorder) GetLastEdited(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastEdited", reflect.TypeOf((*MockStatsRepository)(nil).GetLastEdited), ctx, userID, limit)
+}
+
+// GetTopTags mocks base method.
+func (m *MockStatsRepository) GetTopTags(ctx context.Context, userID, limit int) ([]model.TagUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTopTags", ctx, userID, limit)
+	ret0, _ := ret[0].([]model.TagUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTopTags indicates an expected call of GetTopTags.
+func (mr *MockStatsRepositoryMockRecorder) GetTopTags(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTopTags", reflect.TypeOf((*MockStatsRepository)(nil).GetTopTags), ctx, userID, limit)
+}
+
+// GetTotals mocks base method.
+func (m *MockStatsRepository) GetTotals(ctx context.Context, userID int) (model.NoteTotals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotals", ctx, userID)
+	ret0, _ := ret[0].(model.NoteTotals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTotals indicates an expected call of GetTotals.
+func (mr *MockStatsRepositoryMockRecorder) GetTotals(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotals", reflect.TypeOf((*MockStatsRepository)(nil).GetTotals), ctx, userID)
+}
+
+// MockTokenRepository is a mock of TokenRepository interface.
+type MockTokenRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenRepositoryMockRecorder
+}
+
+// MockTokenRepositoryMockRecorder is the mock recorder for MockTokenRepository.
+type MockTokenRepositoryMockRecorder struct {
+	mock *MockTokenRepository
+}
+
+// NewMockTokenRepository creates a new mock instance.
+func NewMockTokenRepository(ctrl *gomock.Controller) *MockTokenRepository {
+	mock := &MockTokenRepository{ctrl: ctrl}
+	mock.recorder = &MockTokenRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenRepository) EXPECT() *MockTokenRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockTokenRepository) Consume(ctx context.Context, kind model.TokenKind, hash string) (model.AccountToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, kind, hash)
+	ret0, _ := ret[0].(model.AccountToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockTokenRepositoryMockRecorder) Consume(ctx, kind, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockTokenRepository)(nil).Consume), ctx, kind, hash)
+}
+
+// Create mocks base method.
+func (m *MockTokenRepository) Create(ctx context.Context, t *model.AccountToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockTokenRepositoryMockRecorder) Create(ctx, t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTokenRepository)(nil).Create), ctx, t)
+}
+
+// Revoke mocks base method.
+func (m *MockTokenRepository) Revoke(ctx context.Context, userID int, kind model.TokenKind) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, userID, kind)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockTokenRepositoryMockRecorder) Revoke(ctx, userID, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockTokenRepository)(nil).Revoke), ctx, userID, kind)
+}
+
+// MockExportRepository is a mock of ExportRepository interface.
+type MockExportRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportRepositoryMockRecorder
+}
+
+// MockExportRepositoryMockRecorder is the mock recorder for MockExportRepository.
+type MockExportRepositoryMockRecorder struct {
+	mock *MockExportRepository
+}
+
+// NewMockExportRepository creates a new mock instance.
+func NewMockExportRepository(ctrl *gomock.Controller) *MockExportRepository {
+	mock := &MockExportRepository{ctrl: ctrl}
+	mock.recorder = &MockExportRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportRepository) EXPECT() *MockExportRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Export mocks base method.
+func (m *MockExportRepository) Export(ctx context.Context, userID int) (model.Export, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, userID)
+	ret0, _ := ret[0].(model.Export)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockExportRepositoryMockRecorder) Export(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockExportRepository)(nil).Export), ctx, userID)
+}
+
+// MockTwoFactorRepository is a mock of TwoFactorRepository interface.
+type MockTwoFactorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorRepositoryMockRecorder
+}
+
+// MockTwoFactorRepositoryMockRecorder is the mock recorder for MockTwoFactorRepository.
+type MockTwoFactorRepositoryMockRecorder struct {
+	mock *MockTwoFactorRepository
+}
+
+// NewMockTwoFactorRepository creates a new mock instance.
+func NewMockTwoFactorRepository(ctrl *gomock.Controller) *MockTwoFactorRepository {
+	mock := &MockTwoFactorRepository{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactorRepository) EXPECT() *MockTwoFactorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Disable mocks base method.
+func (m *MockTwoFactorRepository) Disable(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Disable", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Disable indicates an expected call of Disable.
+func (mr *MockTwoFactorRepositoryMockRecorder) Disable(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disable", reflect.TypeOf((*MockTwoFactorRepository)(nil).Disable), ctx, userID)
+}
+
+// Enable mocks base method.
+func (m *MockTwoFactorRepository) Enable(ctx context.Context, userID int, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enable", ctx, userID, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enable indicates an expected call of Enable.
+func (mr *MockTwoFactorRepositoryMockRecorder) Enable(ctx, userID, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enable", reflect.TypeOf((*MockTwoFactorRepository)(nil).Enable), ctx, userID, codeHashes)
+}
+
+// SetSecret mocks base method.
+func (m *MockTwoFactorRepository) SetSecret(ctx context.Context, userID int, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSecret", ctx, userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSecret indicates an expected call of SetSecret.
+func (mr *MockTwoFactorRepositoryMockRecorder) SetSecret(ctx, userID, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSecret", reflect.TypeOf((*MockTwoFactorRepository)(nil).SetSecret), ctx, userID, secret)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockTwoFactorRepositoryMockRecorder) UseRecoveryCode(ctx, userID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockTwoFactorRepository)(nil).UseRecoveryCode), ctx, userID, codeHash)
+}
+
+// UseStep mocks base method.
+func (m *MockTwoFactorRepository) UseStep(ctx context.Context, userID int, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseStep", ctx, userID, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseStep indicates an expected call of UseStep.
+func (mr *MockTwoFactorRepositoryMockRecorder) UseStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseStep", reflect.TypeOf((*MockTwoFactorRepository)(nil).UseStep), ctx, userID, step)
+}
+
+// MockLockoutRepository is a mock of LockoutRepository interface.
+type MockLockoutRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLockoutRepositoryMockRecorder
+}
+
+// MockLockoutRepositoryMockRecorder is the mock recorder for MockLockoutRepository.
+type MockLockoutRepositoryMockRecorder struct {
+	mock *MockLockoutRepository
+}
+
+// NewMockLockoutRepository creates a new mock instance.
+func NewMockLockoutRepository(ctrl *gomock.Controller) *MockLockoutRepository {
+	mock := &MockLockoutRepository{ctrl: ctrl}
+	mock.recorder = &MockLockoutRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLockoutRepository) EXPECT() *MockLockoutRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Lock mocks base method.
+func (m *MockLockoutRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockLockoutRepositoryMockRecorder) Lock(ctx, key, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockLockoutRepository)(nil).Lock), ctx, key, until)
+}
+
+// LockedUntil mocks base method.
+func (m *MockLockoutRepository) LockedUntil(ctx context.Context, keys []string, now time.Time) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockedUntil", ctx, keys, now)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockedUntil indicates an expected call of LockedUntil.
+func (mr *MockLockoutRepositoryMockRecorder) LockedUntil(ctx, keys, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockedUntil", reflect.TypeOf((*MockLockoutRepository)(nil).LockedUntil), ctx, keys, now)
+}
+
+// RegisterFailure mocks base method.
+func (m *MockLockoutRepository) RegisterFailure(ctx context.Context, key string, now time.Time, window time.Duration) (model.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterFailure", ctx, key, now, window)
+	ret0, _ := ret[0].(model.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterFailure indicates an expected call of RegisterFailure.
+func (mr *MockLockoutRepositoryMockRecorder) RegisterFailure(ctx, key, now, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterFailure", reflect.TypeOf((*MockLockoutRepository)(nil).RegisterFailure), ctx, key, now, window)
+}
+
+// Reset mocks base method.
+func (m *MockLockoutRepository) Reset(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLockoutRepositoryMockRecorder) Reset(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLockoutRepository)(nil).Reset), ctx, key)
+}
+
+// MockRateLimitRepository is a mock of RateLimitRepository interface.
+type MockRateLimitRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateLimitRepositoryMockRecorder
+}
+
+// MockRateLimitRepositoryMockRecorder is the mock recorder for MockRateLimitRepository.
+type MockRateLimitRepositoryMockRecorder struct {
+	mock *MockRateLimitRepository
+}
+
+// NewMockRateLimitRepository creates a new mock instance.
+func NewMockRateLimitRepository(ctrl *gomock.Controller) *MockRateLimitRepository {
+	mock := &MockRateLimitRepository{ctrl: ctrl}
+	mock.recorder = &MockRateLimitRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateLimitRepository) EXPECT() *MockRateLimitRepositoryMockRecorder {
+	return m.recorder
+}
+
+// PurgeIdle mocks base method.
+func (m *MockRateLimitRepository) PurgeIdle(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeIdle", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeIdle indicates an expected call of PurgeIdle.
+func (mr *MockRateLimitRepositoryMockRecorder) PurgeIdle(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeIdle", reflect.TypeOf((*MockRateLimitRepository)(nil).PurgeIdle), ctx, before)
+}
+
+// Take mocks base method.
+func (m *MockRateLimitRepository) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", ctx, key, limit, now)
+	ret0, _ := ret[0].(ratelimit.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockRateLimitRepositoryMockRecorder) Take(ctx, key, limit, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockRateLimitRepository)(nil).Take), ctx, key, limit, now)
+}
+
+// MockOIDCRepository is a mock of OIDCRepository interface.
+type MockOIDCRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCRepositoryMockRecorder
+}
+
+// MockOIDCRepositoryMockRecorder is the mock recorder for MockOIDCRepository.
+type MockOIDCRepositoryMockRecorder struct {
+	mock *MockOIDCRepository
+}
+
+// NewMockOIDCRepository creates a new mock instance.
+func NewMockOIDCRepository(ctrl *gomock.Controller) *MockOIDCRepository {
+	mock := &MockOIDCRepository{ctrl: ctrl}
+	mock.recorder = &MockOIDCRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDCRepository) EXPECT() *MockOIDCRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ConsumeFlow mocks base method.
+func (m *MockOIDCRepository) ConsumeFlow(ctx context.Context, state string) (model.OIDCFlow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeFlow", ctx, state)
+	ret0, _ := ret[0].(model.OIDCFlow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeFlow indicates an expected call of ConsumeFlow.
+func (mr *MockOIDCRepositoryMockRecorder) ConsumeFlow(ctx, state interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeFlow", reflect.TypeOf((*MockOIDCRepository)(nil).ConsumeFlow), ctx, state)
+}
+
+// CreateFlow mocks base method.
+func (m *MockOIDCRepository) CreateFlow(ctx context.Context, f model.OIDCFlow) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFlow", ctx, f)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateFlow indicates an expected call of CreateFlow.
+func (mr *MockOIDCRepositoryMockRecorder) CreateFlow(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFlow", reflect.TypeOf((*MockOIDCRepository)(nil).CreateFlow), ctx, f)
+}
+
+// FindUser mocks base method.
+func (m *MockOIDCRepository) FindUser(ctx context.Context, issuer, subject string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUser", ctx, issuer, subject)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUser indicates an expected call of FindUser.
+func (mr *MockOIDCRepositoryMockRecorder) FindUser(ctx, issuer, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUser", reflect.TypeOf((*MockOIDCRepository)(nil).FindUser), ctx, issuer, subject)
+}
+
+// Link mocks base method.
+func (m *MockOIDCRepository) Link(ctx context.Context, userID int, issuer, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Link", ctx, userID, issuer, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Link indicates an expected call of Link.
+func (mr *MockOIDCRepositoryMockRecorder) Link(ctx, userID, issuer, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Link", reflect.TypeOf((*MockOIDCRepository)(nil).Link), ctx, userID, issuer, subject)
+}
+
+// MockSessionRepository is a mock of SessionRepository interface.
+type MockSessionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionRepositoryMockRecorder
+}
+
+// MockSessionRepositoryMockRecorder is the mock recorder for MockSessionRepository.
+type MockSessionRepositoryMockRecorder struct {
+	mock *MockSessionRepository
+}
+
+// NewMockSessionRepository creates a new mock instance.
+func NewMockSessionRepository(ctrl *gomock.Controller) *MockSessionRepository {
+	mock := &MockSessionRepository{ctrl: ctrl}
+	mock.recorder = &MockSessionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionRepository) EXPECT() *MockSessionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockSessionRepository) Create(ctx context.Context, s model.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, s)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSessionRepositoryMockRecorder) Create(ctx, s interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSessionRepository)(nil).Create), ctx, s)
+}
+
+// Delete mocks base method.
+func (m *MockSessionRepository) Delete(ctx context.Context, userID int, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSessionRepositoryMockRecorder) Delete(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSessionRepository)(nil).Delete), ctx, userID, sessionID)
+}
+
+// DeleteExpired mocks base method.
+func (m *MockSessionRepository) DeleteExpired(ctx context.Context, userID int, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, userID, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockSessionRepositoryMockRecorder) DeleteExpired(ctx, userID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockSessionRepository)(nil).DeleteExpired), ctx, userID, now)
+}
+
+// GetAll mocks base method.
+func (m *MockSessionRepository) GetAll(ctx context.Context, userID int, now time.Time) ([]model.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx, userID, now)
+	ret0, _ := ret[0].([]model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockSessionRepositoryMockRecorder) GetAll(ctx, userID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockSessionRepository)(nil).GetAll), ctx, userID, now)
+}
+
+// Touch mocks base method.
+func (m *MockSessionRepository) Touch(ctx context.Context, userID int, sessionID string, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Touch", ctx, userID, sessionID, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Touch indicates an expected call of Touch.
+func (mr *MockSessionRepositoryMockRecorder) Touch(ctx, userID, sessionID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Touch", reflect.TypeOf((*MockSessionRepository)(nil).Touch), ctx, userID, sessionID, now)
+}
+
+// MockAdminRepository is a mock of AdminRepository interface.
+type MockAdminRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminRepositoryMockRecorder
+}
+
+// MockAdminRepositoryMockRecorder is the mock recorder for MockAdminRepository.
+type MockAdminRepositoryMockRecorder struct {
+	mock *MockAdminRepository
+}
+
+// NewMockAdminRepository creates a new mock instance.
+func NewMockAdminRepository(ctrl *gomock.Controller) *MockAdminRepository {
+	mock := &MockAdminRepository{ctrl: ctrl}
+	mock.recorder = &MockAdminRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminRepository) EXPECT() *MockAdminRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetOne mocks base method.
+func (m *MockAdminRepository) GetOne(ctx context.Context, userID int) (model.AdminAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOne", ctx, userID)
+	ret0, _ := ret[0].(model.AdminAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOne indicates an expected call of GetOne.
+func (mr *MockAdminRepositoryMockRecorder) GetOne(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockAdminRepository)(nil).GetOne), ctx, userID)
+}
+
+// GrantAdmin mocks base method.
+func (m *MockAdminRepository) GrantAdmin(ctx context.Context, usernames []string) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantAdmin", ctx, usernames)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantAdmin indicates an expected call of GrantAdmin.
+func (mr *MockAdminRepositoryMockRecorder) GrantAdmin(ctx, usernames interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantAdmin", reflect.TypeOf((*MockAdminRepository)(nil).GrantAdmin), ctx, usernames)
+}
+
+// Search mocks base method.
+func (m *MockAdminRepository) Search(ctx context.Context, s model.AdminSearch) ([]model.AdminAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, s)
+	ret0, _ := ret[0].([]model.AdminAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockAdminRepositoryMockRecorder) Search(ctx, s interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockAdminRepository)(nil).Search), ctx, s)
+}
+
+// SetDisabled mocks base method.
+func (m *MockAdminRepository) SetDisabled(ctx context.Context, userID int, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDisabled", ctx, userID, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDisabled indicates an expected call of SetDisabled.
+func (mr *MockAdminRepositoryMockRecorder) SetDisabled(ctx, userID, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDisabled", reflect.TypeOf((*MockAdminRepository)(nil).SetDisabled), ctx, userID, disabled)
+}
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockAuditRepository) Create(ctx context.Context, a model.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, a)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAuditRepositoryMockRecorder) Create(ctx, a interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAuditRepository)(nil).Create), ctx, a)
+}
+
+// GetAll mocks base method.
+func (m *MockAuditRepository) GetAll(ctx context.Context, p model.AuditPage) ([]model.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx, p)
+	ret0, _ := ret[0].([]model.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockAuditRepositoryMockRecorder) GetAll(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockAuditRepository)(nil).GetAll), ctx, p)
+}
+
+// GetByUser mocks base method.
+func (m *MockAuditRepository) GetByUser(ctx context.Context, userID int, p model.AuditPage) ([]model.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUser", ctx, userID, p)
+	ret0, _ := ret[0].([]model.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUser indicates an expected call of GetByUser.
+func (mr *MockAuditRepositoryMockRecorder) GetByUser(ctx, userID, p interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTagRepository)(nil).Update), userID, tagID, t)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUser", reflect.TypeOf((*MockAuditRepository)(nil).GetByUser), ctx, userID, p)
 }
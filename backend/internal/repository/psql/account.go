@@ -1,15 +1,21 @@
 package psql
 
 import (
+	"context"
+	"database/sql"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"neatly/internal/model"
 	"neatly/pkg/dbclient"
 	"neatly/pkg/e"
 	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
 )
 
 type AccountPostgres struct {
 	db     *sqlx.DB
+	tx     *sqlx.Tx
 	logger logging.Logger
 }
 
@@ -20,27 +26,192 @@ func NewAccountPostgres(client *dbclient.Client, logger logging.Logger) *Account
 	}
 }
 
-func (r *AccountPostgres) CreateAccount(a *model.Account) error {
+func (r *AccountPostgres) ex() executor {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+func (r *AccountPostgres) CreateAccount(ctx context.Context, a *model.Account) error {
+	defer metrics.ObserveQuery("account", "CreateAccount", time.Now())
 	query := `INSERT INTO users
               (name, username, email, password_hash)
               VALUES ($1, $2, $3, $4) RETURNING id`
 
-	row := r.db.QueryRow(query, a.Name, a.Username, a.Email, a.PasswordHash)
+	row := r.ex().QueryRowContext(ctx, query, a.Name, a.Username, a.Email, a.PasswordHash)
 	if err := row.Scan(&a.ID); err != nil {
-		r.logger.Infof("Internal error: %v", err.Error())
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
 		return ParsePsqlError(err)
 	}
 	return nil
 }
 
-func (r *AccountPostgres) AuthorizeAccount(a *model.Account) error {
-	query := `SELECT id, name, username, password_hash, email
+func (r *AccountPostgres) AuthorizeAccount(ctx context.Context, a *model.Account) error {
+	defer metrics.ObserveQuery("account", "AuthorizeAccount", time.Now())
+	query := `SELECT id, name, username, password_hash, email, email_verified, session_version, delete_after,
+			  totp_secret, totp_enabled, role, disabled
 			  FROM users WHERE username=$1`
 
-	err := r.db.Get(a, query, &a.Username)
-	if err != nil {
+	err := r.ex().GetContext(ctx, a, query, &a.Username)
+	if err == sql.ErrNoRows {
 		return e.ClientAuthorizeError
 	}
+	if err != nil {
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *AccountPostgres) GetOne(ctx context.Context, userID int) (model.Account, error) {
+	defer metrics.ObserveQuery("account", "GetOne", time.Now())
+	var a model.Account
+
+	query := `SELECT id, name, username, password_hash, email, email_verified, session_version, delete_after,
+			  totp_secret, totp_enabled, role, disabled
+			  FROM users WHERE id=$1`
+
+	err := r.ex().GetContext(ctx, &a, query, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
+		if err == sql.ErrNoRows {
+			return a, e.ClientAuthorizeError
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+func (r *AccountPostgres) GetByEmail(ctx context.Context, email string) ([]model.Account, error) {
+	defer metrics.ObserveQuery("account", "GetByEmail", time.Now())
+	accounts := make([]model.Account, 0)
+
+	query := `SELECT id, name, username, password_hash, email, email_verified, session_version, delete_after,
+			  totp_secret, totp_enabled, role, disabled
+			  FROM users WHERE lower(email)=lower($1)`
+
+	err := r.ex().SelectContext(ctx, &accounts, query, email)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return accounts, err
+}
+
+// UpdatePassword sets new password, increases session version of account
+// and removes its sessions, so tokens issued before stop working. New
+// version is returned.
+func (r *AccountPostgres) UpdatePassword(ctx context.Context, userID int, passwordHash string) (int, error) {
+	defer metrics.ObserveQuery("account", "UpdatePassword", time.Now())
+	var version int
+
+	query := `WITH revoked AS (DELETE FROM sessions WHERE users_id=$2)
+			  UPDATE users SET password_hash=$1, session_version=session_version+1
+			  WHERE id=$2 RETURNING session_version`
+
+	err := r.ex().QueryRowContext(ctx, query, passwordHash, userID).Scan(&version)
+	if err != nil {
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
+		if err == sql.ErrNoRows {
+			return 0, e.ClientAuthorizeError
+		}
+		return 0, err
+	}
+	return version, nil
+}
+
+// RehashPassword replaces hash of the same password, unlike UpdatePassword
+// it keeps issued tokens valid
+func (r *AccountPostgres) RehashPassword(ctx context.Context, userID int, passwordHash string) error {
+	defer metrics.ObserveQuery("account", "RehashPassword", time.Now())
+	query := `UPDATE users SET password_hash=$1 WHERE id=$2`
+
+	_, err := r.ex().ExecContext(ctx, query, passwordHash, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
+		return err
+	}
+	return nil
+}
 
+func (r *AccountPostgres) Update(ctx context.Context, a model.Account) error {
+	defer metrics.ObserveQuery("account", "Update", time.Now())
+	query := `UPDATE users SET name=$1, username=$2, email=$3, email_verified=$4 WHERE id=$5`
+
+	res, err := r.ex().ExecContext(ctx, query, a.Name, a.Username, a.Email, a.Verified, a.ID)
+	if err != nil {
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
+		return ParsePsqlError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return e.ClientAuthorizeError
+	}
 	return nil
 }
+
+func (r *AccountPostgres) SetVerified(ctx context.Context, userID int, verified bool) error {
+	defer metrics.ObserveQuery("account", "SetVerified", time.Now())
+	query := `UPDATE users SET email_verified=$1 WHERE id=$2`
+
+	_, err := r.ex().ExecContext(ctx, query, verified, userID)
+	return err
+}
+
+// ScheduleDeletion marks account to be purged after given moment and revokes
+// its sessions
+func (r *AccountPostgres) ScheduleDeletion(ctx context.Context, userID int, deleteAfter time.Time) error {
+	defer metrics.ObserveQuery("account", "ScheduleDeletion", time.Now())
+	query := `WITH revoked AS (DELETE FROM sessions WHERE users_id=$2)
+			  UPDATE users SET delete_after=$1, session_version=session_version+1 WHERE id=$2`
+
+	_, err := r.ex().ExecContext(ctx, query, deleteAfter, userID)
+	return err
+}
+
+func (r *AccountPostgres) CancelDeletion(ctx context.Context, userID int) error {
+	defer metrics.ObserveQuery("account", "CancelDeletion", time.Now())
+	query := `UPDATE users SET delete_after=NULL WHERE id=$1`
+
+	_, err := r.ex().ExecContext(ctx, query, userID)
+	return err
+}
+
+// PurgeDeleted removes accounts whose grace period is over along with their
+// notes, tags and templates. Cascades from users only clear link tables, so
+// owned rows are deleted explicitly.
+func (r *AccountPostgres) PurgeDeleted(ctx context.Context, now time.Time) (int, error) {
+	defer metrics.ObserveQuery("account", "PurgeDeleted", time.Now())
+	tx, err := beginScope(ctx, r.db, r.tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []int64
+	selectQuery := `SELECT id FROM users WHERE delete_after IS NOT NULL AND delete_after <= $1 FOR UPDATE`
+	if err := tx.SelectContext(ctx, &ids, selectQuery, now); err != nil {
+		tx.Rollback()
+		r.logger.WithContext(ctx).Info(err)
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, tx.Rollback()
+	}
+
+	queries := []string{
+		`DELETE FROM tags WHERE id IN (SELECT tags_id FROM users_tags WHERE users_id = ANY($1))`,
+		`DELETE FROM notes WHERE id IN (SELECT notes_id FROM users_notes WHERE users_id = ANY($1))`,
+		`DELETE FROM templates WHERE id IN (SELECT templates_id FROM users_templates WHERE users_id = ANY($1))`,
+		`DELETE FROM users WHERE id = ANY($1)`,
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+			tx.Rollback()
+			r.logger.WithContext(ctx).Info(err)
+			return 0, err
+		}
+	}
+
+	return len(ids), tx.Commit()
+}
@@ -0,0 +1,101 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"neatly/internal/model"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"strings"
+	"time"
+)
+
+const adminAccountColumns = `id, name, username, email, email_verified, role, disabled, totp_enabled, delete_after,
+	(SELECT count(*) FROM users_notes WHERE users_notes.users_id = users.id) AS note_count,
+	(SELECT count(*) FROM users_tags WHERE users_tags.users_id = users.id) AS tag_count`
+
+type AdminPostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewAdminPostgres(client *dbclient.Client, logger logging.Logger) *AdminPostgres {
+	return &AdminPostgres{db: client.DB, logger: logger}
+}
+
+func (r *AdminPostgres) Search(ctx context.Context, s model.AdminSearch) ([]model.AdminAccount, error) {
+	defer metrics.ObserveQuery("admin", "Search", time.Now())
+	accounts := make([]model.AdminAccount, 0)
+
+	query := `SELECT ` + adminAccountColumns + ` FROM users
+			  WHERE $1 = '' OR name ILIKE $2 OR username ILIKE $2 OR email ILIKE $2
+			  ORDER BY id LIMIT $3 OFFSET $4`
+
+	err := r.db.SelectContext(ctx, &accounts, query, s.Query, "%"+escapeLike(s.Query)+"%", s.Limit, s.Offset)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return accounts, err
+}
+
+func (r *AdminPostgres) GetOne(ctx context.Context, userID int) (model.AdminAccount, error) {
+	defer metrics.ObserveQuery("admin", "GetOne", time.Now())
+	var a model.AdminAccount
+
+	query := `SELECT ` + adminAccountColumns + ` FROM users WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &a, query, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		if err == sql.ErrNoRows {
+			return a, e.ClientUserError
+		}
+	}
+	return a, err
+}
+
+// SetDisabled enables or disables account. Disabling also revokes sessions
+// of the account, so it is signed out everywhere.
+func (r *AdminPostgres) SetDisabled(ctx context.Context, userID int, disabled bool) error {
+	defer metrics.ObserveQuery("admin", "SetDisabled", time.Now())
+	query := `UPDATE users SET disabled = $1 WHERE id = $2`
+	if disabled {
+		query = `WITH revoked AS (DELETE FROM sessions WHERE users_id = $2)
+				 UPDATE users SET disabled = $1, session_version = session_version + 1 WHERE id = $2`
+	}
+
+	res, err := r.db.ExecContext(ctx, query, disabled, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return e.ClientUserError
+	}
+	return nil
+}
+
+// GrantAdmin gives admin role to accounts with given usernames and returns
+// IDs of the ones which didn't have it yet
+func (r *AdminPostgres) GrantAdmin(ctx context.Context, usernames []string) ([]int, error) {
+	defer metrics.ObserveQuery("admin", "GrantAdmin", time.Now())
+	ids := make([]int, 0)
+
+	query := `UPDATE users SET role = $1 WHERE username = ANY($2) AND role <> $1 RETURNING id`
+
+	if err := r.db.SelectContext(ctx, &ids, query, model.RoleAdmin, pq.Array(usernames)); err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
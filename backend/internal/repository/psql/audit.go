@@ -0,0 +1,61 @@
+package psql
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"neatly/internal/model"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
+)
+
+const auditColumns = `id, actor_id, users_id, action, target, ip, request_id, before, after, created_at`
+
+// AuditPostgres only appends to audit log, table rejects changes of entries
+type AuditPostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewAuditPostgres(client *dbclient.Client, logger logging.Logger) *AuditPostgres {
+	return &AuditPostgres{db: client.DB, logger: logger}
+}
+
+func (r *AuditPostgres) Create(ctx context.Context, a model.AuditEntry) error {
+	defer metrics.ObserveQuery("audit", "Create", time.Now())
+	query := `INSERT INTO audit_log (actor_id, users_id, action, target, ip, request_id, before, after, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := r.db.ExecContext(ctx, query, a.ActorID, a.UserID, a.Action, a.Target, a.IP, a.RequestID, a.Before, a.After, a.CreatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return err
+}
+
+func (r *AuditPostgres) GetByUser(ctx context.Context, userID int, p model.AuditPage) ([]model.AuditEntry, error) {
+	defer metrics.ObserveQuery("audit", "GetByUser", time.Now())
+	entries := make([]model.AuditEntry, 0)
+
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE users_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`
+
+	err := r.db.SelectContext(ctx, &entries, query, userID, p.Limit, p.Offset)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return entries, err
+}
+
+func (r *AuditPostgres) GetAll(ctx context.Context, p model.AuditPage) ([]model.AuditEntry, error) {
+	defer metrics.ObserveQuery("audit", "GetAll", time.Now())
+	entries := make([]model.AuditEntry, 0)
+
+	query := `SELECT ` + auditColumns + ` FROM audit_log ORDER BY id DESC LIMIT $1 OFFSET $2`
+
+	err := r.db.SelectContext(ctx, &entries, query, p.Limit, p.Offset)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return entries, err
+}
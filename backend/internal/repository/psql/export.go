@@ -0,0 +1,96 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"neatly/internal/model"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
+)
+
+type ExportPostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewExportPostgres(client *dbclient.Client, logger logging.Logger) *ExportPostgres {
+	return &ExportPostgres{db: client.DB, logger: logger}
+}
+
+// Export reads all data of user within one snapshot, so archive is
+// consistent even if user keeps editing notes meanwhile
+func (r *ExportPostgres) Export(ctx context.Context, userID int) (model.Export, error) {
+	defer metrics.ObserveQuery("export", "Export", time.Now())
+	ex := model.Export{
+		Notes:       make([]model.ExportNote, 0),
+		Tags:        make([]model.Tag, 0),
+		Assignments: make([]model.TagAssignment, 0),
+		Templates:   make([]model.Template, 0),
+	}
+
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return ex, err
+	}
+	defer tx.Rollback()
+
+	profileQuery := `SELECT name, username, email, email_verified FROM users WHERE id = $1`
+	if err := tx.GetContext(ctx, &ex.Profile, profileQuery, userID); err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		if err == sql.ErrNoRows {
+			return ex, e.ClientAuthorizeError
+		}
+		return ex, err
+	}
+
+	notesQuery := `SELECT n.id, n.header, COALESCE(nb.body, '') AS body, n.color, n.edited,
+				   n.pinned, n.archived, n.favourite, n.notebook FROM notes n
+				   JOIN users_notes un ON n.id = un.notes_id
+				   LEFT JOIN notes_body nb ON nb.id = n.id
+				   WHERE un.users_id = $1 ORDER BY n.id`
+	if err := tx.SelectContext(ctx, &ex.Notes, notesQuery, userID); err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return ex, err
+	}
+
+	tagsQuery := `SELECT t.id, t.label FROM tags t
+				  JOIN users_tags ut ON t.id = ut.tags_id
+				  WHERE ut.users_id = $1 ORDER BY t.id`
+	if err := tx.SelectContext(ctx, &ex.Tags, tagsQuery, userID); err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return ex, err
+	}
+
+	assignmentsQuery := `SELECT tn.tags_id, tn.notes_id FROM tags_notes tn
+						 JOIN users_notes un ON tn.notes_id = un.notes_id
+						 WHERE un.users_id = $1 ORDER BY tn.notes_id, tn.tags_id`
+	if err := tx.SelectContext(ctx, &ex.Assignments, assignmentsQuery, userID); err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return ex, err
+	}
+
+	templatesQuery := `SELECT t.id, t.header, t.body, t.color, t.tags FROM templates t
+					   JOIN users_templates ut ON t.id = ut.templates_id
+					   WHERE ut.users_id = $1 ORDER BY t.id`
+	rows, err := tx.QueryContext(ctx, templatesQuery, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return ex, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).Info(err)
+			return ex, err
+		}
+		ex.Templates = append(ex.Templates, t)
+	}
+
+	return ex, rows.Err()
+}
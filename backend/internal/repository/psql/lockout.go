@@ -0,0 +1,78 @@
+package psql
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"neatly/internal/model"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
+)
+
+type LockoutPostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewLockoutPostgres(client *dbclient.Client, logger logging.Logger) *LockoutPostgres {
+	return &LockoutPostgres{db: client.DB, logger: logger}
+}
+
+// LockedUntil returns the latest lock among keys which is still active at
+// now, or zero time if none of keys is locked
+func (r *LockoutPostgres) LockedUntil(ctx context.Context, keys []string, now time.Time) (time.Time, error) {
+	defer metrics.ObserveQuery("lockout", "LockedUntil", time.Now())
+	var until *time.Time
+
+	query := `SELECT MAX(locked_until) FROM login_attempts
+			  WHERE key = ANY($1) AND locked_until > $2`
+
+	err := r.db.GetContext(ctx, &until, query, pq.Array(keys), now)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return time.Time{}, err
+	}
+	if until == nil {
+		return time.Time{}, nil
+	}
+	return *until, nil
+}
+
+// RegisterFailure increments failures of key. Counter starts over when
+// neither failure nor lock happened within window.
+func (r *LockoutPostgres) RegisterFailure(ctx context.Context, key string, now time.Time, window time.Duration) (model.LoginAttempt, error) {
+	defer metrics.ObserveQuery("lockout", "RegisterFailure", time.Now())
+	var a model.LoginAttempt
+
+	query := `INSERT INTO login_attempts (key, failures, last_failure) VALUES ($1, 1, $2)
+			  ON CONFLICT (key) DO UPDATE SET
+			  failures = CASE WHEN login_attempts.last_failure < $2 - make_interval(secs => $3)
+			  AND COALESCE(login_attempts.locked_until, login_attempts.last_failure) < $2 - make_interval(secs => $3)
+			  THEN 1 ELSE login_attempts.failures + 1 END,
+			  last_failure = $2
+			  RETURNING key, failures, last_failure, locked_until`
+
+	err := r.db.GetContext(ctx, &a, query, key, now, window.Seconds())
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return a, err
+}
+
+func (r *LockoutPostgres) Lock(ctx context.Context, key string, until time.Time) error {
+	defer metrics.ObserveQuery("lockout", "Lock", time.Now())
+	query := `UPDATE login_attempts SET locked_until = $1 WHERE key = $2`
+
+	_, err := r.db.ExecContext(ctx, query, until, key)
+	return err
+}
+
+func (r *LockoutPostgres) Reset(ctx context.Context, key string) error {
+	defer metrics.ObserveQuery("lockout", "Reset", time.Now())
+	query := `DELETE FROM login_attempts WHERE key = $1`
+
+	_, err := r.db.ExecContext(ctx, query, key)
+	return err
+}
@@ -1,17 +1,21 @@
 package psql
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"neatly/internal/model"
 	"neatly/pkg/dbclient"
 	"neatly/pkg/e"
 	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
 	"time"
 )
 
 type NotePostgres struct {
 	db     *sqlx.DB
+	tx     *sqlx.Tx
 	logger logging.Logger
 }
 
@@ -22,80 +26,93 @@ func NewNotePostgres(client *dbclient.Client, logger logging.Logger) *NotePostgr
 	}
 }
 
-func (r *NotePostgres) Create(userID int, n *model.Note) error {
-	tx, err := r.db.Begin()
+func (r *NotePostgres) ex() executor {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+func (r *NotePostgres) Create(ctx context.Context, userID int, n *model.Note) error {
+	defer metrics.ObserveQuery("note", "Create", time.Now())
+	tx, err := beginScope(ctx, r.db, r.tx)
 	if err != nil {
 		return err
 	}
 
-	createNoteQuery := `INSERT INTO notes (header, short_body, color, edited)
-						VALUES ($1, $2, $3, $4) RETURNING id`
+	createNoteQuery := `INSERT INTO notes (header, short_body, color, edited, notebook)
+						VALUES ($1, $2, $3, $4, $5) RETURNING id`
 
-	row := tx.QueryRow(createNoteQuery, n.Header, n.ShortBody, n.Color, time.Now())
+	row := tx.QueryRowContext(ctx, createNoteQuery, n.Header, n.ShortBody, n.Color, time.Now(), n.Notebook)
 	if err := row.Scan(&n.ID); err != nil {
 		tx.Rollback()
-		r.logger.Error(err)
+		r.logger.WithContext(ctx).Error(err)
 		return e.InternalDBError
 	}
 	createNoteBodyQuery := `INSERT INTO notes_body (id, body) VALUES ($1, $2)`
-	_, err = tx.Exec(createNoteBodyQuery, n.ID, n.Body)
+	_, err = tx.ExecContext(ctx, createNoteBodyQuery, n.ID, n.Body)
 	if err != nil {
 		tx.Rollback()
-		r.logger.Error(err)
+		r.logger.WithContext(ctx).Error(err)
 		return e.InternalDBError
 	}
 	createUsersNoteQuery := `INSERT INTO users_notes (users_id, notes_id) VALUES ($1, $2)`
-	_, err = tx.Exec(createUsersNoteQuery, userID, n.ID)
+	_, err = tx.ExecContext(ctx, createUsersNoteQuery, userID, n.ID)
 	if err != nil {
 		tx.Rollback()
-		r.logger.Error(err)
+		r.logger.WithContext(ctx).Error(err)
 		return e.InternalDBError
 	}
 
 	return tx.Commit()
 }
 
-func (r *NotePostgres) GetAll(userID int) ([]model.Note, error) {
+func (r *NotePostgres) GetAll(ctx context.Context, userID int) ([]model.Note, error) {
+	defer metrics.ObserveQuery("note", "GetAll", time.Now())
 	var notes []model.Note
 	notes = make([]model.Note, 0)
 
-	getNotesQuery := `SELECT n.id, n.header, n.short_body, n.color, n.edited FROM notes n
+	getNotesQuery := `SELECT n.id, n.header, n.short_body, n.color, n.edited,
+    			      n.pinned, n.archived, n.favourite, n.notebook FROM notes n
     			      JOIN users_notes un ON n.id = un.notes_id
-    			      WHERE un.users_id = $1`
+    			      WHERE un.users_id = $1 ORDER BY n.pinned DESC, n.id`
 
-	err := r.db.Select(&notes, getNotesQuery, userID)
+	err := r.ex().SelectContext(ctx, &notes, getNotesQuery, userID)
 	if err != nil {
-		r.logger.Info(err)
+		r.logger.WithContext(ctx).Info(err)
 		return notes, err
 	}
 
 	return notes, err
 }
 
-func (r *NotePostgres) GetOne(userID, noteID int) (model.Note, error) {
-	tx, err := r.db.Begin()
+func (r *NotePostgres) GetOne(ctx context.Context, userID, noteID int) (model.Note, error) {
+	defer metrics.ObserveQuery("note", "GetOne", time.Now())
+	tx, err := beginScope(ctx, r.db, r.tx)
 	if err != nil {
 		return model.Note{}, err
 	}
 	var n model.Note
 
-	selectNoteQuery := `SELECT n.id, n.header, n.short_body, n.color, n.edited FROM
+	selectNoteQuery := `SELECT n.id, n.header, n.short_body, n.color, n.edited,
+				        n.pinned, n.archived, n.favourite, n.notebook FROM
 				        notes n JOIN users_notes un ON n.id = un.notes_id
 				        WHERE un.users_id = $1 AND un.notes_id = $2`
 
-	err = r.db.Get(&n, selectNoteQuery, userID, noteID)
+	err = tx.GetContext(ctx, &n, selectNoteQuery, userID, noteID)
 	if err != nil {
 		tx.Rollback()
-		r.logger.Infof("Internal error: %v", err.Error())
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
 		if err == sql.ErrNoRows {
 			return n, e.ClientNoteError
 		}
+		return n, err
 	}
 
 	selectBodyQuery := `SELECT nb.body FROM notes_body nb JOIN notes n ON nb.id = n.id
 				        WHERE n.id = $1`
 
-	err = r.db.Get(&n.Body, selectBodyQuery, noteID)
+	err = tx.GetContext(ctx, &n.Body, selectBodyQuery, noteID)
 	if err != nil {
 		tx.Rollback()
 		return n, err
@@ -104,29 +121,35 @@ func (r *NotePostgres) GetOne(userID, noteID int) (model.Note, error) {
 	return n, tx.Commit()
 }
 
-func (r *NotePostgres) Delete(userID, noteID int) error {
-	query := `DELETE FROM notes WHERE 
-              notes.id = $1`
-	_, err := r.db.Exec(query, userID)
+func (r *NotePostgres) Delete(ctx context.Context, userID, noteID int) error {
+	defer metrics.ObserveQuery("note", "Delete", time.Now())
+	query := `DELETE FROM notes USING users_notes un WHERE
+              notes.id = un.notes_id AND un.users_id = $1 AND un.notes_id = $2`
+	_, err := r.ex().ExecContext(ctx, query, userID, noteID)
 
 	return err
 }
 
-func (r *NotePostgres) Update(userID int, n model.Note) error {
-	tx, err := r.db.Begin()
+func (r *NotePostgres) Update(ctx context.Context, userID int, n model.Note) error {
+	defer metrics.ObserveQuery("note", "Update", time.Now())
+	tx, err := beginScope(ctx, r.db, r.tx)
 	if err != nil {
 		return err
 	}
 	noteQuery := `UPDATE notes SET 
-                  header=$1, short_body=$2, color = $3, edited=$4 FROM
+                  header=$1, short_body=$2, color = $3, edited=$4,
+                  pinned=$5, archived=$6, favourite=$7 FROM
                   users_notes WHERE notes.id = users_notes.notes_id AND 
-				  users_notes.notes_id = $5 AND users_notes.users_id = $6`
-	_, err = r.db.Exec(
+				  users_notes.notes_id = $8 AND users_notes.users_id = $9`
+	_, err = tx.ExecContext(ctx,
 		noteQuery,
 		n.Header,
 		n.ShortBody,
 		n.Color,
 		time.Now().UTC().Format(time.RFC3339),
+		n.Pinned,
+		n.Archived,
+		n.Favourite,
 		n.ID,
 		userID,
 	)
@@ -136,7 +159,7 @@ func (r *NotePostgres) Update(userID int, n model.Note) error {
 	}
 
 	bodyQuery := `UPDATE notes_body SET body=$2 WHERE notes_body.id = $1`
-	_, err = r.db.Exec(bodyQuery, n.ID, n.Body)
+	_, err = tx.ExecContext(ctx, bodyQuery, n.ID, n.Body)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -144,3 +167,59 @@ func (r *NotePostgres) Update(userID int, n model.Note) error {
 
 	return tx.Commit()
 }
+
+func (r *NotePostgres) UpdateState(ctx context.Context, userID int, n model.Note) error {
+	defer metrics.ObserveQuery("note", "UpdateState", time.Now())
+	query := `UPDATE notes SET pinned=$1, archived=$2, favourite=$3 FROM
+			  users_notes WHERE notes.id = users_notes.notes_id AND
+			  users_notes.notes_id = $4 AND users_notes.users_id = $5`
+
+	_, err := r.ex().ExecContext(ctx, query, n.Pinned, n.Archived, n.Favourite, n.ID, userID)
+
+	return err
+}
+
+// Move puts note into notebook, empty notebook takes it out of any
+func (r *NotePostgres) Move(ctx context.Context, userID, noteID int, notebook string) error {
+	defer metrics.ObserveQuery("note", "Move", time.Now())
+	query := `UPDATE notes SET notebook=$1 FROM
+			  users_notes WHERE notes.id = users_notes.notes_id AND
+			  users_notes.notes_id = $2 AND users_notes.users_id = $3`
+
+	_, err := r.ex().ExecContext(ctx, query, notebook, noteID, userID)
+
+	return err
+}
+
+var stateColumns = map[model.NoteState]string{
+	model.NoteStatePinned:    "pinned",
+	model.NoteStateArchived:  "archived",
+	model.NoteStateFavourite: "favourite",
+}
+
+// ToggleState flips one state flag of note in place, so concurrent toggles
+// don't overwrite each other, and returns state of note after the flip
+func (r *NotePostgres) ToggleState(ctx context.Context, userID, noteID int, state model.NoteState) (model.Note, error) {
+	defer metrics.ObserveQuery("note", "ToggleState", time.Now())
+	var n model.Note
+
+	column, ok := stateColumns[state]
+	if !ok {
+		return n, fmt.Errorf("unknown note state %v", state)
+	}
+	query := fmt.Sprintf(`UPDATE notes SET %[1]s = NOT notes.%[1]s FROM
+			  users_notes WHERE notes.id = users_notes.notes_id AND
+			  users_notes.notes_id = $1 AND users_notes.users_id = $2
+			  RETURNING notes.id, notes.pinned, notes.archived, notes.favourite`, column)
+
+	err := r.ex().GetContext(ctx, &n, query, noteID, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
+		if err == sql.ErrNoRows {
+			return n, e.ClientNoteError
+		}
+		return n, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,80 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"neatly/internal/model"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
+)
+
+type OIDCPostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewOIDCPostgres(client *dbclient.Client, logger logging.Logger) *OIDCPostgres {
+	return &OIDCPostgres{db: client.DB, logger: logger}
+}
+
+func (r *OIDCPostgres) CreateFlow(ctx context.Context, f model.OIDCFlow) error {
+	defer metrics.ObserveQuery("oidc", "CreateFlow", time.Now())
+	query := `INSERT INTO oidc_flows (state, verifier, nonce, expires_at) VALUES ($1, $2, $3, $4)`
+
+	_, err := r.db.ExecContext(ctx, query, f.State, f.Verifier, f.Nonce, f.ExpiresAt)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return err
+}
+
+// ConsumeFlow removes flow and returns it, so state can be used only once
+func (r *OIDCPostgres) ConsumeFlow(ctx context.Context, state string) (model.OIDCFlow, error) {
+	defer metrics.ObserveQuery("oidc", "ConsumeFlow", time.Now())
+	var f model.OIDCFlow
+
+	query := `DELETE FROM oidc_flows WHERE state = $1 AND expires_at > now()
+			  RETURNING state, verifier, nonce, expires_at`
+
+	err := r.db.GetContext(ctx, &f, query, state)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		if err == sql.ErrNoRows {
+			return f, e.ClientOIDCError
+		}
+	}
+	return f, err
+}
+
+// FindUser returns ID of account linked to external identity, or zero if
+// identity is not linked yet
+func (r *OIDCPostgres) FindUser(ctx context.Context, issuer, subject string) (int, error) {
+	defer metrics.ObserveQuery("oidc", "FindUser", time.Now())
+	var userID int
+
+	query := `SELECT users_id FROM external_identities WHERE issuer = $1 AND subject = $2`
+
+	err := r.db.GetContext(ctx, &userID, query, issuer, subject)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return userID, err
+}
+
+func (r *OIDCPostgres) Link(ctx context.Context, userID int, issuer, subject string) error {
+	defer metrics.ObserveQuery("oidc", "Link", time.Now())
+	query := `INSERT INTO external_identities (users_id, issuer, subject) VALUES ($1, $2, $3)`
+
+	_, err := r.db.ExecContext(ctx, query, userID, issuer, subject)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return err
+}
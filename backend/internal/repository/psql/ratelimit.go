@@ -0,0 +1,69 @@
+package psql
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"neatly/pkg/ratelimit"
+	"time"
+)
+
+// RateLimitPostgres is a ratelimit.Store shared by all replicas
+type RateLimitPostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewRateLimitPostgres(client *dbclient.Client, logger logging.Logger) *RateLimitPostgres {
+	return &RateLimitPostgres{db: client.DB, logger: logger}
+}
+
+// Take locks bucket of key, creating full one if there is none, so requests
+// of the same client are counted one by one
+func (r *RateLimitPostgres) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	defer metrics.ObserveQuery("ratelimit", "Take", time.Now())
+	var b ratelimit.Bucket
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return ratelimit.Result{}, err
+	}
+
+	full := ratelimit.NewBucket(limit, now)
+	query := `INSERT INTO rate_limits (key, tokens, updated_at) VALUES ($1, $2, $3)
+			  ON CONFLICT (key) DO UPDATE SET key = EXCLUDED.key
+			  RETURNING tokens, updated_at`
+
+	if err := tx.GetContext(ctx, &b, query, key, full.Tokens, full.UpdatedAt); err != nil {
+		tx.Rollback()
+		r.logger.WithContext(ctx).Info(err)
+		return ratelimit.Result{}, err
+	}
+
+	b, res := b.Take(limit, now)
+	query = `UPDATE rate_limits SET tokens = $1, updated_at = $2 WHERE key = $3`
+
+	if _, err := tx.ExecContext(ctx, query, b.Tokens, b.UpdatedAt, key); err != nil {
+		tx.Rollback()
+		r.logger.WithContext(ctx).Info(err)
+		return ratelimit.Result{}, err
+	}
+	return res, tx.Commit()
+}
+
+// PurgeIdle removes buckets not used since before, they are full by then
+func (r *RateLimitPostgres) PurgeIdle(ctx context.Context, before time.Time) (int, error) {
+	defer metrics.ObserveQuery("ratelimit", "PurgeIdle", time.Now())
+	query := `DELETE FROM rate_limits WHERE updated_at < $1`
+
+	res, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
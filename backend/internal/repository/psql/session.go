@@ -0,0 +1,88 @@
+package psql
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"neatly/internal/model"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
+)
+
+type SessionPostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewSessionPostgres(client *dbclient.Client, logger logging.Logger) *SessionPostgres {
+	return &SessionPostgres{db: client.DB, logger: logger}
+}
+
+func (r *SessionPostgres) Create(ctx context.Context, s model.Session) error {
+	defer metrics.ObserveQuery("session", "Create", time.Now())
+	query := `INSERT INTO sessions (id, users_id, user_agent, ip, created_at, last_seen_at, expires_at, impersonator_id)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := r.db.ExecContext(ctx, query, s.ID, s.UserID, s.UserAgent, s.IP, s.CreatedAt, s.LastSeenAt, s.ExpiresAt, s.ImpersonatorID)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return err
+}
+
+func (r *SessionPostgres) GetAll(ctx context.Context, userID int, now time.Time) ([]model.Session, error) {
+	defer metrics.ObserveQuery("session", "GetAll", time.Now())
+	sessions := make([]model.Session, 0)
+
+	query := `SELECT id, users_id, user_agent, ip, created_at, last_seen_at, expires_at, impersonator_id FROM sessions
+			  WHERE users_id = $1 AND expires_at > $2 ORDER BY last_seen_at DESC`
+
+	err := r.db.SelectContext(ctx, &sessions, query, userID, now)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return sessions, err
+}
+
+// Touch updates last-seen time of session and reports whether it exists and
+// has not expired
+func (r *SessionPostgres) Touch(ctx context.Context, userID int, sessionID string, now time.Time) (bool, error) {
+	defer metrics.ObserveQuery("session", "Touch", time.Now())
+	query := `UPDATE sessions SET last_seen_at = $3 WHERE id = $1 AND users_id = $2 AND expires_at > $3`
+
+	res, err := r.db.ExecContext(ctx, query, sessionID, userID, now)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *SessionPostgres) Delete(ctx context.Context, userID int, sessionID string) error {
+	defer metrics.ObserveQuery("session", "Delete", time.Now())
+	query := `DELETE FROM sessions WHERE id = $1 AND users_id = $2`
+
+	res, err := r.db.ExecContext(ctx, query, sessionID, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return e.ClientSessionError
+	}
+	return nil
+}
+
+func (r *SessionPostgres) DeleteExpired(ctx context.Context, userID int, now time.Time) error {
+	defer metrics.ObserveQuery("session", "DeleteExpired", time.Now())
+	query := `DELETE FROM sessions WHERE users_id = $1 AND expires_at <= $2`
+
+	_, err := r.db.ExecContext(ctx, query, userID, now)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package psql
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"neatly/internal/model"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
+)
+
+type StatsPostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewStatsPostgres(client *dbclient.Client, logger logging.Logger) *StatsPostgres {
+	return &StatsPostgres{db: client.DB, logger: logger}
+}
+
+func (r *StatsPostgres) GetTotals(ctx context.Context, userID int) (model.NoteTotals, error) {
+	defer metrics.ObserveQuery("stats", "GetTotals", time.Now())
+	var totals model.NoteTotals
+
+	query := `SELECT COUNT(n.id) AS notes,
+			  COALESCE(SUM(CASE WHEN COALESCE(btrim(nb.body), '') = '' THEN 0
+			  ELSE array_length(regexp_split_to_array(btrim(nb.body), '\s+'), 1) END), 0) AS words,
+			  COALESCE(SUM(char_length(nb.body)), 0) AS characters
+			  FROM notes n JOIN users_notes un ON n.id = un.notes_id
+			  LEFT JOIN notes_body nb ON nb.id = n.id
+			  WHERE un.users_id = $1`
+
+	err := r.db.GetContext(ctx, &totals, query, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return totals, err
+}
+
+func (r *StatsPostgres) GetEditedPerDay(ctx context.Context, userID, days int) ([]model.DayActivity, error) {
+	defer metrics.ObserveQuery("stats", "GetEditedPerDay", time.Now())
+	activity := make([]model.DayActivity, 0, days)
+
+	query := `SELECT d::date AS day, COUNT(n.id) AS edited
+			  FROM generate_series(current_date - ($2::int - 1), current_date, interval '1 day') d
+			  LEFT JOIN (notes n JOIN users_notes un ON n.id = un.notes_id AND un.users_id = $1)
+			  ON n.edited::date = d::date
+			  GROUP BY d ORDER BY d`
+
+	err := r.db.SelectContext(ctx, &activity, query, userID, days)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return activity, err
+}
+
+func (r *StatsPostgres) GetTopTags(ctx context.Context, userID, limit int) ([]model.TagUsage, error) {
+	defer metrics.ObserveQuery("stats", "GetTopTags", time.Now())
+	tags := make([]model.TagUsage, 0, limit)
+
+	query := `SELECT t.id, t.label, COUNT(tn.notes_id) AS notes FROM tags t
+			  JOIN users_tags ut ON ut.tags_id = t.id
+			  LEFT JOIN tags_notes tn ON tn.tags_id = t.id
+			  WHERE ut.users_id = $1
+			  GROUP BY t.id, t.label ORDER BY notes DESC, t.label LIMIT $2`
+
+	err := r.db.SelectContext(ctx, &tags, query, userID, limit)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return tags, err
+}
+
+func (r *StatsPostgres) GetColors(ctx context.Context, userID int) ([]model.ColorUsage, error) {
+	defer metrics.ObserveQuery("stats", "GetColors", time.Now())
+	colors := make([]model.ColorUsage, 0)
+
+	query := `SELECT n.color, COUNT(n.id) AS notes FROM notes n
+			  JOIN users_notes un ON n.id = un.notes_id
+			  WHERE un.users_id = $1
+			  GROUP BY n.color ORDER BY notes DESC, n.color`
+
+	err := r.db.SelectContext(ctx, &colors, query, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return colors, err
+}
+
+func (r *StatsPostgres) GetLastEdited(ctx context.Context, userID, limit int) ([]model.Note, error) {
+	defer metrics.ObserveQuery("stats", "GetLastEdited", time.Now())
+	notes := make([]model.Note, 0, limit)
+
+	query := `SELECT n.id, n.header, n.short_body, n.color, n.edited,
+			  n.pinned, n.archived, n.favourite, n.notebook FROM notes n
+			  JOIN users_notes un ON n.id = un.notes_id
+			  WHERE un.users_id = $1
+			  ORDER BY n.edited DESC NULLS LAST, n.id DESC LIMIT $2`
+
+	err := r.db.SelectContext(ctx, &notes, query, userID, limit)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+	}
+	return notes, err
+}
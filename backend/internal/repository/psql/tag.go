@@ -1,16 +1,20 @@
 package psql
 
 import (
+	"context"
 	"database/sql"
 	"github.com/jmoiron/sqlx"
 	"neatly/internal/model"
 	"neatly/pkg/dbclient"
 	"neatly/pkg/e"
 	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
 )
 
 type TagPostgres struct {
 	db     *sqlx.DB
+	tx     *sqlx.Tx
 	logger logging.Logger
 }
 
@@ -18,42 +22,53 @@ func NewTagPostgres(client *dbclient.Client, logger logging.Logger) *TagPostgres
 	return &TagPostgres{db: client.DB, logger: logger}
 }
 
-func (r *TagPostgres) Create(userID, noteID int, t *model.Tag) error {
+func (r *TagPostgres) ex() executor {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+func (r *TagPostgres) Create(ctx context.Context, userID, noteID int, t *model.Tag) error {
+	defer metrics.ObserveQuery("tag", "Create", time.Now())
 
-	tx, err := r.db.Begin()
+	tx, err := beginScope(ctx, r.db, r.tx)
 	if err != nil {
 		return err
 	}
 
 	createTagQuery := `INSERT INTO tags AS t (label) VALUES ($1) RETURNING id`
 
-	r.logger.Infof("Tag with id %v created", t.ID)
+	r.logger.WithContext(ctx).Infof("Tag with id %v created", t.ID)
 
-	row := r.db.QueryRow(createTagQuery, t.Label)
+	row := tx.QueryRowContext(ctx, createTagQuery, t.Label)
 	err = row.Scan(&t.ID)
 
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	r.logger.Infof("Connecting tag with id %v and accounts with id with id %v", t.ID, userID)
+	r.logger.WithContext(ctx).Infof("Connecting tag with id %v and accounts with id with id %v", t.ID, userID)
 	userTagQuery := `INSERT INTO users_tags (users_id, tags_id)
 				    SELECT $1, $2 WHERE NOT EXISTS (
     			       SELECT users_id, tags_id FROM users_tags WHERE users_id = $1 AND tags_id = $2
     			)`
-	_, err = tx.Exec(userTagQuery, userID, t.ID)
+	_, err = tx.ExecContext(ctx, userTagQuery, userID, t.ID)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 	return tx.Commit()
 }
 
-func (r *TagPostgres) Assign(tagID, noteID, userID int) error {
-	r.logger.Infof("Assigning tag with id %v to note with id with id %v", tagID, noteID)
+func (r *TagPostgres) Assign(ctx context.Context, tagID, noteID, userID int) error {
+	defer metrics.ObserveQuery("tag", "Assign", time.Now())
+	r.logger.WithContext(ctx).Infof("Assigning tag with id %v to note with id with id %v", tagID, noteID)
 	assignTagQuery := `INSERT INTO tags_notes (notes_id, tags_id) VALUES ($1, $2)`
-	_, err := r.db.Exec(assignTagQuery, noteID, tagID)
+	_, err := r.ex().ExecContext(ctx, assignTagQuery, noteID, tagID)
 	if err != nil {
-		r.logger.Infof("Internal error: %v", err.Error())
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
 		if err == sql.ErrNoRows {
 			return e.ClientNoteError
 		}
@@ -63,7 +78,8 @@ func (r *TagPostgres) Assign(tagID, noteID, userID int) error {
 	return nil
 }
 
-func (r *TagPostgres) GetAll(userID int) ([]model.Tag, error) {
+func (r *TagPostgres) GetAll(ctx context.Context, userID int) ([]model.Tag, error) {
+	defer metrics.ObserveQuery("tag", "GetAll", time.Now())
 	var tags []model.Tag
 	tags = make([]model.Tag, 0)
 
@@ -71,14 +87,15 @@ func (r *TagPostgres) GetAll(userID int) ([]model.Tag, error) {
 			  tags t JOIN users_tags ut ON ut.tags_id = t.id  WHERE
 			  ut.users_id = $1`
 
-	err := r.db.Select(&tags, query, userID)
+	err := r.ex().SelectContext(ctx, &tags, query, userID)
 	if err != nil {
-		r.logger.Info(err)
+		r.logger.WithContext(ctx).Info(err)
 	}
 	return tags, err
 }
 
-func (r *TagPostgres) GetAllByNote(userID, noteID int) ([]model.Tag, error) {
+func (r *TagPostgres) GetAllByNote(ctx context.Context, userID, noteID int) ([]model.Tag, error) {
+	defer metrics.ObserveQuery("tag", "GetAllByNote", time.Now())
 	var tags []model.Tag
 	tags = make([]model.Tag, 0)
 
@@ -87,21 +104,22 @@ func (r *TagPostgres) GetAllByNote(userID, noteID int) ([]model.Tag, error) {
     		  JOIN tags_notes nt on t.id = nt.tags_id
     		  WHERE users_id = $1 AND notes_id = $2`
 
-	err := r.db.Select(&tags, query, userID, noteID)
+	err := r.ex().SelectContext(ctx, &tags, query, userID, noteID)
 	if err != nil {
-		r.logger.Info(err)
+		r.logger.WithContext(ctx).Info(err)
 	}
 	return tags, err
 }
 
-func (r *TagPostgres) GetOne(userID, tagID int) (model.Tag, error) {
+func (r *TagPostgres) GetOne(ctx context.Context, userID, tagID int) (model.Tag, error) {
+	defer metrics.ObserveQuery("tag", "GetOne", time.Now())
 	var t model.Tag
 
 	query := `SELECT t.id AS id, label FROM tags t where t.id = $1`
 
-	err := r.db.Get(&t, query, tagID)
+	err := r.ex().GetContext(ctx, &t, query, tagID)
 	if err != nil {
-		r.logger.Infof("Internal error: %v", err.Error())
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
 		if err == sql.ErrNoRows {
 			return t, e.ClientTagError
 		}
@@ -109,27 +127,30 @@ func (r *TagPostgres) GetOne(userID, tagID int) (model.Tag, error) {
 	return t, nil
 }
 
-func (r *TagPostgres) Delete(userID, tagID int) error {
+func (r *TagPostgres) Delete(ctx context.Context, userID, tagID int) error {
+	defer metrics.ObserveQuery("tag", "Delete", time.Now())
 	query := `DELETE FROM tags t USING users_tags ut WHERE 
               t.id = ut.tags_id AND ut.users_id = $1 AND ut.tags_id = $2`
-	_, err := r.db.Exec(query, userID, tagID)
+	_, err := r.ex().ExecContext(ctx, query, userID, tagID)
 
 	return err
 }
 
-func (r *TagPostgres) Update(userID, tagID int, t model.Tag) error {
+func (r *TagPostgres) Update(ctx context.Context, userID, tagID int, t model.Tag) error {
+	defer metrics.ObserveQuery("tag", "Update", time.Now())
 	query := `UPDATE tags t SET label=$1 FROM users_tags ut
               WHERE t.id = ut.tags_id AND ut.tags_id = $2 AND ut.users_id = $3`
 
-	_, err := r.db.Exec(query, t.Label, tagID, userID)
+	_, err := r.ex().ExecContext(ctx, query, t.Label, tagID, userID)
 
 	return err
 }
 
-func (r *TagPostgres) Detach(userID, tagID, noteID int) error {
+func (r *TagPostgres) Detach(ctx context.Context, userID, tagID, noteID int) error {
+	defer metrics.ObserveQuery("tag", "Detach", time.Now())
 	query := `DELETE FROM tags_notes USING users_tags ut WHERE
               tags_notes.tags_id = ut.tags_id AND ut.users_id = $1 AND ut.tags_id = $2 AND notes_id = $3`
-	_, err := r.db.Exec(query, userID, tagID, noteID)
+	_, err := r.ex().ExecContext(ctx, query, userID, tagID, noteID)
 
 	return err
 }
@@ -0,0 +1,138 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"neatly/internal/model"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
+)
+
+type TemplatePostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewTemplatePostgres(client *dbclient.Client, logger logging.Logger) *TemplatePostgres {
+	return &TemplatePostgres{db: client.DB, logger: logger}
+}
+
+func (r *TemplatePostgres) Create(ctx context.Context, userID int, t *model.Template) error {
+	defer metrics.ObserveQuery("template", "Create", time.Now())
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	createTemplateQuery := `INSERT INTO templates (header, body, color, tags)
+							VALUES ($1, $2, $3, $4) RETURNING id`
+
+	row := tx.QueryRowContext(ctx, createTemplateQuery, t.Header, t.Body, t.Color, pq.Array(t.Tags))
+	if err := row.Scan(&t.ID); err != nil {
+		tx.Rollback()
+		r.logger.WithContext(ctx).Error(err)
+		return e.InternalDBError
+	}
+
+	createUsersTemplateQuery := `INSERT INTO users_templates (users_id, templates_id) VALUES ($1, $2)`
+	_, err = tx.ExecContext(ctx, createUsersTemplateQuery, userID, t.ID)
+	if err != nil {
+		tx.Rollback()
+		r.logger.WithContext(ctx).Error(err)
+		return e.InternalDBError
+	}
+
+	return tx.Commit()
+}
+
+func (r *TemplatePostgres) GetAll(ctx context.Context, userID int) ([]model.Template, error) {
+	defer metrics.ObserveQuery("template", "GetAll", time.Now())
+	templates := make([]model.Template, 0)
+
+	query := `SELECT t.id, t.header, t.body, t.color, t.tags FROM templates t
+			  JOIN users_templates ut ON t.id = ut.templates_id
+			  WHERE ut.users_id = $1 ORDER BY t.id`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return templates, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			r.logger.WithContext(ctx).Info(err)
+			return templates, err
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+}
+
+func (r *TemplatePostgres) GetOne(ctx context.Context, userID, templateID int) (model.Template, error) {
+	defer metrics.ObserveQuery("template", "GetOne", time.Now())
+	query := `SELECT t.id, t.header, t.body, t.color, t.tags FROM templates t
+			  JOIN users_templates ut ON t.id = ut.templates_id
+			  WHERE ut.users_id = $1 AND ut.templates_id = $2`
+
+	t, err := scanTemplate(r.db.QueryRowContext(ctx, query, userID, templateID))
+	if err != nil {
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
+		if err == sql.ErrNoRows {
+			return t, e.ClientTemplateError
+		}
+		return t, err
+	}
+
+	return t, nil
+}
+
+func (r *TemplatePostgres) Update(ctx context.Context, userID int, t model.Template) error {
+	defer metrics.ObserveQuery("template", "Update", time.Now())
+	query := `UPDATE templates SET header=$1, body=$2, color=$3, tags=$4
+			  FROM users_templates ut WHERE templates.id = ut.templates_id AND
+			  ut.templates_id = $5 AND ut.users_id = $6`
+
+	_, err := r.db.ExecContext(ctx, query, t.Header, t.Body, t.Color, pq.Array(t.Tags), t.ID, userID)
+
+	return err
+}
+
+func (r *TemplatePostgres) Delete(ctx context.Context, userID, templateID int) error {
+	defer metrics.ObserveQuery("template", "Delete", time.Now())
+	query := `DELETE FROM templates t USING users_templates ut WHERE
+			  t.id = ut.templates_id AND ut.users_id = $1 AND ut.templates_id = $2`
+
+	_, err := r.db.ExecContext(ctx, query, userID, templateID)
+
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTemplate(row rowScanner) (model.Template, error) {
+	var (
+		t    model.Template
+		body sql.NullString
+		tags pq.StringArray
+	)
+
+	err := row.Scan(&t.ID, &t.Header, &body, &t.Color, &tags)
+	t.Body = body.String
+	t.Tags = tags
+	if t.Tags == nil {
+		t.Tags = make([]string, 0)
+	}
+
+	return t, err
+}
@@ -0,0 +1,73 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"neatly/internal/model"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
+)
+
+type TokenPostgres struct {
+	db     *sqlx.DB
+	tx     *sqlx.Tx
+	logger logging.Logger
+}
+
+func NewTokenPostgres(client *dbclient.Client, logger logging.Logger) *TokenPostgres {
+	return &TokenPostgres{db: client.DB, logger: logger}
+}
+
+func (r *TokenPostgres) ex() executor {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+func (r *TokenPostgres) Create(ctx context.Context, t *model.AccountToken) error {
+	defer metrics.ObserveQuery("token", "Create", time.Now())
+	query := `INSERT INTO account_tokens (users_id, kind, token_hash, expires_at)
+			  VALUES ($1, $2, $3, $4) RETURNING id`
+
+	row := r.ex().QueryRowContext(ctx, query, t.UserID, t.Kind, t.Hash, t.ExpiresAt)
+	if err := row.Scan(&t.ID); err != nil {
+		r.logger.WithContext(ctx).Error(err)
+		return e.InternalDBError
+	}
+	return nil
+}
+
+// Consume marks token as used and returns it. Expired, used or unknown
+// tokens can not be consumed.
+func (r *TokenPostgres) Consume(ctx context.Context, kind model.TokenKind, hash string) (model.AccountToken, error) {
+	defer metrics.ObserveQuery("token", "Consume", time.Now())
+	var t model.AccountToken
+
+	query := `UPDATE account_tokens SET used_at = now()
+			  WHERE kind = $1 AND token_hash = $2 AND used_at IS NULL AND expires_at > now()
+			  RETURNING id, users_id, kind, token_hash, expires_at, used_at`
+
+	err := r.ex().GetContext(ctx, &t, query, kind, hash)
+	if err != nil {
+		r.logger.WithContext(ctx).Infof("Internal error: %v", err.Error())
+		if err == sql.ErrNoRows {
+			return t, e.ClientTokenError
+		}
+		return t, err
+	}
+	return t, nil
+}
+
+// Revoke removes every unused token of given kind issued to user
+func (r *TokenPostgres) Revoke(ctx context.Context, userID int, kind model.TokenKind) error {
+	defer metrics.ObserveQuery("token", "Revoke", time.Now())
+	query := `DELETE FROM account_tokens WHERE users_id = $1 AND kind = $2 AND used_at IS NULL`
+
+	_, err := r.ex().ExecContext(ctx, query, userID, kind)
+	return err
+}
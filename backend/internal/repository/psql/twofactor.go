@@ -0,0 +1,123 @@
+package psql
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"time"
+)
+
+type TwoFactorPostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewTwoFactorPostgres(client *dbclient.Client, logger logging.Logger) *TwoFactorPostgres {
+	return &TwoFactorPostgres{db: client.DB, logger: logger}
+}
+
+// SetSecret stores secret of pending enrolment, secret of enabled 2FA can
+// not be replaced
+func (r *TwoFactorPostgres) SetSecret(ctx context.Context, userID int, secret string) error {
+	defer metrics.ObserveQuery("twofactor", "SetSecret", time.Now())
+	query := `UPDATE users SET totp_secret=$1, totp_last_step=0 WHERE id=$2 AND NOT totp_enabled`
+
+	res, err := r.db.ExecContext(ctx, query, secret, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return e.ClientTwoFactorEnabledError
+	}
+	return nil
+}
+
+// Enable turns 2FA on and replaces recovery codes of user
+func (r *TwoFactorPostgres) Enable(ctx context.Context, userID int, codeHashes []string) error {
+	defer metrics.ObserveQuery("twofactor", "Enable", time.Now())
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET totp_enabled=TRUE WHERE id=$1`, userID); err != nil {
+		tx.Rollback()
+		r.logger.WithContext(ctx).Info(err)
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE users_id=$1`, userID); err != nil {
+		tx.Rollback()
+		r.logger.WithContext(ctx).Info(err)
+		return err
+	}
+	for _, hash := range codeHashes {
+		_, err := tx.ExecContext(ctx, `INSERT INTO recovery_codes (users_id, code_hash) VALUES ($1, $2)`, userID, hash)
+		if err != nil {
+			tx.Rollback()
+			r.logger.WithContext(ctx).Info(err)
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *TwoFactorPostgres) Disable(ctx context.Context, userID int) error {
+	defer metrics.ObserveQuery("twofactor", "Disable", time.Now())
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE users SET totp_secret='', totp_enabled=FALSE, totp_last_step=0 WHERE id=$1`
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		tx.Rollback()
+		r.logger.WithContext(ctx).Info(err)
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE users_id=$1`, userID); err != nil {
+		tx.Rollback()
+		r.logger.WithContext(ctx).Info(err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseStep remembers TOTP period which code was accepted for. Code of the
+// same or earlier period can not be used again.
+func (r *TwoFactorPostgres) UseStep(ctx context.Context, userID int, step int64) error {
+	defer metrics.ObserveQuery("twofactor", "UseStep", time.Now())
+	query := `UPDATE users SET totp_last_step=$1 WHERE id=$2 AND totp_last_step < $1`
+
+	res, err := r.db.ExecContext(ctx, query, step, userID)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return e.ClientTwoFactorCodeError
+	}
+	return nil
+}
+
+func (r *TwoFactorPostgres) UseRecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	defer metrics.ObserveQuery("twofactor", "UseRecoveryCode", time.Now())
+	query := `UPDATE recovery_codes SET used_at=now()
+			  WHERE id = (SELECT id FROM recovery_codes
+			  WHERE users_id=$1 AND code_hash=$2 AND used_at IS NULL LIMIT 1)`
+
+	res, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		r.logger.WithContext(ctx).Info(err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return e.ClientTwoFactorCodeError
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jmoiron/sqlx"
+	"neatly/pkg/dbclient"
+	"neatly/pkg/logging"
+)
+
+// executor is implemented by both *sqlx.DB and *sqlx.Tx, so the same queries
+// can run standalone or as a part of an outer transaction
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// txScope is a transaction which is either owned by repository method or
+// borrowed from outer transaction. Borrowed transaction is committed or
+// rolled back by its owner only.
+type txScope struct {
+	*sqlx.Tx
+	owned bool
+}
+
+func beginScope(ctx context.Context, db *sqlx.DB, outer *sqlx.Tx) (*txScope, error) {
+	if outer != nil {
+		return &txScope{Tx: outer, owned: false}, nil
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &txScope{Tx: tx, owned: true}, nil
+}
+
+func (s *txScope) Commit() error {
+	if !s.owned {
+		return nil
+	}
+	return s.Tx.Commit()
+}
+
+func (s *txScope) Rollback() error {
+	if !s.owned {
+		return nil
+	}
+	return s.Tx.Rollback()
+}
+
+type TransactorPostgres struct {
+	db     *sqlx.DB
+	logger logging.Logger
+}
+
+func NewTransactorPostgres(client *dbclient.Client, logger logging.Logger) *TransactorPostgres {
+	return &TransactorPostgres{db: client.DB, logger: logger}
+}
+
+// WithinTransaction runs fn with note and tag repositories bound to one
+// transaction, which is committed only if fn succeeds
+func (r *TransactorPostgres) WithinTransaction(ctx context.Context, fn func(notes *NotePostgres, tags *TagPostgres) error) error {
+	return r.run(ctx, func(tx *sqlx.Tx) error {
+		return fn(&NotePostgres{db: r.db, tx: tx, logger: r.logger}, &TagPostgres{db: r.db, tx: tx, logger: r.logger})
+	})
+}
+
+// WithinAccountTransaction runs fn with account and token repositories
+// bound to one transaction, which is committed only if fn succeeds
+func (r *TransactorPostgres) WithinAccountTransaction(ctx context.Context, fn func(accounts *AccountPostgres, tokens *TokenPostgres) error) error {
+	return r.run(ctx, func(tx *sqlx.Tx) error {
+		return fn(&AccountPostgres{db: r.db, tx: tx, logger: r.logger}, &TokenPostgres{db: r.db, tx: tx, logger: r.logger})
+	})
+}
+
+func (r *TransactorPostgres) run(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			r.logger.WithContext(ctx).Error(rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
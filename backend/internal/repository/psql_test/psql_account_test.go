@@ -4,6 +4,7 @@
 package psql_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"neatly/internal/model"
@@ -54,7 +55,7 @@ func TestAccountPostgres_CreateAccount(t *testing.T) {
 				}
 			}
 			logger.Info(testSuite.inAccount)
-			err = repo.CreateAccount(&testSuite.inAccount)
+			err = repo.CreateAccount(context.Background(), &testSuite.inAccount)
 			logger.Info(err)
 
 			assert.Equal(t, testSuite.expectedError, err)
@@ -112,7 +113,7 @@ func TestAccountPostgres_AuthorizeAccount(t *testing.T) {
 				}
 			}
 			logger.Info(testSuite.inAccount)
-			err = repo.AuthorizeAccount(&testSuite.inAccount)
+			err = repo.AuthorizeAccount(context.Background(), &testSuite.inAccount)
 
 			assert.Equal(t, testSuite.expectedError, err)
 
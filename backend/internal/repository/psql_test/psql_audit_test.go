@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package psql_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/internal/repository/psql"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"testing"
+	"time"
+)
+
+func TestAuditPostgres_AppendOnly(t *testing.T) {
+	testAccount := mother.AccountMother()
+
+	client, err := testutils.Setup("../../../etc/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logging.Init()
+	repo := psql.NewAuditPostgres(client, logging.GetLogger())
+
+	_, err = client.DB.Exec(fmt.Sprintf(testutils.NewAccountQuery, testAccount.Name, testAccount.Username, testAccount.Email, testAccount.PasswordHash))
+	if err != nil {
+		t.Fatalf("sql.Exec: Error: %s\n", err)
+	}
+
+	entry := model.NewAuditEntry(1, model.AuditNoteUpdated, model.AuditTarget("note", 1))
+	entry.Before = model.AuditSummary{"header": "before"}
+	entry.After = model.AuditSummary{"header": "after"}
+	entry.CreatedAt = time.Now()
+	assert.Nil(t, repo.Create(context.Background(), entry))
+
+	entries, err := repo.GetByUser(context.Background(), 1, model.AuditPage{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "after", entries[0].After["header"])
+
+	_, err = client.DB.Exec(`UPDATE audit_log SET action = 'note.deleted'`)
+	assert.NotNil(t, err)
+	_, err = client.DB.Exec(`UPDATE audit_log SET users_id = NULL`)
+	assert.NotNil(t, err)
+	_, err = client.DB.Exec(`DELETE FROM audit_log`)
+	assert.NotNil(t, err)
+	_, err = client.DB.Exec(`TRUNCATE audit_log`)
+	assert.NotNil(t, err)
+
+	// entries outlive account, they are only unlinked from it
+	_, err = client.DB.Exec(`DELETE FROM users WHERE id = 1`)
+	assert.Nil(t, err)
+	entries, err = repo.GetAll(context.Background(), model.AuditPage{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Nil(t, entries[0].UserID)
+	assert.Equal(t, model.AuditNoteUpdated, entries[0].Action)
+
+	err = testutils.Cleanup(client, "../../../etc/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,6 +4,7 @@
 package psql_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"neatly/internal/model"
@@ -50,7 +51,7 @@ func TestNotePostgres_Create(t *testing.T) {
 					t.Fatalf("sql.Exec: Error: %s\n", err)
 				}
 			}
-			err = repo.Create(testSuite.inID, &testSuite.inNote)
+			err = repo.Create(context.Background(), testSuite.inID, &testSuite.inNote)
 			assert.Equal(t, testSuite.expectedError, err)
 
 			err = testutils.Cleanup(client, "../../../etc/migrations")
@@ -97,7 +98,7 @@ func TestNotePostgres_GetAll(t *testing.T) {
 					t.Fatalf("sql.Exec: Error: %s\n", err)
 				}
 			}
-			_, err = repo.GetAll(testSuite.inID)
+			_, err = repo.GetAll(context.Background(), testSuite.inID)
 			assert.Equal(t, testSuite.expectedError, err)
 
 			err = testutils.Cleanup(client, "../../../etc/migrations")
@@ -155,9 +156,9 @@ func TestNotePostgres_GetOne(t *testing.T) {
 				}
 			}
 			if testSuite.noteShouldBeCreated {
-				err = repo.Create(1, &testNote)
+				err = repo.Create(context.Background(), 1, &testNote)
 			}
-			_, err = repo.GetOne(testSuite.inID, testNote.ID)
+			_, err = repo.GetOne(context.Background(), testSuite.inID, testNote.ID)
 
 			assert.Equal(t, testSuite.expectedError, err)
 
@@ -206,9 +207,9 @@ func TestNotePostgres_Delete(t *testing.T) {
 					t.Fatalf("sql.Exec: Error: %s\n", err)
 				}
 			}
-			err = repo.Create(1, &testNote)
+			err = repo.Create(context.Background(), 1, &testNote)
 
-			err = repo.Delete(testSuite.inID, testNote.ID)
+			err = repo.Delete(context.Background(), testSuite.inID, testNote.ID)
 			assert.Equal(t, testSuite.expectedError, err)
 
 			err = testutils.Cleanup(client, "../../../etc/migrations")
@@ -260,9 +261,9 @@ func TestNotePostgres_Update(t *testing.T) {
 				}
 			}
 			if testSuite.noteShouldBeCreated {
-				err = repo.Create(1, &testNote)
+				err = repo.Create(context.Background(), 1, &testNote)
 			}
-			err = repo.Update(testSuite.inID, testSuite.inNote)
+			err = repo.Update(context.Background(), testSuite.inID, testSuite.inNote)
 			assert.Equal(t, testSuite.expectedError, err)
 
 			err = testutils.Cleanup(client, "../../../etc/migrations")
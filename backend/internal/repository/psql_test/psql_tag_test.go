@@ -4,6 +4,7 @@
 package psql_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"neatly/internal/model"
@@ -53,12 +54,12 @@ func TestTagPostgres_Create(t *testing.T) {
 					t.Fatalf("sql.Exec: Error: %s\n", err)
 				}
 			}
-			err = noteRepo.Create(1, &testNote)
+			err = noteRepo.Create(context.Background(), 1, &testNote)
 			if err != nil {
 				t.Fatalf("Error: %s\n", err)
 			}
 
-			err = repo.Create(1, testNote.ID, &testTag)
+			err = repo.Create(context.Background(), 1, testNote.ID, &testTag)
 			logger.Info(err)
 
 			assert.Equal(t, testSuite.expectedError, err)
@@ -115,14 +116,14 @@ func TestTagPostgres_Assign(t *testing.T) {
 					t.Fatalf("sql.Exec: Error: %s\n", err)
 				}
 			}
-			err = noteRepo.Create(1, &testNote)
+			err = noteRepo.Create(context.Background(), 1, &testNote)
 			if err != nil {
 				t.Fatalf("Error: %s\n", err)
 			}
 
-			err = repo.Create(1, testNote.ID, &testTag)
+			err = repo.Create(context.Background(), 1, testNote.ID, &testTag)
 
-			err = repo.Assign(testTag.ID, testNote.ID, 1)
+			err = repo.Assign(context.Background(), testTag.ID, testNote.ID, 1)
 
 			logger.Info(err)
 
@@ -179,14 +180,14 @@ func TestTagPostgres_GetAll(t *testing.T) {
 					t.Fatalf("sql.Exec: Error: %s\n", err)
 				}
 			}
-			err = noteRepo.Create(1, &testNote)
+			err = noteRepo.Create(context.Background(), 1, &testNote)
 			if err != nil {
 				t.Fatalf("Error: %s\n", err)
 			}
 
-			err = repo.Create(1, testNote.ID, &testTag)
+			err = repo.Create(context.Background(), 1, testNote.ID, &testTag)
 
-			_, err = repo.GetAll(1)
+			_, err = repo.GetAll(context.Background(), 1)
 
 			logger.Info(err)
 
@@ -243,14 +244,14 @@ func TestTagPostgres_GetAllByNote(t *testing.T) {
 					t.Fatalf("sql.Exec: Error: %s\n", err)
 				}
 			}
-			err = noteRepo.Create(1, &testNote)
+			err = noteRepo.Create(context.Background(), 1, &testNote)
 			if err != nil {
 				t.Fatalf("Error: %s\n", err)
 			}
 
-			err = repo.Create(1, testNote.ID, &testTag)
+			err = repo.Create(context.Background(), 1, testNote.ID, &testTag)
 
-			_, err = repo.GetAllByNote(1, testTag.ID)
+			_, err = repo.GetAllByNote(context.Background(), 1, testTag.ID)
 
 			logger.Info(err)
 
@@ -310,18 +311,18 @@ func TestTagPostgres_GetOne(t *testing.T) {
 				}
 			}
 			if testSuite.noteShouldBeCreated {
-				err = noteRepo.Create(1, &testNote)
+				err = noteRepo.Create(context.Background(), 1, &testNote)
 				if err != nil {
 					t.Fatalf("Error: %s\n", err)
 				}
 			}
 
-			err = repo.Create(1, testNote.ID, &testTag)
+			err = repo.Create(context.Background(), 1, testNote.ID, &testTag)
 			if testSuite.noteShouldBeCreated {
-				err = repo.Assign(testTag.ID, testNote.ID, 1)
+				err = repo.Assign(context.Background(), testTag.ID, testNote.ID, 1)
 			}
 
-			_, err = repo.GetOne(1, testTag.ID)
+			_, err = repo.GetOne(context.Background(), 1, testTag.ID)
 
 			logger.Info(err)
 
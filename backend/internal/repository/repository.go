@@ -1,17 +1,29 @@
 package repository
 
 import (
+	"context"
 	"neatly/internal/model"
 	"neatly/internal/repository/psql"
 	"neatly/pkg/dbclient"
 	"neatly/pkg/logging"
+	"neatly/pkg/ratelimit"
+	"time"
 )
 
 //go:generate mockgen -destination=mock/$GOFILE -package=mock -source=$GOFILE
 
 type AccountRepository interface {
-	CreateAccount(a *model.Account) error
-	AuthorizeAccount(a *model.Account) error
+	CreateAccount(ctx context.Context, a *model.Account) error
+	AuthorizeAccount(ctx context.Context, a *model.Account) error
+	GetOne(ctx context.Context, userID int) (model.Account, error)
+	GetByEmail(ctx context.Context, email string) ([]model.Account, error)
+	SetVerified(ctx context.Context, userID int, verified bool) error
+	Update(ctx context.Context, a model.Account) error
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) (int, error)
+	RehashPassword(ctx context.Context, userID int, passwordHash string) error
+	ScheduleDeletion(ctx context.Context, userID int, deleteAfter time.Time) error
+	CancelDeletion(ctx context.Context, userID int) error
+	PurgeDeleted(ctx context.Context, now time.Time) (int, error)
 }
 
 type AccountRepositoryImpl struct {
@@ -25,11 +37,14 @@ func NewAccountRepositoryImpl(client *dbclient.Client, logger logging.Logger) *A
 }
 
 type NoteRepository interface {
-	Create(userID int, note *model.Note) error
-	GetAll(userID int) ([]model.Note, error)
-	GetOne(userID, noteID int) (model.Note, error)
-	Delete(userID, noteID int) error
-	Update(userID int, n model.Note) error
+	Create(ctx context.Context, userID int, note *model.Note) error
+	GetAll(ctx context.Context, userID int) ([]model.Note, error)
+	GetOne(ctx context.Context, userID, noteID int) (model.Note, error)
+	Delete(ctx context.Context, userID, noteID int) error
+	Update(ctx context.Context, userID int, n model.Note) error
+	UpdateState(ctx context.Context, userID int, n model.Note) error
+	ToggleState(ctx context.Context, userID, noteID int, state model.NoteState) (model.Note, error)
+	Move(ctx context.Context, userID, noteID int, notebook string) error
 }
 
 type NoteRepositoryImpl struct {
@@ -41,14 +56,14 @@ func NewNoteRepositoryImpl(client *dbclient.Client, logger logging.Logger) *Note
 }
 
 type TagRepository interface {
-	Create(userID int, noteID int, t *model.Tag) error
-	GetAll(userID int) ([]model.Tag, error)
-	GetAllByNote(userID, noteID int) ([]model.Tag, error)
-	GetOne(userID, tagID int) (model.Tag, error)
-	Delete(userID, tagID int) error
-	Detach(userID, tagID, noteID int) error
-	Assign(tagID, noteID, userID int) error
-	Update(userID, tagID int, t model.Tag) error
+	Create(ctx context.Context, userID int, noteID int, t *model.Tag) error
+	GetAll(ctx context.Context, userID int) ([]model.Tag, error)
+	GetAllByNote(ctx context.Context, userID, noteID int) ([]model.Tag, error)
+	GetOne(ctx context.Context, userID, tagID int) (model.Tag, error)
+	Delete(ctx context.Context, userID, tagID int) error
+	Detach(ctx context.Context, userID, tagID, noteID int) error
+	Assign(ctx context.Context, tagID, noteID, userID int) error
+	Update(ctx context.Context, userID, tagID int, t model.Tag) error
 }
 
 type TagRepositoryImpl struct {
@@ -60,3 +75,220 @@ func NewTagRepositoryImpl(client *dbclient.Client, logger logging.Logger) *TagRe
 		TagRepository: psql.NewTagPostgres(client, logger),
 	}
 }
+
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(notes NoteRepository, tags TagRepository) error) error
+	WithinAccountTransaction(ctx context.Context, fn func(accounts AccountRepository, tokens TokenRepository) error) error
+}
+
+type TransactorImpl struct {
+	Transactor
+}
+
+func NewTransactorImpl(client *dbclient.Client, logger logging.Logger) *TransactorImpl {
+	return &TransactorImpl{
+		Transactor: transactorPostgres{psql.NewTransactorPostgres(client, logger)},
+	}
+}
+
+// transactorPostgres exposes postgres repositories bound to transaction
+// through repository interfaces
+type transactorPostgres struct {
+	*psql.TransactorPostgres
+}
+
+func (t transactorPostgres) WithinTransaction(ctx context.Context, fn func(notes NoteRepository, tags TagRepository) error) error {
+	return t.TransactorPostgres.WithinTransaction(ctx, func(notes *psql.NotePostgres, tags *psql.TagPostgres) error {
+		return fn(notes, tags)
+	})
+}
+
+func (t transactorPostgres) WithinAccountTransaction(ctx context.Context, fn func(accounts AccountRepository, tokens TokenRepository) error) error {
+	return t.TransactorPostgres.WithinAccountTransaction(ctx, func(accounts *psql.AccountPostgres, tokens *psql.TokenPostgres) error {
+		return fn(accounts, tokens)
+	})
+}
+
+type TemplateRepository interface {
+	Create(ctx context.Context, userID int, t *model.Template) error
+	GetAll(ctx context.Context, userID int) ([]model.Template, error)
+	GetOne(ctx context.Context, userID, templateID int) (model.Template, error)
+	Update(ctx context.Context, userID int, t model.Template) error
+	Delete(ctx context.Context, userID, templateID int) error
+}
+
+type TemplateRepositoryImpl struct {
+	TemplateRepository
+}
+
+func NewTemplateRepositoryImpl(client *dbclient.Client, logger logging.Logger) *TemplateRepositoryImpl {
+	return &TemplateRepositoryImpl{
+		TemplateRepository: psql.NewTemplatePostgres(client, logger),
+	}
+}
+
+type StatsRepository interface {
+	GetTotals(ctx context.Context, userID int) (model.NoteTotals, error)
+	GetEditedPerDay(ctx context.Context, userID, days int) ([]model.DayActivity, error)
+	GetTopTags(ctx context.Context, userID, limit int) ([]model.TagUsage, error)
+	GetColors(ctx context.Context, userID int) ([]model.ColorUsage, error)
+	GetLastEdited(ctx context.Context, userID, limit int) ([]model.Note, error)
+}
+
+type StatsRepositoryImpl struct {
+	StatsRepository
+}
+
+func NewStatsRepositoryImpl(client *dbclient.Client, logger logging.Logger) *StatsRepositoryImpl {
+	return &StatsRepositoryImpl{
+		StatsRepository: psql.NewStatsPostgres(client, logger),
+	}
+}
+
+type TokenRepository interface {
+	Create(ctx context.Context, t *model.AccountToken) error
+	Consume(ctx context.Context, kind model.TokenKind, hash string) (model.AccountToken, error)
+	Revoke(ctx context.Context, userID int, kind model.TokenKind) error
+}
+
+type TokenRepositoryImpl struct {
+	TokenRepository
+}
+
+func NewTokenRepositoryImpl(client *dbclient.Client, logger logging.Logger) *TokenRepositoryImpl {
+	return &TokenRepositoryImpl{
+		TokenRepository: psql.NewTokenPostgres(client, logger),
+	}
+}
+
+type ExportRepository interface {
+	Export(ctx context.Context, userID int) (model.Export, error)
+}
+
+type ExportRepositoryImpl struct {
+	ExportRepository
+}
+
+func NewExportRepositoryImpl(client *dbclient.Client, logger logging.Logger) *ExportRepositoryImpl {
+	return &ExportRepositoryImpl{
+		ExportRepository: psql.NewExportPostgres(client, logger),
+	}
+}
+
+type TwoFactorRepository interface {
+	SetSecret(ctx context.Context, userID int, secret string) error
+	Enable(ctx context.Context, userID int, codeHashes []string) error
+	Disable(ctx context.Context, userID int) error
+	UseStep(ctx context.Context, userID int, step int64) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) error
+}
+
+type TwoFactorRepositoryImpl struct {
+	TwoFactorRepository
+}
+
+func NewTwoFactorRepositoryImpl(client *dbclient.Client, logger logging.Logger) *TwoFactorRepositoryImpl {
+	return &TwoFactorRepositoryImpl{
+		TwoFactorRepository: psql.NewTwoFactorPostgres(client, logger),
+	}
+}
+
+type LockoutRepository interface {
+	LockedUntil(ctx context.Context, keys []string, now time.Time) (time.Time, error)
+	RegisterFailure(ctx context.Context, key string, now time.Time, window time.Duration) (model.LoginAttempt, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+type LockoutRepositoryImpl struct {
+	LockoutRepository
+}
+
+func NewLockoutRepositoryImpl(client *dbclient.Client, logger logging.Logger) *LockoutRepositoryImpl {
+	return &LockoutRepositoryImpl{
+		LockoutRepository: psql.NewLockoutPostgres(client, logger),
+	}
+}
+
+type RateLimitRepository interface {
+	Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error)
+	PurgeIdle(ctx context.Context, before time.Time) (int, error)
+}
+
+type RateLimitRepositoryImpl struct {
+	RateLimitRepository
+}
+
+func NewRateLimitRepositoryImpl(client *dbclient.Client, logger logging.Logger) *RateLimitRepositoryImpl {
+	return &RateLimitRepositoryImpl{
+		RateLimitRepository: psql.NewRateLimitPostgres(client, logger),
+	}
+}
+
+type OIDCRepository interface {
+	CreateFlow(ctx context.Context, f model.OIDCFlow) error
+	ConsumeFlow(ctx context.Context, state string) (model.OIDCFlow, error)
+	FindUser(ctx context.Context, issuer, subject string) (int, error)
+	Link(ctx context.Context, userID int, issuer, subject string) error
+}
+
+type OIDCRepositoryImpl struct {
+	OIDCRepository
+}
+
+func NewOIDCRepositoryImpl(client *dbclient.Client, logger logging.Logger) *OIDCRepositoryImpl {
+	return &OIDCRepositoryImpl{
+		OIDCRepository: psql.NewOIDCPostgres(client, logger),
+	}
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, s model.Session) error
+	GetAll(ctx context.Context, userID int, now time.Time) ([]model.Session, error)
+	Touch(ctx context.Context, userID int, sessionID string, now time.Time) (bool, error)
+	Delete(ctx context.Context, userID int, sessionID string) error
+	DeleteExpired(ctx context.Context, userID int, now time.Time) error
+}
+
+type SessionRepositoryImpl struct {
+	SessionRepository
+}
+
+func NewSessionRepositoryImpl(client *dbclient.Client, logger logging.Logger) *SessionRepositoryImpl {
+	return &SessionRepositoryImpl{
+		SessionRepository: psql.NewSessionPostgres(client, logger),
+	}
+}
+
+type AdminRepository interface {
+	Search(ctx context.Context, s model.AdminSearch) ([]model.AdminAccount, error)
+	GetOne(ctx context.Context, userID int) (model.AdminAccount, error)
+	SetDisabled(ctx context.Context, userID int, disabled bool) error
+	GrantAdmin(ctx context.Context, usernames []string) ([]int, error)
+}
+
+type AdminRepositoryImpl struct {
+	AdminRepository
+}
+
+func NewAdminRepositoryImpl(client *dbclient.Client, logger logging.Logger) *AdminRepositoryImpl {
+	return &AdminRepositoryImpl{
+		AdminRepository: psql.NewAdminPostgres(client, logger),
+	}
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, a model.AuditEntry) error
+	GetByUser(ctx context.Context, userID int, p model.AuditPage) ([]model.AuditEntry, error)
+	GetAll(ctx context.Context, p model.AuditPage) ([]model.AuditEntry, error)
+}
+
+type AuditRepositoryImpl struct {
+	AuditRepository
+}
+
+func NewAuditRepositoryImpl(client *dbclient.Client, logger logging.Logger) *AuditRepositoryImpl {
+	return &AuditRepositoryImpl{
+		AuditRepository: psql.NewAuditPostgres(client, logger),
+	}
+}
@@ -1,18 +1,23 @@
 package account
 
 import (
+	"context"
 	"errors"
 	"github.com/go-playground/assert/v2"
 	"github.com/golang/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 	"neatly/internal/model"
 	"neatly/internal/model/mother"
 	"neatly/internal/repository"
 	"neatly/internal/repository/mock"
 	"neatly/pkg/e"
+	"neatly/pkg/jwt"
 	"neatly/pkg/logging"
+	"neatly/pkg/password"
 	"neatly/pkg/testutils"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestService_CreateAccount(t *testing.T) {
@@ -29,7 +34,7 @@ func TestService_CreateAccount(t *testing.T) {
 			testName:  "ValidAccountRegistration",
 			inAccount: mother.AccountMother(),
 			CreateAccountBehaviour: func(r *mock.MockAccountRepository, a *model.Account) {
-				r.EXPECT().CreateAccount(a).Return(nil)
+				r.EXPECT().CreateAccount(gomock.Any(), a).Return(nil)
 			},
 			outAccount:    mother.AccountMother(),
 			ExpectedError: nil,
@@ -38,7 +43,7 @@ func TestService_CreateAccount(t *testing.T) {
 			testName:  "UserAlreadyExists",
 			inAccount: mother.AccountMother(),
 			CreateAccountBehaviour: func(r *mock.MockAccountRepository, a *model.Account) {
-				r.EXPECT().CreateAccount(a).Return(e.ClientAccountError)
+				r.EXPECT().CreateAccount(gomock.Any(), a).Return(e.ClientAccountError)
 			},
 			outAccount:    mother.AccountMother(),
 			ExpectedError: e.ClientAccountError,
@@ -57,9 +62,9 @@ func TestService_CreateAccount(t *testing.T) {
 			repo := &repository.AccountRepositoryImpl{
 				AccountRepository: repoMock,
 			}
-			mockService := NewService(repo, logging.GetLogger())
+			mockService := NewService(repo, testutils.SessionStarter{}, &testutils.Auditor{}, logging.GetLogger())
 
-			err := mockService.CreateAccount(&testSuite.inAccount)
+			err := mockService.CreateAccount(context.Background(), &testSuite.inAccount)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 		})
@@ -73,10 +78,19 @@ func TestService_CreateAccount(t *testing.T) {
 func TestService_GenerateJWT(t *testing.T) {
 	type RepoMockBehaviour func(r *mock.MockAccountRepository, a *model.Account)
 	testAccount := mother.AccountMother()
+	testAccount.ID = 1
 	testAccountInvalidPassword := testAccount
 	testAccountInvalidPassword.Password = "kto prochital tot loh"
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte(testAccount.Password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testAccountDisabled := testAccount
+	testAccountDisabled.Disabled = true
+	testAccountLegacyHash := testAccount
+	testAccountLegacyHash.PasswordHash = string(legacyHash)
 
-	err := os.Setenv("CONF_FILE", "../etc/test.yml")
+	err = os.Setenv("CONF_FILE", "../etc/test.yml")
 	if err != nil {
 		t.Fatalf("Can't set config path: %s", err)
 	}
@@ -87,27 +101,55 @@ func TestService_GenerateJWT(t *testing.T) {
 		AuthorizeAccountBehaviour RepoMockBehaviour
 		outAccount                model.Account
 		ExpectedError             error
-		ExpectedTokenVal          string
+		expectedNoToken           bool
+		ExpectedAudit             model.AuditAction
 	}{
 		{
 			testName:  "AuthorizeSuccessful",
 			inAccount: testAccount,
 			AuthorizeAccountBehaviour: func(r *mock.MockAccountRepository, a *model.Account) {
-				r.EXPECT().AuthorizeAccount(a).Return(nil)
+				r.EXPECT().AuthorizeAccount(gomock.Any(), a).Return(nil)
 			},
-			outAccount:       testAccount,
-			ExpectedError:    nil,
-			ExpectedTokenVal: mother.TokenMother(),
+			outAccount:    testAccount,
+			ExpectedError: nil,
+			ExpectedAudit: model.AuditLoginSucceeded,
 		},
 		{
 			testName:  "PasswordDoesNotMatch",
 			inAccount: testAccountInvalidPassword,
 			AuthorizeAccountBehaviour: func(r *mock.MockAccountRepository, a *model.Account) {
-				r.EXPECT().AuthorizeAccount(a).Return(nil)
+				r.EXPECT().AuthorizeAccount(gomock.Any(), a).Return(nil)
+			},
+			outAccount:      testAccount,
+			ExpectedError:   errors.New("password does not match"),
+			expectedNoToken: true,
+			ExpectedAudit:   model.AuditLoginFailed,
+		},
+		{
+			testName:  "AccountDisabled",
+			inAccount: testAccountDisabled,
+			AuthorizeAccountBehaviour: func(r *mock.MockAccountRepository, a *model.Account) {
+				r.EXPECT().AuthorizeAccount(gomock.Any(), a).Return(nil)
+			},
+			outAccount:      testAccount,
+			ExpectedError:   e.AccountDisabledError,
+			expectedNoToken: true,
+			ExpectedAudit:   model.AuditLoginFailed,
+		},
+		{
+			testName:  "LegacyHashUpgraded",
+			inAccount: testAccountLegacyHash,
+			AuthorizeAccountBehaviour: func(r *mock.MockAccountRepository, a *model.Account) {
+				r.EXPECT().AuthorizeAccount(gomock.Any(), a).Return(nil)
+				r.EXPECT().RehashPassword(gomock.Any(), a.ID, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, hash string) error {
+					assert.Equal(t, nil, password.Compare(hash, a.Password))
+					assert.Equal(t, false, password.NeedsRehash(hash))
+					return nil
+				})
 			},
-			outAccount:       testAccount,
-			ExpectedError:    errors.New("password does not match"),
-			ExpectedTokenVal: "",
+			outAccount:    testAccount,
+			ExpectedError: nil,
+			ExpectedAudit: model.AuditLoginSucceeded,
 		},
 	}
 	for _, testSuite := range testSuites {
@@ -123,12 +165,294 @@ func TestService_GenerateJWT(t *testing.T) {
 			repo := &repository.AccountRepositoryImpl{
 				AccountRepository: repoMock,
 			}
-			mockService := NewService(repo, logger)
+			audit := &testutils.Auditor{}
+			mockService := NewService(repo, testutils.SessionStarter{}, audit, logger)
 
-			token, err := mockService.GenerateJWT(&testSuite.inAccount)
+			token, err := mockService.GenerateJWT(context.Background(), &testSuite.inAccount, model.Client{})
 
 			assert.Equal(t, testSuite.ExpectedError, err)
-			assert.Equal(t, testSuite.ExpectedTokenVal, token)
+			assert.Equal(t, testSuite.expectedNoToken, token == "")
+			assert.Equal(t, 1, len(audit.Entries))
+			assert.Equal(t, testSuite.ExpectedAudit, audit.Entries[0].Action)
+			assert.Equal(t, "username:"+testSuite.inAccount.Username, audit.Entries[0].Target)
+			assert.Equal(t, testSuite.inAccount.ID, *audit.Entries[0].UserID)
+			// failed attempt may be made by anyone, so it has no actor
+			assert.Equal(t, testSuite.ExpectedAudit == model.AuditLoginSucceeded, audit.Entries[0].ActorID != nil)
+		})
+	}
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_GenerateJWT_RepositoryError(t *testing.T) {
+	testAccount := mother.AccountMother()
+	dbErr := errors.New("connection refused")
+
+	err := os.Setenv("CONF_FILE", "../etc/test.yml")
+	if err != nil {
+		t.Fatalf("Can't set config path: %s", err)
+	}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repoMock := mock.NewMockAccountRepository(c)
+	repoMock.EXPECT().AuthorizeAccount(gomock.Any(), &testAccount).Return(dbErr)
+
+	logging.Init()
+	audit := &testutils.Auditor{}
+	mockService := NewService(&repository.AccountRepositoryImpl{AccountRepository: repoMock}, testutils.SessionStarter{}, audit, logging.GetLogger())
+
+	token, err := mockService.GenerateJWT(context.Background(), &testAccount, model.Client{})
+
+	// outage is not a failed login
+	assert.Equal(t, dbErr, err)
+	assert.Equal(t, "", token)
+	assert.Equal(t, 0, len(audit.Entries))
+
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_GenerateJWT_TwoFactor(t *testing.T) {
+	deleteAfter := time.Now().Add(time.Hour)
+	testAccount := mother.AccountMother()
+	testAccount.TOTPEnabled = true
+	testAccount.DeleteAfter = &deleteAfter
+
+	err := os.Setenv("CONF_FILE", "../etc/test.yml")
+	if err != nil {
+		t.Fatalf("Can't set config path: %s", err)
+	}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repoMock := mock.NewMockAccountRepository(c)
+	repoMock.EXPECT().AuthorizeAccount(gomock.Any(), &testAccount).Return(nil)
+	// deletion is cancelled only after second factor
+	repoMock.EXPECT().CancelDeletion(gomock.Any(), gomock.Any()).Times(0)
+
+	logging.Init()
+	audit := &testutils.Auditor{}
+	mockService := NewService(&repository.AccountRepositoryImpl{AccountRepository: repoMock}, testutils.SessionStarter{}, audit, logging.GetLogger())
+
+	token, err := mockService.GenerateJWT(context.Background(), &testAccount, model.Client{})
+
+	assert.Equal(t, nil, err)
+	_, err = jwt.ParseChallengeToken(token)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(audit.Entries))
+
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_GenerateJWT_PendingDeletion(t *testing.T) {
+	deleteAfter := time.Now().Add(time.Hour)
+	testAccount := mother.AccountMother()
+	testAccount.ID = 1
+	testAccount.DeleteAfter = &deleteAfter
+
+	err := os.Setenv("CONF_FILE", "../etc/test.yml")
+	if err != nil {
+		t.Fatalf("Can't set config path: %s", err)
+	}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repoMock := mock.NewMockAccountRepository(c)
+	repoMock.EXPECT().AuthorizeAccount(gomock.Any(), &testAccount).Return(nil)
+	repoMock.EXPECT().CancelDeletion(gomock.Any(), testAccount.ID).Return(nil)
+
+	logging.Init()
+	audit := &testutils.Auditor{}
+	mockService := NewService(&repository.AccountRepositoryImpl{AccountRepository: repoMock}, testutils.SessionStarter{}, audit, logging.GetLogger())
+
+	_, err = mockService.GenerateJWT(context.Background(), &testAccount, model.Client{})
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(audit.Entries))
+	assert.Equal(t, model.AuditDeletionCancelled, audit.Entries[0].Action)
+	assert.Equal(t, testAccount.ID, *audit.Entries[0].ActorID)
+	assert.Equal(t, model.AuditLoginSucceeded, audit.Entries[1].Action)
+
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	type RepoMockBehaviour func(r *mock.MockAccountRepository, a model.Account)
+
+	testAccount := mother.AccountMother()
+	testAccount.Verified = true
+
+	renamed := testAccount
+	renamed.Username = "renamed"
+
+	emailChanged := testAccount
+	emailChanged.Email = "new@example.com"
+	emailChanged.Verified = false
+
+	testSuites := []struct {
+		testName       string
+		inAccount      model.Account
+		inMask         model.AccountUpdateMask
+		UpdateBehavior RepoMockBehaviour
+		outAccount     model.Account
+		ExpectedError  error
+	}{
+		{
+			testName:  "UsernameChanged",
+			inAccount: model.Account{Username: renamed.Username},
+			inMask:    model.AccountUpdateMask{Username: true},
+			UpdateBehavior: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+				r.EXPECT().Update(gomock.Any(), renamed).Return(nil)
+			},
+			outAccount:    renamed,
+			ExpectedError: nil,
+		},
+		{
+			testName:  "EmailChangeResetsVerification",
+			inAccount: model.Account{Email: emailChanged.Email},
+			inMask:    model.AccountUpdateMask{Email: true},
+			UpdateBehavior: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+				r.EXPECT().Update(gomock.Any(), emailChanged).Return(nil)
+			},
+			outAccount:    emailChanged,
+			ExpectedError: nil,
+		},
+		{
+			testName:  "UsernameTaken",
+			inAccount: model.Account{Username: renamed.Username},
+			inMask:    model.AccountUpdateMask{Username: true},
+			UpdateBehavior: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+				r.EXPECT().Update(gomock.Any(), renamed).Return(e.ClientAccountError)
+			},
+			outAccount:    renamed,
+			ExpectedError: e.ClientAccountError,
+		},
+		{
+			testName:  "EmptyName",
+			inAccount: model.Account{},
+			inMask:    model.AccountUpdateMask{Name: true},
+			UpdateBehavior: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+				r.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
+			},
+			outAccount:    testAccount,
+			ExpectedError: e.ClientProfileError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoMock := mock.NewMockAccountRepository(c)
+			testSuite.UpdateBehavior(repoMock, testAccount)
+
+			logging.Init()
+			repo := &repository.AccountRepositoryImpl{
+				AccountRepository: repoMock,
+			}
+			mockService := NewService(repo, testutils.SessionStarter{}, &testutils.Auditor{}, logging.GetLogger())
+
+			a, err := mockService.Update(context.Background(), testAccount.ID, testSuite.inAccount, testSuite.inMask)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.outAccount, a)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_ChangePassword(t *testing.T) {
+	type RepoMockBehaviour func(r *mock.MockAccountRepository, a model.Account)
+
+	testAccount := mother.AccountMother()
+
+	err := os.Setenv("CONF_FILE", "../etc/test.yml")
+	if err != nil {
+		t.Fatalf("Can't set config path: %s", err)
+	}
+
+	testSuites := []struct {
+		testName        string
+		inCurrent       string
+		inNew           string
+		ChangeBehaviour RepoMockBehaviour
+		ExpectedError   error
+		expectedNoToken bool
+	}{
+		{
+			testName:  "PasswordChanged",
+			inCurrent: testAccount.Password,
+			inNew:     "new password",
+			ChangeBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+				r.EXPECT().UpdatePassword(gomock.Any(), a.ID, gomock.Any()).Return(a.SessionVersion+1, nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName:  "CurrentPasswordDoesNotMatch",
+			inCurrent: "wrong password",
+			inNew:     "new password",
+			ChangeBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+				r.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError:   e.ClientPasswordError,
+			expectedNoToken: true,
+		},
+		{
+			testName:  "NewPasswordTooCommon",
+			inCurrent: testAccount.Password,
+			inNew:     "password123",
+			ChangeBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+				r.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError:   e.ClientPasswordPolicyError,
+			expectedNoToken: true,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoMock := mock.NewMockAccountRepository(c)
+			testSuite.ChangeBehaviour(repoMock, testAccount)
+
+			logging.Init()
+			repo := &repository.AccountRepositoryImpl{
+				AccountRepository: repoMock,
+			}
+			mockService := NewService(repo, testutils.SessionStarter{}, &testutils.Auditor{}, logging.GetLogger())
+
+			token, err := mockService.ChangePassword(context.Background(), testAccount.ID, testSuite.inCurrent, testSuite.inNew, model.Client{})
+
+			assert.Equal(t, true, errors.Is(err, testSuite.ExpectedError))
+			assert.Equal(t, testSuite.expectedNoToken, token == "")
 		})
 	}
 	err = testutils.CleanupLogs()
@@ -1,40 +1,211 @@
 package account
 
 import (
+	"context"
+	"errors"
 	"neatly/internal/model"
 	"neatly/internal/repository"
+	"neatly/pkg/e"
 	"neatly/pkg/jwt"
 	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"neatly/pkg/password"
+	"neatly/pkg/tracing"
+	"time"
 )
 
+// SessionStarter records login of client and issues access token bound to
+// the new session
+type SessionStarter interface {
+	Start(ctx context.Context, userID, version int, client model.Client) (string, error)
+}
+
+// Auditor appends security relevant events to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
 type Service struct {
 	repository *repository.AccountRepositoryImpl
+	sessions   SessionStarter
+	audit      Auditor
 	logger     logging.Logger
 }
 
-func NewService(repository *repository.AccountRepositoryImpl, logger logging.Logger) *Service {
-	return &Service{repository: repository, logger: logger}
+func NewService(repository *repository.AccountRepositoryImpl, sessions SessionStarter, audit Auditor, logger logging.Logger) *Service {
+	return &Service{repository: repository, sessions: sessions, audit: audit, logger: logger}
 }
 
-func (s *Service) CreateAccount(a *model.Account) error {
-	err := s.repository.CreateAccount(a)
+func (s *Service) CreateAccount(ctx context.Context, a *model.Account) error {
+	ctx, span := tracing.Start(ctx, "account.CreateAccount")
+	defer span.End()
+
+	err := s.repository.CreateAccount(ctx, a)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *Service) GenerateJWT(a *model.Account) (string, error) {
-	err := s.repository.AuthorizeAccount(a)
+// GenerateJWT checks credentials and returns access token. If account has
+// two-factor authentication enabled, challenge token is returned instead
+// and has to be exchanged for access token with a code.
+func (s *Service) GenerateJWT(ctx context.Context, a *model.Account, client model.Client) (string, error) {
+	ctx, span := tracing.Start(ctx, "account.GenerateJWT")
+	defer span.End()
+
+	// unknown username fails on password check like wrong password does
+	err := s.repository.AuthorizeAccount(ctx, a)
+	if err != nil && !errors.Is(err, e.ClientAuthorizeError) {
+		return "", err
+	}
 	err = a.CheckPassword(a.Password)
 	if err != nil {
+		metrics.LoginsFailed.WithLabelValues("password").Inc()
+		s.recordLogin(ctx, a, model.AuditLoginFailed, model.AuditSummary{"reason": "password"})
 		return "", err
 	}
 
-	token, err := jwt.GenerateAccessToken(a.ID)
+	if a.Disabled {
+		s.recordLogin(ctx, a, model.AuditLoginFailed, model.AuditSummary{"reason": "disabled"})
+		return "", e.AccountDisabledError
+	}
+
+	if a.PasswordNeedsRehash() {
+		s.rehash(ctx, a)
+	}
+
+	// deletion is cancelled by two-factor service once second factor passes
+	if a.TOTPEnabled {
+		return jwt.GenerateChallengeToken(a.ID, a.SessionVersion)
+	}
+
+	if a.DeleteAfter != nil {
+		if err := s.repository.CancelDeletion(ctx, a.ID); err != nil {
+			return "", err
+		}
+		s.logger.WithContext(ctx).Infof("Deletion of account %v cancelled by login", a.ID)
+		s.recordCancelledDeletion(ctx, a.ID, *a.DeleteAfter)
+	}
+
+	token, err := s.sessions.Start(ctx, a.ID, a.SessionVersion, client)
 	if err != nil {
 		return "", err
 	}
+	s.recordLogin(ctx, a, model.AuditLoginSucceeded, model.AuditSummary{"method": "password"})
 
 	return token, nil
 }
+
+// recordLogin audits login attempt, failed attempts of unknown usernames
+// are recorded too but are visible to admins only. Failed attempt may be
+// made by anyone, so account is recorded as actor only on success.
+func (s *Service) recordLogin(ctx context.Context, a *model.Account, action model.AuditAction, summary model.AuditSummary) {
+	entry := model.AuditEntry{Action: action, Target: model.AuditTarget("username", a.Username), After: summary}
+	if a.ID != 0 {
+		entry.UserID = &a.ID
+	}
+	if a.ID != 0 && action == model.AuditLoginSucceeded {
+		entry.ActorID = &a.ID
+	}
+	s.audit.Record(ctx, entry)
+}
+
+// recordCancelledDeletion audits deletion cancelled by login, nobody is
+// authenticated yet, so account is recorded as its actor
+func (s *Service) recordCancelledDeletion(ctx context.Context, userID int, deleteAfter time.Time) {
+	entry := model.NewAuditEntry(userID, model.AuditDeletionCancelled, model.AuditTarget("user", userID))
+	entry.ActorID = &userID
+	entry.Before = model.AuditSummary{"delete_after": deleteAfter}
+	s.audit.Record(ctx, entry)
+}
+
+// rehash upgrades stored hash while plain password is known, failure is
+// not fatal for login and is retried next time
+func (s *Service) rehash(ctx context.Context, a *model.Account) {
+	phash, err := model.GeneratePasswordHash(a.Password)
+	if err == nil {
+		err = s.repository.RehashPassword(ctx, a.ID, phash)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("Can't upgrade password hash of account %v: %v", a.ID, err)
+		return
+	}
+	a.PasswordHash = phash
+	s.logger.WithContext(ctx).Infof("Password hash of account %v upgraded", a.ID)
+}
+
+func (s *Service) GetOne(ctx context.Context, userID int) (model.Account, error) {
+	ctx, span := tracing.Start(ctx, "account.GetOne")
+	defer span.End()
+
+	return s.repository.GetOne(ctx, userID)
+}
+
+// Update changes fields of account marked in mask. Changed email has to be
+// verified again.
+func (s *Service) Update(ctx context.Context, userID int, in model.Account, mask model.AccountUpdateMask) (model.Account, error) {
+	ctx, span := tracing.Start(ctx, "account.Update")
+	defer span.End()
+
+	a, err := s.repository.GetOne(ctx, userID)
+	if err != nil {
+		return a, err
+	}
+
+	if mask.Name {
+		if in.Name == "" {
+			return a, e.ClientProfileError
+		}
+		a.Name = in.Name
+	}
+	if mask.Username {
+		if in.Username == "" {
+			return a, e.ClientProfileError
+		}
+		a.Username = in.Username
+	}
+	if mask.Email && in.Email != a.Email {
+		a.Email = in.Email
+		a.Verified = false
+	}
+
+	if err := s.repository.Update(ctx, a); err != nil {
+		return a, err
+	}
+	s.logger.WithContext(ctx).Infof("Profile of account %v updated", userID)
+
+	return a, nil
+}
+
+// ChangePassword sets new password if current one matches. Tokens issued
+// before are revoked, token of new session is returned instead.
+func (s *Service) ChangePassword(ctx context.Context, userID int, current, pass string, client model.Client) (string, error) {
+	ctx, span := tracing.Start(ctx, "account.ChangePassword")
+	defer span.End()
+
+	a, err := s.repository.GetOne(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if err := a.CheckPassword(current); err != nil {
+		return "", e.ClientPasswordError
+	}
+	if err := password.Validate(pass); err != nil {
+		return "", err
+	}
+
+	phash, err := model.GeneratePasswordHash(pass)
+	if err != nil {
+		return "", err
+	}
+
+	version, err := s.repository.UpdatePassword(ctx, userID, phash)
+	if err != nil {
+		return "", err
+	}
+	s.logger.WithContext(ctx).Infof("Password of account %v changed", userID)
+	s.audit.Record(ctx, model.NewAuditEntry(userID, model.AuditPasswordChanged, model.AuditTarget("user", userID)))
+
+	return s.sessions.Start(ctx, userID, version, client)
+}
@@ -0,0 +1,263 @@
+//go:build unit
+// +build unit
+
+package admin
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"testing"
+)
+
+const testAdminID = 42
+
+// impersonatorStub records impersonated sessions instead of storing them
+type impersonatorStub struct {
+	started []int
+}
+
+func (s *impersonatorStub) Impersonate(_ context.Context, adminID, userID, _ int, _ model.Client) (string, error) {
+	s.started = append(s.started, userID)
+	return "token", nil
+}
+
+// resetterStub records accounts whose password reset was forced
+type resetterStub struct {
+	forced []int
+}
+
+func (s *resetterStub) Force(_ context.Context, userID int) error {
+	s.forced = append(s.forced, userID)
+	return nil
+}
+
+func newTestService(accounts *mock.MockAccountRepository, admins *mock.MockAdminRepository,
+	sessions *impersonatorStub, resetter *resetterStub, audit *testutils.Auditor) *Service {
+	logging.Init()
+	return NewService(
+		&repository.AccountRepositoryImpl{AccountRepository: accounts},
+		&repository.AdminRepositoryImpl{AdminRepository: admins},
+		sessions, resetter, audit, logging.GetLogger())
+}
+
+func TestService_Search(t *testing.T) {
+	testSuites := []struct {
+		testName string
+		in       model.AdminSearch
+		expected model.AdminSearch
+	}{
+		{"DefaultLimit", model.AdminSearch{Query: "test"}, model.AdminSearch{Query: "test", Limit: DefaultLimit}},
+		{"LimitCapped", model.AdminSearch{Limit: 1000, Offset: 10}, model.AdminSearch{Limit: MaxLimit, Offset: 10}},
+		{"NegativeOffset", model.AdminSearch{Limit: 10, Offset: -1}, model.AdminSearch{Limit: 10}},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			adminMock := mock.NewMockAdminRepository(c)
+			adminMock.EXPECT().Search(gomock.Any(), testSuite.expected).Return([]model.AdminAccount{}, nil)
+
+			s := newTestService(mock.NewMockAccountRepository(c), adminMock, &impersonatorStub{}, &resetterStub{}, &testutils.Auditor{})
+
+			_, err := s.Search(context.Background(), testSuite.in)
+			assert.Equal(t, nil, err)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_SetDisabled(t *testing.T) {
+	type adminRepoMockBehaviour func(r *mock.MockAdminRepository, userID int)
+
+	testSuites := []struct {
+		testName       string
+		inUserID       int
+		adminBehaviour adminRepoMockBehaviour
+		ExpectedError  error
+	}{
+		{
+			testName: "Disabled",
+			inUserID: 1,
+			adminBehaviour: func(r *mock.MockAdminRepository, userID int) {
+				r.EXPECT().GetOne(gomock.Any(), userID).Return(model.AdminAccount{ID: userID, Role: model.RoleUser}, nil)
+				r.EXPECT().SetDisabled(gomock.Any(), userID, true).Return(nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName: "UnknownUser",
+			inUserID: 2,
+			adminBehaviour: func(r *mock.MockAdminRepository, userID int) {
+				r.EXPECT().GetOne(gomock.Any(), userID).Return(model.AdminAccount{}, e.ClientUserError)
+				r.EXPECT().SetDisabled(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.ClientUserError,
+		},
+		{
+			testName: "OtherAdmin",
+			inUserID: 3,
+			adminBehaviour: func(r *mock.MockAdminRepository, userID int) {
+				r.EXPECT().GetOne(gomock.Any(), userID).Return(model.AdminAccount{ID: userID, Role: model.RoleAdmin}, nil)
+				r.EXPECT().SetDisabled(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.ClientAdminError,
+		},
+		{
+			testName: "OwnAccount",
+			inUserID: testAdminID,
+			adminBehaviour: func(r *mock.MockAdminRepository, userID int) {
+				r.EXPECT().SetDisabled(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.ClientAdminError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			adminMock := mock.NewMockAdminRepository(c)
+			testSuite.adminBehaviour(adminMock, testSuite.inUserID)
+
+			s := newTestService(mock.NewMockAccountRepository(c), adminMock, &impersonatorStub{}, &resetterStub{}, &testutils.Auditor{})
+
+			err := s.SetDisabled(context.Background(), testAdminID, testSuite.inUserID, true)
+			assert.Equal(t, testSuite.ExpectedError, err)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Impersonate(t *testing.T) {
+	user := mother.AccountMother()
+	user.ID = 1
+	user.Role = model.RoleUser
+
+	otherAdmin := user
+	otherAdmin.ID = 2
+	otherAdmin.Role = model.RoleAdmin
+
+	disabled := user
+	disabled.ID = 3
+	disabled.Disabled = true
+
+	testSuites := []struct {
+		testName        string
+		account         model.Account
+		expectedStarted int
+		ExpectedError   error
+	}{
+		{"Impersonated", user, 1, nil},
+		{"AdminCanNotBeImpersonated", otherAdmin, 0, e.ClientAdminError},
+		{"DisabledAccount", disabled, 0, e.AccountDisabledError},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			accountMock := mock.NewMockAccountRepository(c)
+			accountMock.EXPECT().GetOne(gomock.Any(), testSuite.account.ID).Return(testSuite.account, nil)
+			sessions := &impersonatorStub{}
+
+			s := newTestService(accountMock, mock.NewMockAdminRepository(c), sessions, &resetterStub{}, &testutils.Auditor{})
+
+			token, err := s.Impersonate(context.Background(), testAdminID, testSuite.account.ID, model.Client{IP: "127.0.0.1"})
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.expectedStarted, len(sessions.started))
+			assert.Equal(t, testSuite.ExpectedError == nil, token != "")
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_ForcePasswordReset(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	adminMock := mock.NewMockAdminRepository(c)
+	adminMock.EXPECT().GetOne(gomock.Any(), 1).Return(model.AdminAccount{ID: 1, Role: model.RoleUser}, nil)
+	resetter := &resetterStub{}
+	audit := &testutils.Auditor{}
+	s := newTestService(mock.NewMockAccountRepository(c), adminMock, &impersonatorStub{}, resetter, audit)
+
+	err := s.ForcePasswordReset(context.Background(), testAdminID, 1)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int{1}, resetter.forced)
+	assert.Equal(t, 1, len(audit.Entries))
+	assert.Equal(t, model.AuditPasswordResetForced, audit.Entries[0].Action)
+	assert.Equal(t, testAdminID, *audit.Entries[0].ActorID)
+	assert.Equal(t, 1, *audit.Entries[0].UserID)
+
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_ForcePasswordReset_Admin(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	adminMock := mock.NewMockAdminRepository(c)
+	adminMock.EXPECT().GetOne(gomock.Any(), 2).Return(model.AdminAccount{ID: 2, Role: model.RoleAdmin}, nil)
+	resetter := &resetterStub{}
+	audit := &testutils.Auditor{}
+	s := newTestService(mock.NewMockAccountRepository(c), adminMock, &impersonatorStub{}, resetter, audit)
+
+	assert.Equal(t, e.ClientAdminError, s.ForcePasswordReset(context.Background(), testAdminID, 2))
+	assert.Equal(t, e.ClientAdminError, s.ForcePasswordReset(context.Background(), testAdminID, testAdminID))
+	assert.Equal(t, 0, len(resetter.forced))
+	assert.Equal(t, 0, len(audit.Entries))
+
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Bootstrap(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	adminMock := mock.NewMockAdminRepository(c)
+	adminMock.EXPECT().GrantAdmin(gomock.Any(), []string{"root", "missing"}).Return([]int{1}, nil)
+	audit := &testutils.Auditor{}
+	s := newTestService(mock.NewMockAccountRepository(c), adminMock, &impersonatorStub{}, &resetterStub{}, audit)
+
+	assert.Equal(t, nil, s.Bootstrap(context.Background(), nil))
+	assert.Equal(t, nil, s.Bootstrap(context.Background(), []string{"root", "missing"}))
+
+	assert.Equal(t, 1, len(audit.Entries))
+	assert.Equal(t, model.AuditAdminGranted, audit.Entries[0].Action)
+	assert.Equal(t, (*int)(nil), audit.Entries[0].ActorID)
+	assert.Equal(t, 1, *audit.Entries[0].UserID)
+
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"context"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/tracing"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+// SessionImpersonator starts session of account on behalf of admin
+type SessionImpersonator interface {
+	Impersonate(ctx context.Context, adminID, userID, version int, client model.Client) (string, error)
+}
+
+// PasswordResetter replaces password of account and mails reset link
+type PasswordResetter interface {
+	Force(ctx context.Context, userID int) error
+}
+
+// Auditor appends actions of admins to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
+type Service struct {
+	accountsRepository *repository.AccountRepositoryImpl
+	adminRepository    *repository.AdminRepositoryImpl
+	sessions           SessionImpersonator
+	resetter           PasswordResetter
+	audit              Auditor
+	logger             logging.Logger
+}
+
+func NewService(accountsRepository *repository.AccountRepositoryImpl, adminRepository *repository.AdminRepositoryImpl,
+	sessions SessionImpersonator, resetter PasswordResetter, audit Auditor, logger logging.Logger) *Service {
+	return &Service{
+		accountsRepository: accountsRepository,
+		adminRepository:    adminRepository,
+		sessions:           sessions,
+		resetter:           resetter,
+		audit:              audit,
+		logger:             logger,
+	}
+}
+
+func (s *Service) GetRole(ctx context.Context, userID int) (string, error) {
+	ctx, span := tracing.Start(ctx, "admin.GetRole")
+	defer span.End()
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return a.Role, nil
+}
+
+func (s *Service) Search(ctx context.Context, search model.AdminSearch) ([]model.AdminAccount, error) {
+	ctx, span := tracing.Start(ctx, "admin.Search")
+	defer span.End()
+
+	if search.Limit <= 0 {
+		search.Limit = DefaultLimit
+	}
+	if search.Limit > MaxLimit {
+		search.Limit = MaxLimit
+	}
+	if search.Offset < 0 {
+		search.Offset = 0
+	}
+	return s.adminRepository.Search(ctx, search)
+}
+
+func (s *Service) GetOne(ctx context.Context, userID int) (model.AdminAccount, error) {
+	ctx, span := tracing.Start(ctx, "admin.GetOne")
+	defer span.End()
+
+	return s.adminRepository.GetOne(ctx, userID)
+}
+
+// SetDisabled enables or disables account of other user, disabled accounts
+// can not log in and their sessions are revoked. Admin accounts can not be
+// disabled.
+func (s *Service) SetDisabled(ctx context.Context, adminID, userID int, disabled bool) error {
+	ctx, span := tracing.Start(ctx, "admin.SetDisabled")
+	defer span.End()
+
+	if err := s.checkTarget(ctx, adminID, userID); err != nil {
+		return err
+	}
+
+	if err := s.adminRepository.SetDisabled(ctx, userID, disabled); err != nil {
+		return err
+	}
+	action := model.AuditAccountEnabled
+	if disabled {
+		action = model.AuditAccountDisabled
+	}
+	s.record(ctx, adminID, userID, action, model.AuditSummary{"disabled": disabled})
+
+	return nil
+}
+
+// ForcePasswordReset signs user out everywhere and mails password reset
+// link. It is not allowed on admin accounts.
+func (s *Service) ForcePasswordReset(ctx context.Context, adminID, userID int) error {
+	ctx, span := tracing.Start(ctx, "admin.ForcePasswordReset")
+	defer span.End()
+
+	if err := s.checkTarget(ctx, adminID, userID); err != nil {
+		return err
+	}
+
+	if err := s.resetter.Force(ctx, userID); err != nil {
+		return err
+	}
+	s.record(ctx, adminID, userID, model.AuditPasswordResetForced, nil)
+
+	return nil
+}
+
+// Impersonate issues access token of user for support. Admin accounts can
+// not be impersonated.
+func (s *Service) Impersonate(ctx context.Context, adminID, userID int, client model.Client) (string, error) {
+	ctx, span := tracing.Start(ctx, "admin.Impersonate")
+	defer span.End()
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if a.Role == model.RoleAdmin || a.ID == adminID {
+		return "", e.ClientAdminError
+	}
+	if a.Disabled {
+		return "", e.AccountDisabledError
+	}
+
+	token, err := s.sessions.Impersonate(ctx, adminID, a.ID, a.SessionVersion, client)
+	if err != nil {
+		return "", err
+	}
+	s.logger.WithContext(ctx).Warnf("Admin %v impersonated account %v", adminID, a.ID)
+	s.record(ctx, adminID, a.ID, model.AuditImpersonated, nil)
+
+	return token, nil
+}
+
+// Bootstrap grants admin role to accounts listed in config, it is run on
+// startup. Usernames which aren't registered yet are skipped.
+func (s *Service) Bootstrap(ctx context.Context, usernames []string) error {
+	ctx, span := tracing.Start(ctx, "admin.Bootstrap")
+	defer span.End()
+
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	ids, err := s.adminRepository.GrantAdmin(ctx, usernames)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		s.logger.WithContext(ctx).Warnf("Account %v was granted admin role by bootstrap", id)
+		entry := model.NewAuditEntry(id, model.AuditAdminGranted, model.AuditTarget("user", id))
+		entry.After = model.AuditSummary{"role": model.RoleAdmin, "source": "bootstrap"}
+		s.audit.Record(ctx, entry)
+	}
+
+	return nil
+}
+
+// checkTarget refuses actions on own account and on other admins, so one
+// admin can't lock another out
+func (s *Service) checkTarget(ctx context.Context, adminID, userID int) error {
+	if adminID == userID {
+		return e.ClientAdminError
+	}
+
+	a, err := s.adminRepository.GetOne(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if a.Role == model.RoleAdmin {
+		return e.ClientAdminError
+	}
+	return nil
+}
+
+func (s *Service) record(ctx context.Context, adminID, userID int, action model.AuditAction, after model.AuditSummary) {
+	entry := model.NewAuditEntry(userID, action, model.AuditTarget("user", userID))
+	entry.ActorID = &adminID
+	entry.After = after
+	s.audit.Record(ctx, entry)
+}
@@ -0,0 +1,143 @@
+//go:build unit
+// +build unit
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"testing"
+	"time"
+)
+
+func newTestService(r *mock.MockAuditRepository, now time.Time) *Service {
+	logging.Init()
+	s := NewService(&repository.AuditRepositoryImpl{AuditRepository: r}, logging.GetLogger())
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestService_Record(t *testing.T) {
+	userID, adminID := 1, 42
+	now := time.Now()
+
+	ctx := logging.WithRequestID(context.Background(), "request-1")
+	ctx = logging.WithClientIP(ctx, "10.0.0.1")
+
+	testSuites := []struct {
+		testName string
+		ctx      context.Context
+		in       model.AuditEntry
+		expected model.AuditEntry
+		err      error
+	}{
+		{
+			testName: "FilledFromContext",
+			ctx:      logging.WithUserID(ctx, userID),
+			in:       model.NewAuditEntry(userID, model.AuditNoteDeleted, "note:3"),
+			expected: model.AuditEntry{
+				ActorID:   &userID,
+				UserID:    &userID,
+				Action:    model.AuditNoteDeleted,
+				Target:    "note:3",
+				IP:        "10.0.0.1",
+				RequestID: "request-1",
+				CreatedAt: now,
+			},
+		},
+		{
+			testName: "SetFieldsKept",
+			ctx:      logging.WithUserID(ctx, userID),
+			in: model.AuditEntry{
+				ActorID: &adminID,
+				UserID:  &userID,
+				Action:  model.AuditImpersonated,
+				IP:      "192.168.0.1",
+			},
+			expected: model.AuditEntry{
+				ActorID:   &adminID,
+				UserID:    &userID,
+				Action:    model.AuditImpersonated,
+				IP:        "192.168.0.1",
+				RequestID: "request-1",
+				CreatedAt: now,
+			},
+		},
+		{
+			testName: "AnonymousActor",
+			ctx:      ctx,
+			in:       model.AuditEntry{Action: model.AuditLoginLocked, Target: "ip:10.0.0.1"},
+			expected: model.AuditEntry{
+				Action:    model.AuditLoginLocked,
+				Target:    "ip:10.0.0.1",
+				IP:        "10.0.0.1",
+				RequestID: "request-1",
+				CreatedAt: now,
+			},
+		},
+		{
+			testName: "FailureNotReturned",
+			ctx:      ctx,
+			in:       model.AuditEntry{Action: model.AuditLoginLocked},
+			expected: model.AuditEntry{
+				Action:    model.AuditLoginLocked,
+				IP:        "10.0.0.1",
+				RequestID: "request-1",
+				CreatedAt: now,
+			},
+			err: errors.New("connection refused"),
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mock.NewMockAuditRepository(c)
+			r.EXPECT().Create(gomock.Any(), testSuite.expected).Return(testSuite.err)
+
+			newTestService(r, now).Record(testSuite.ctx, testSuite.in)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_GetByUser(t *testing.T) {
+	testSuites := []struct {
+		testName string
+		in       model.AuditPage
+		expected model.AuditPage
+	}{
+		{"DefaultLimit", model.AuditPage{}, model.AuditPage{Limit: DefaultLimit}},
+		{"LimitCapped", model.AuditPage{Limit: 1000, Offset: 10}, model.AuditPage{Limit: MaxLimit, Offset: 10}},
+		{"NegativeOffset", model.AuditPage{Limit: 10, Offset: -1}, model.AuditPage{Limit: 10}},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			r := mock.NewMockAuditRepository(c)
+			r.EXPECT().GetByUser(gomock.Any(), 1, testSuite.expected).Return([]model.AuditEntry{}, nil)
+
+			_, err := newTestService(r, time.Now()).GetByUser(context.Background(), 1, testSuite.in)
+			assert.Equal(t, nil, err)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/pkg/logging"
+	"neatly/pkg/tracing"
+	"time"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+type Service struct {
+	repository *repository.AuditRepositoryImpl
+	logger     logging.Logger
+	now        func() time.Time
+}
+
+func NewService(repository *repository.AuditRepositoryImpl, logger logging.Logger) *Service {
+	return &Service{repository: repository, logger: logger, now: time.Now}
+}
+
+// Record appends entry to audit log. Actor, IP and request ID not set in
+// entry are taken from ctx. Audited action has already happened, so failure
+// to record it is only logged.
+func (s *Service) Record(ctx context.Context, entry model.AuditEntry) {
+	ctx, span := tracing.Start(ctx, "audit.Record")
+	defer span.End()
+
+	if entry.ActorID == nil {
+		if id, ok := logging.UserID(ctx); ok {
+			entry.ActorID = &id
+		}
+	}
+	if entry.IP == "" {
+		entry.IP = logging.ClientIP(ctx)
+	}
+	if entry.RequestID == "" {
+		entry.RequestID = logging.RequestID(ctx)
+	}
+	entry.CreatedAt = s.now()
+
+	if err := s.repository.Create(ctx, entry); err != nil {
+		s.logger.WithContext(ctx).WithFields(map[string]interface{}{
+			"action": entry.Action,
+			"target": entry.Target,
+		}).Errorf("Can't record audit entry: %v", err)
+	}
+}
+
+// GetByUser returns entries of account, including actions of admins on it
+func (s *Service) GetByUser(ctx context.Context, userID int, page model.AuditPage) ([]model.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "audit.GetByUser")
+	defer span.End()
+
+	return s.repository.GetByUser(ctx, userID, normalize(page))
+}
+
+// GetAll returns entries of every account and those bound to none, for admins
+func (s *Service) GetAll(ctx context.Context, page model.AuditPage) ([]model.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "audit.GetAll")
+	defer span.End()
+
+	return s.repository.GetAll(ctx, normalize(page))
+}
+
+func normalize(page model.AuditPage) model.AuditPage {
+	if page.Limit <= 0 {
+		page.Limit = DefaultLimit
+	}
+	if page.Limit > MaxLimit {
+		page.Limit = MaxLimit
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	return page
+}
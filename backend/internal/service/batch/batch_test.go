@@ -0,0 +1,485 @@
+//go:build unit
+// +build unit
+
+package batch
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/go-test/deep"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"testing"
+)
+
+func TestService_Apply(t *testing.T) {
+	type notesMockBehaviour func(r *mock.MockNoteRepository)
+	type tagsMockBehaviour func(r *mock.MockTagRepository)
+
+	testNote := mother.NoteMother()
+	testNote.ID = 1
+	recoloredNote := testNote
+	recoloredNote.Color = "FFFFFF"
+	archivedNote := testNote
+	archivedNote.Archived = true
+	otherNote := mother.NoteMother()
+	otherNote.ID = 2
+
+	testTag := mother.TagMother()
+	testTag.ID = 1
+	testTag.Label = "work"
+
+	testSuites := []struct {
+		testName        string
+		inOps           []model.BatchOperation
+		runsTransaction bool
+		notesBehaviour  notesMockBehaviour
+		tagsBehaviour   tagsMockBehaviour
+		outResults      []model.BatchResult
+		ExpectedError   error
+		expectedAudit   []model.AuditAction
+	}{
+		{
+			testName: "RecolorAndArchive",
+			inOps: []model.BatchOperation{
+				{Action: model.BatchActionRecolor, NoteIDs: []int{1}, Color: "FFFFFF"},
+				{Action: model.BatchActionArchive, NoteIDs: []int{1}},
+			},
+			runsTransaction: true,
+			notesBehaviour: func(r *mock.MockNoteRepository) {
+				gomock.InOrder(
+					r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(testNote, nil),
+					r.EXPECT().Update(gomock.Any(), 0, recoloredNote).Return(nil),
+					r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(testNote, nil),
+					r.EXPECT().UpdateState(gomock.Any(), 0, archivedNote).Return(nil),
+				)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository) {},
+			outResults: []model.BatchResult{
+				{Action: model.BatchActionRecolor, NoteIDs: []int{1}, Status: model.BatchStatusDone},
+				{Action: model.BatchActionArchive, NoteIDs: []int{1}, Status: model.BatchStatusDone},
+			},
+			ExpectedError: nil,
+			expectedAudit: []model.AuditAction{model.AuditNoteUpdated, model.AuditNoteUpdated},
+		},
+		{
+			testName: "AddExistingTag",
+			inOps: []model.BatchOperation{
+				{Action: model.BatchActionAddTag, NoteIDs: []int{1}, Tag: "work"},
+			},
+			runsTransaction: true,
+			notesBehaviour: func(r *mock.MockNoteRepository) {
+				r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(testNote, nil)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository) {
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), 0, 1).Return([]model.Tag{}, nil)
+				r.EXPECT().Assign(gomock.Any(), testTag.ID, 1, 0).Return(nil)
+				r.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			outResults: []model.BatchResult{
+				{Action: model.BatchActionAddTag, NoteIDs: []int{1}, Status: model.BatchStatusDone},
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName: "RemoveLastTagUse",
+			inOps: []model.BatchOperation{
+				{Action: model.BatchActionRemoveTag, NoteIDs: []int{1}, Tag: "work"},
+			},
+			runsTransaction: true,
+			notesBehaviour: func(r *mock.MockNoteRepository) {
+				r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(testNote, nil)
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Note{testNote, otherNote}, nil)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository) {
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().Detach(gomock.Any(), 0, testTag.ID, 1).Return(nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), 0, otherNote.ID).Return([]model.Tag{}, nil)
+				r.EXPECT().Delete(gomock.Any(), 0, testTag.ID).Return(nil)
+			},
+			outResults: []model.BatchResult{
+				{Action: model.BatchActionRemoveTag, NoteIDs: []int{1}, Status: model.BatchStatusDone},
+			},
+			ExpectedError: nil,
+			expectedAudit: []model.AuditAction{model.AuditTagDeleted},
+		},
+		{
+			testName: "RemoveSharedTag",
+			inOps: []model.BatchOperation{
+				{Action: model.BatchActionRemoveTag, NoteIDs: []int{1}, Tag: "work"},
+			},
+			runsTransaction: true,
+			notesBehaviour: func(r *mock.MockNoteRepository) {
+				r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(testNote, nil)
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Note{testNote, otherNote}, nil)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository) {
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().Detach(gomock.Any(), 0, testTag.ID, 1).Return(nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), 0, otherNote.ID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			outResults: []model.BatchResult{
+				{Action: model.BatchActionRemoveTag, NoteIDs: []int{1}, Status: model.BatchStatusDone},
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName: "MoveToNotebook",
+			inOps: []model.BatchOperation{
+				{Action: model.BatchActionMove, NoteIDs: []int{1}, Notebook: "work"},
+			},
+			runsTransaction: true,
+			notesBehaviour: func(r *mock.MockNoteRepository) {
+				r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(testNote, nil)
+				r.EXPECT().Move(gomock.Any(), 0, 1, "work").Return(nil)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository) {},
+			outResults: []model.BatchResult{
+				{Action: model.BatchActionMove, NoteIDs: []int{1}, Status: model.BatchStatusDone},
+			},
+			ExpectedError: nil,
+			expectedAudit: []model.AuditAction{model.AuditNoteUpdated},
+		},
+		{
+			testName: "MoveWithoutNotebook",
+			inOps: []model.BatchOperation{
+				{Action: model.BatchActionMove, NoteIDs: []int{1}},
+			},
+			runsTransaction: false,
+			notesBehaviour:  func(r *mock.MockNoteRepository) {},
+			tagsBehaviour:   func(r *mock.MockTagRepository) {},
+			outResults:      nil,
+			ExpectedError:   e.ClientBatchError,
+		},
+		{
+			testName: "FailureRollsBackEverything",
+			inOps: []model.BatchOperation{
+				{Action: model.BatchActionDelete, NoteIDs: []int{1}},
+				{Action: model.BatchActionDelete, NoteIDs: []int{2}},
+				{Action: model.BatchActionArchive, NoteIDs: []int{1}},
+			},
+			runsTransaction: true,
+			notesBehaviour: func(r *mock.MockNoteRepository) {
+				r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(testNote, nil)
+				r.EXPECT().Delete(gomock.Any(), 0, 1).Return(nil)
+				r.EXPECT().GetOne(gomock.Any(), 0, 2).Return(model.Note{}, e.ClientNoteError)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository) {},
+			outResults: []model.BatchResult{
+				{Action: model.BatchActionDelete, NoteIDs: []int{1}, Status: model.BatchStatusRolledBack},
+				{Action: model.BatchActionDelete, NoteIDs: []int{2}, Status: model.BatchStatusFailed, Error: e.ClientNoteError.Error()},
+				{Action: model.BatchActionArchive, NoteIDs: []int{1}, Status: model.BatchStatusSkipped},
+			},
+			ExpectedError: e.BatchAbortedError,
+		},
+		{
+			testName: "TooManyOperations",
+			inOps: []model.BatchOperation{
+				{Action: model.BatchActionDelete, NoteIDs: []int{1, 2, 3, 4}},
+			},
+			runsTransaction: false,
+			notesBehaviour:  func(r *mock.MockNoteRepository) {},
+			tagsBehaviour:   func(r *mock.MockTagRepository) {},
+			outResults:      nil,
+			ExpectedError:   e.ClientBatchError,
+		},
+		{
+			testName: "UnknownAction",
+			inOps: []model.BatchOperation{
+				{Action: "rename", NoteIDs: []int{1}},
+			},
+			runsTransaction: false,
+			notesBehaviour:  func(r *mock.MockNoteRepository) {},
+			tagsBehaviour:   func(r *mock.MockTagRepository) {},
+			outResults:      nil,
+			ExpectedError:   e.ClientBatchError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			notesMock := mock.NewMockNoteRepository(c)
+			tagsMock := mock.NewMockTagRepository(c)
+			transactorMock := mock.NewMockTransactor(c)
+			testSuite.notesBehaviour(notesMock)
+			testSuite.tagsBehaviour(tagsMock)
+
+			if testSuite.runsTransaction {
+				transactorMock.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, fn func(notes repository.NoteRepository, tags repository.TagRepository) error) error {
+						return fn(notesMock, tagsMock)
+					})
+			} else {
+				transactorMock.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			logging.Init()
+			transactor := &repository.TransactorImpl{
+				Transactor: transactorMock,
+			}
+			audit := &testutils.Auditor{}
+			mockService := NewService(transactor, 3, audit, logging.GetLogger())
+
+			got, err := mockService.Apply(context.Background(), 0, testSuite.inOps)
+
+			assert.Equal(t, true, errors.Is(err, testSuite.ExpectedError))
+			if diff := deep.Equal(testSuite.outResults, got); diff != nil {
+				t.Error(diff)
+			}
+			var actions []model.AuditAction
+			for _, entry := range audit.Entries {
+				actions = append(actions, entry.Action)
+			}
+			assert.Equal(t, testSuite.expectedAudit, actions)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Duplicate(t *testing.T) {
+	type notesMockBehaviour func(r *mock.MockNoteRepository)
+	type tagsMockBehaviour func(r *mock.MockTagRepository)
+
+	testNote := mother.NoteMother()
+	testNote.ID = 1
+	testNote.Header = "header"
+	testNote.Body = "body"
+	testNote.Pinned = true
+
+	testTag := mother.TagMother()
+	testTag.ID = 3
+
+	testSuites := []struct {
+		testName       string
+		notesBehaviour notesMockBehaviour
+		tagsBehaviour  tagsMockBehaviour
+		outHeader      string
+		outTags        []model.Tag
+		ExpectedError  error
+	}{
+		{
+			testName: "NoteDuplicated",
+			notesBehaviour: func(r *mock.MockNoteRepository) {
+				r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(testNote, nil)
+				r.EXPECT().Create(gomock.Any(), 0, gomock.Any()).DoAndReturn(func(_ context.Context, userID int, n *model.Note) error {
+					assert.Equal(t, "body", n.Body)
+					assert.Equal(t, false, n.Pinned)
+					n.ID = 2
+					return nil
+				})
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository) {
+				r.EXPECT().GetAllByNote(gomock.Any(), 0, 1).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().Assign(gomock.Any(), testTag.ID, 2, 0).Return(nil)
+			},
+			outHeader:     "header",
+			outTags:       []model.Tag{testTag},
+			ExpectedError: nil,
+		},
+		{
+			testName: "NoteNotFound",
+			notesBehaviour: func(r *mock.MockNoteRepository) {
+				r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(model.Note{}, e.ClientNoteError)
+				r.EXPECT().Create(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository) {},
+			outHeader:     "",
+			outTags:       nil,
+			ExpectedError: e.ClientNoteError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			notesMock := mock.NewMockNoteRepository(c)
+			tagsMock := mock.NewMockTagRepository(c)
+			transactorMock := mock.NewMockTransactor(c)
+			testSuite.notesBehaviour(notesMock)
+			testSuite.tagsBehaviour(tagsMock)
+			transactorMock.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, fn func(notes repository.NoteRepository, tags repository.TagRepository) error) error {
+					return fn(notesMock, tagsMock)
+				})
+
+			logging.Init()
+			transactor := &repository.TransactorImpl{
+				Transactor: transactorMock,
+			}
+			mockService := NewService(transactor, 3, &testutils.Auditor{}, logging.GetLogger())
+
+			got, err := mockService.Duplicate(context.Background(), 0, 1)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.outHeader, got.Header)
+			if diff := deep.Equal(testSuite.outTags, got.Tags); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Merge(t *testing.T) {
+	type notesMockBehaviour func(r *mock.MockNoteRepository)
+	type tagsMockBehaviour func(r *mock.MockTagRepository)
+
+	first := mother.NoteMother()
+	first.ID = 1
+	first.Header = "first"
+	first.Body = "one"
+	second := mother.NoteMother()
+	second.ID = 2
+	second.Header = "second"
+	second.Body = "two"
+	archivedFirst := first
+	archivedFirst.Archived = true
+	archivedSecond := second
+	archivedSecond.Archived = true
+
+	sharedTag := model.Tag{ID: 1, Label: "shared"}
+	ownTag := model.Tag{ID: 2, Label: "own"}
+
+	testSuites := []struct {
+		testName        string
+		inOpts          model.MergeOptions
+		runsTransaction bool
+		notesBehaviour  notesMockBehaviour
+		tagsBehaviour   tagsMockBehaviour
+		outBody         string
+		ExpectedError   error
+	}{
+		{
+			testName: "MergeInGivenOrderAndDeleteSources",
+			inOpts: model.MergeOptions{
+				NoteIDs:   []int{2, 1},
+				Separator: model.DefaultMergeSeparator,
+				Sources:   model.MergeSourcesDelete,
+			},
+			runsTransaction: true,
+			notesBehaviour: func(r *mock.MockNoteRepository) {
+				r.EXPECT().GetOne(gomock.Any(), 0, 2).Return(second, nil)
+				r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(first, nil)
+				r.EXPECT().Create(gomock.Any(), 0, gomock.Any()).DoAndReturn(func(_ context.Context, userID int, n *model.Note) error {
+					assert.Equal(t, "second", n.Header)
+					n.ID = 3
+					return nil
+				})
+				r.EXPECT().Delete(gomock.Any(), 0, 2).Return(nil)
+				r.EXPECT().Delete(gomock.Any(), 0, 1).Return(nil)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository) {
+				r.EXPECT().GetAllByNote(gomock.Any(), 0, 2).Return([]model.Tag{sharedTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), 0, 1).Return([]model.Tag{sharedTag, ownTag}, nil)
+				r.EXPECT().Assign(gomock.Any(), sharedTag.ID, 3, 0).Return(nil).Times(1)
+				r.EXPECT().Assign(gomock.Any(), ownTag.ID, 3, 0).Return(nil).Times(1)
+			},
+			outBody:       "two\n\none",
+			ExpectedError: nil,
+		},
+		{
+			testName: "MergeAndArchiveSources",
+			inOpts: model.MergeOptions{
+				NoteIDs:   []int{1, 2},
+				Separator: " ",
+				Sources:   model.MergeSourcesArchive,
+			},
+			runsTransaction: true,
+			notesBehaviour: func(r *mock.MockNoteRepository) {
+				r.EXPECT().GetOne(gomock.Any(), 0, 1).Return(first, nil)
+				r.EXPECT().GetOne(gomock.Any(), 0, 2).Return(second, nil)
+				r.EXPECT().Create(gomock.Any(), 0, gomock.Any()).Return(nil)
+				r.EXPECT().UpdateState(gomock.Any(), 0, archivedFirst).Return(nil)
+				r.EXPECT().UpdateState(gomock.Any(), 0, archivedSecond).Return(nil)
+				r.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository) {
+				r.EXPECT().GetAllByNote(gomock.Any(), 0, 1).Return([]model.Tag{}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), 0, 2).Return([]model.Tag{}, nil)
+			},
+			outBody:       "one two",
+			ExpectedError: nil,
+		},
+		{
+			testName: "SingleNoteCanNotBeMerged",
+			inOpts: model.MergeOptions{
+				NoteIDs: []int{1},
+				Sources: model.MergeSourcesDelete,
+			},
+			runsTransaction: false,
+			notesBehaviour:  func(r *mock.MockNoteRepository) {},
+			tagsBehaviour:   func(r *mock.MockTagRepository) {},
+			outBody:         "",
+			ExpectedError:   e.ClientBatchError,
+		},
+		{
+			testName: "DuplicatedNoteIDs",
+			inOpts: model.MergeOptions{
+				NoteIDs: []int{1, 1},
+				Sources: model.MergeSourcesDelete,
+			},
+			runsTransaction: false,
+			notesBehaviour:  func(r *mock.MockNoteRepository) {},
+			tagsBehaviour:   func(r *mock.MockTagRepository) {},
+			outBody:         "",
+			ExpectedError:   e.ClientBatchError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			notesMock := mock.NewMockNoteRepository(c)
+			tagsMock := mock.NewMockTagRepository(c)
+			transactorMock := mock.NewMockTransactor(c)
+			testSuite.notesBehaviour(notesMock)
+			testSuite.tagsBehaviour(tagsMock)
+
+			if testSuite.runsTransaction {
+				transactorMock.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, fn func(notes repository.NoteRepository, tags repository.TagRepository) error) error {
+						return fn(notesMock, tagsMock)
+					})
+			} else {
+				transactorMock.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			logging.Init()
+			transactor := &repository.TransactorImpl{
+				Transactor: transactorMock,
+			}
+			mockService := NewService(transactor, 3, &testutils.Auditor{}, logging.GetLogger())
+
+			got, err := mockService.Merge(context.Background(), 0, testSuite.inOpts)
+
+			assert.Equal(t, true, errors.Is(err, testSuite.ExpectedError))
+			assert.Equal(t, testSuite.outBody, got.Body)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
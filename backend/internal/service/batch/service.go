@@ -0,0 +1,416 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/tracing"
+	"strings"
+)
+
+// Auditor appends changes of notes and tags to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
+// journal collects audit entries of changes made in transaction, they are
+// recorded only once the transaction is committed
+type journal []model.AuditEntry
+
+func (j *journal) add(userID int, action model.AuditAction, target string, before, after model.AuditSummary) {
+	entry := model.NewAuditEntry(userID, action, target)
+	entry.Before = before
+	entry.After = after
+	*j = append(*j, entry)
+}
+
+type Service struct {
+	transactor    *repository.TransactorImpl
+	maxOperations int
+	audit         Auditor
+	logger        logging.Logger
+}
+
+func NewService(transactor *repository.TransactorImpl, maxOperations int, audit Auditor, logger logging.Logger) *Service {
+	return &Service{transactor: transactor, maxOperations: maxOperations, audit: audit, logger: logger}
+}
+
+func (s *Service) record(ctx context.Context, j journal) {
+	for _, entry := range j {
+		s.audit.Record(ctx, entry)
+	}
+}
+
+// Apply runs every operation in one transaction. If any operation fails
+// nothing is applied and results show which operation caused the failure.
+func (s *Service) Apply(ctx context.Context, userID int, ops []model.BatchOperation) ([]model.BatchResult, error) {
+	ctx, span := tracing.Start(ctx, "batch.Apply")
+	defer span.End()
+
+	if err := s.validate(ops); err != nil {
+		return nil, err
+	}
+
+	results := make([]model.BatchResult, len(ops))
+	for i, op := range ops {
+		results[i] = model.BatchResult{Action: op.Action, NoteIDs: op.NoteIDs, Status: model.BatchStatusSkipped}
+	}
+
+	var j journal
+	err := s.transactor.WithinTransaction(ctx, func(notes repository.NoteRepository, tags repository.TagRepository) error {
+		for i, op := range ops {
+			if err := s.apply(ctx, userID, op, notes, tags, &j); err != nil {
+				results[i].Status = model.BatchStatusFailed
+				results[i].Error = err.Error()
+				return err
+			}
+			results[i].Status = model.BatchStatusDone
+		}
+		return nil
+	})
+
+	if err != nil {
+		s.logger.WithContext(ctx).Infof("Batch of user %v rolled back: %v", userID, err)
+		for i := range results {
+			if results[i].Status == model.BatchStatusDone {
+				results[i].Status = model.BatchStatusRolledBack
+			}
+		}
+		return results, e.BatchAbortedError
+	}
+	s.record(ctx, j)
+
+	return results, nil
+}
+
+func (s *Service) validate(ops []model.BatchOperation) error {
+	if len(ops) == 0 {
+		return fmt.Errorf("%w: no operations given", e.ClientBatchError)
+	}
+	if size := model.BatchSize(ops); size > s.maxOperations {
+		return fmt.Errorf("%w: %v note operations exceed limit of %v", e.ClientBatchError, size, s.maxOperations)
+	}
+
+	for _, op := range ops {
+		if len(op.NoteIDs) == 0 {
+			return fmt.Errorf("%w: %v has no note ids", e.ClientBatchError, op.Action)
+		}
+
+		switch op.Action {
+		case model.BatchActionDelete, model.BatchActionArchive:
+		case model.BatchActionRecolor:
+			if op.Color == "" {
+				return fmt.Errorf("%w: recolor requires color", e.ClientBatchError)
+			}
+		case model.BatchActionAddTag, model.BatchActionRemoveTag:
+			if op.Tag == "" {
+				return fmt.Errorf("%w: %v requires tag", e.ClientBatchError, op.Action)
+			}
+		case model.BatchActionMove:
+			if op.Notebook == "" {
+				return fmt.Errorf("%w: move requires notebook", e.ClientBatchError)
+			}
+		default:
+			return fmt.Errorf("%w: unknown action %q", e.ClientBatchError, op.Action)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) apply(ctx context.Context, userID int, op model.BatchOperation, notes repository.NoteRepository,
+	tags repository.TagRepository, j *journal) error {
+	for _, noteID := range op.NoteIDs {
+		n, err := notes.GetOne(ctx, userID, noteID)
+		if err != nil {
+			return e.ClientNoteError
+		}
+		before := n.AuditSummary()
+		target := model.AuditTarget("note", noteID)
+
+		switch op.Action {
+		case model.BatchActionDelete:
+			err = notes.Delete(ctx, userID, noteID)
+			j.add(userID, model.AuditNoteDeleted, target, before, nil)
+		case model.BatchActionRecolor:
+			n.Color = op.Color
+			err = notes.Update(ctx, userID, n)
+			j.add(userID, model.AuditNoteUpdated, target, before, n.AuditSummary())
+		case model.BatchActionArchive:
+			n.Archived = true
+			err = notes.UpdateState(ctx, userID, n)
+			j.add(userID, model.AuditNoteUpdated, target, before, n.AuditSummary())
+		case model.BatchActionMove:
+			n.Notebook = op.Notebook
+			err = notes.Move(ctx, userID, noteID, op.Notebook)
+			j.add(userID, model.AuditNoteUpdated, target, before, n.AuditSummary())
+		case model.BatchActionAddTag:
+			err = s.addTag(ctx, userID, noteID, op.Tag, tags, j)
+		case model.BatchActionRemoveTag:
+			err = s.removeTag(ctx, userID, noteID, op.Tag, notes, tags, j)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) addTag(ctx context.Context, userID, noteID int, label string, tags repository.TagRepository, j *journal) error {
+	t, found, err := findTag(ctx, userID, label, tags)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		t = model.Tag{Label: label}
+		if err := tags.Create(ctx, userID, noteID, &t); err != nil {
+			return err
+		}
+		j.add(userID, model.AuditTagCreated, model.AuditTarget("tag", t.ID), nil, t.AuditSummary())
+		return tags.Assign(ctx, t.ID, noteID, userID)
+	}
+
+	assigned, err := tags.GetAllByNote(ctx, userID, noteID)
+	if err != nil {
+		return err
+	}
+	for _, at := range assigned {
+		if at.ID == t.ID {
+			return nil
+		}
+	}
+
+	return tags.Assign(ctx, t.ID, noteID, userID)
+}
+
+// removeTag detaches tag from note and, like tag.Detach, deletes the tag
+// once no other note carries it
+func (s *Service) removeTag(ctx context.Context, userID, noteID int, label string, notes repository.NoteRepository,
+	tags repository.TagRepository, j *journal) error {
+	t, found, err := findTag(ctx, userID, label, tags)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return e.ClientTagError
+	}
+
+	if err := tags.Detach(ctx, userID, t.ID, noteID); err != nil {
+		return err
+	}
+
+	ns, err := notes.GetAll(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, n := range ns {
+		if n.ID == noteID {
+			continue
+		}
+		assigned, err := tags.GetAllByNote(ctx, userID, n.ID)
+		if err != nil {
+			return err
+		}
+		for _, at := range assigned {
+			if at.ID == t.ID {
+				return nil
+			}
+		}
+	}
+
+	if err := tags.Delete(ctx, userID, t.ID); err != nil {
+		return err
+	}
+	j.add(userID, model.AuditTagDeleted, model.AuditTarget("tag", t.ID), t.AuditSummary(), nil)
+
+	return nil
+}
+
+func findTag(ctx context.Context, userID int, label string, tags repository.TagRepository) (model.Tag, bool, error) {
+	all, err := tags.GetAll(ctx, userID)
+	if err != nil {
+		return model.Tag{}, false, err
+	}
+
+	for _, t := range all {
+		if t.Label == label {
+			return t, true, nil
+		}
+	}
+	return model.Tag{}, false, nil
+}
+
+// Duplicate copies note with all its tag assignments into a new note
+func (s *Service) Duplicate(ctx context.Context, userID, noteID int) (model.Note, error) {
+	ctx, span := tracing.Start(ctx, "batch.Duplicate")
+	defer span.End()
+
+	var dup model.Note
+
+	err := s.transactor.WithinTransaction(ctx, func(notes repository.NoteRepository, tags repository.TagRepository) error {
+		n, err := notes.GetOne(ctx, userID, noteID)
+		if err != nil {
+			return e.ClientNoteError
+		}
+
+		noteTags, err := tags.GetAllByNote(ctx, userID, noteID)
+		if err != nil {
+			return err
+		}
+
+		dup = model.Note{
+			Header: n.Header,
+			Body:   n.Body,
+			Color:  n.Color,
+		}
+		dup.GenerateShortBody()
+
+		return s.createWithTags(ctx, userID, &dup, noteTags, notes, tags)
+	})
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	s.logger.WithContext(ctx).Infof("Note %v duplicated into note %v", noteID, dup.ID)
+	entry := model.NewAuditEntry(userID, model.AuditNoteCreated, model.AuditTarget("note", dup.ID))
+	entry.After = dup.AuditSummary()
+	entry.After["duplicate_of"] = noteID
+	s.audit.Record(ctx, entry)
+	return dup, nil
+}
+
+// Merge concatenates bodies of notes in given order into a new note with
+// union of their tags, then deletes or archives source notes
+func (s *Service) Merge(ctx context.Context, userID int, opts model.MergeOptions) (model.Note, error) {
+	ctx, span := tracing.Start(ctx, "batch.Merge")
+	defer span.End()
+
+	if err := s.validateMerge(opts); err != nil {
+		return model.Note{}, err
+	}
+
+	var (
+		merged model.Note
+		j      journal
+	)
+
+	err := s.transactor.WithinTransaction(ctx, func(notes repository.NoteRepository, tags repository.TagRepository) error {
+		var (
+			bodies    = make([]string, 0, len(opts.NoteIDs))
+			sources   = make([]model.Note, 0, len(opts.NoteIDs))
+			mergeTags = make([]model.Tag, 0)
+			seenTags  = make(map[int]bool)
+		)
+
+		for _, noteID := range opts.NoteIDs {
+			n, err := notes.GetOne(ctx, userID, noteID)
+			if err != nil {
+				return e.ClientNoteError
+			}
+			sources = append(sources, n)
+			bodies = append(bodies, n.Body)
+
+			noteTags, err := tags.GetAllByNote(ctx, userID, noteID)
+			if err != nil {
+				return err
+			}
+			for _, t := range noteTags {
+				if !seenTags[t.ID] {
+					seenTags[t.ID] = true
+					mergeTags = append(mergeTags, t)
+				}
+			}
+		}
+
+		merged = model.Note{
+			Header: opts.Header,
+			Body:   strings.Join(bodies, opts.Separator),
+			Color:  opts.Color,
+		}
+		if merged.Header == "" {
+			merged.Header = sources[0].Header
+		}
+		if merged.Color == "" {
+			merged.Color = sources[0].Color
+		}
+		merged.GenerateShortBody()
+
+		if err := s.createWithTags(ctx, userID, &merged, mergeTags, notes, tags); err != nil {
+			return err
+		}
+		after := merged.AuditSummary()
+		after["merged_from"] = opts.NoteIDs
+		j.add(userID, model.AuditNoteCreated, model.AuditTarget("note", merged.ID), nil, after)
+
+		for _, n := range sources {
+			var err error
+			switch opts.Sources {
+			case model.MergeSourcesDelete:
+				err = notes.Delete(ctx, userID, n.ID)
+				j.add(userID, model.AuditNoteDeleted, model.AuditTarget("note", n.ID), n.AuditSummary(), nil)
+			case model.MergeSourcesArchive:
+				before := n.AuditSummary()
+				n.Archived = true
+				err = notes.UpdateState(ctx, userID, n)
+				j.add(userID, model.AuditNoteUpdated, model.AuditTarget("note", n.ID), before, n.AuditSummary())
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	s.logger.WithContext(ctx).Infof("Notes %v merged into note %v", opts.NoteIDs, merged.ID)
+	s.record(ctx, j)
+	return merged, nil
+}
+
+func (s *Service) validateMerge(opts model.MergeOptions) error {
+	if len(opts.NoteIDs) < 2 {
+		return fmt.Errorf("%w: at least two notes are required to merge", e.ClientBatchError)
+	}
+	if len(opts.NoteIDs) > s.maxOperations {
+		return fmt.Errorf("%w: %v notes exceed limit of %v", e.ClientBatchError, len(opts.NoteIDs), s.maxOperations)
+	}
+
+	seen := make(map[int]bool, len(opts.NoteIDs))
+	for _, id := range opts.NoteIDs {
+		if seen[id] {
+			return fmt.Errorf("%w: note %v is given twice", e.ClientBatchError, id)
+		}
+		seen[id] = true
+	}
+
+	switch opts.Sources {
+	case model.MergeSourcesDelete, model.MergeSourcesArchive:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown sources action %q", e.ClientBatchError, opts.Sources)
+	}
+}
+
+func (s *Service) createWithTags(ctx context.Context, userID int, n *model.Note, noteTags []model.Tag, notes repository.NoteRepository, tags repository.TagRepository) error {
+	if err := notes.Create(ctx, userID, n); err != nil {
+		return err
+	}
+
+	for _, t := range noteTags {
+		if err := tags.Assign(ctx, t.ID, n.ID, userID); err != nil {
+			return err
+		}
+	}
+	n.Tags = noteTags
+
+	return nil
+}
@@ -0,0 +1,251 @@
+//go:build unit
+// +build unit
+
+package lockout
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"testing"
+	"time"
+)
+
+var testConfig = session.Lockout{
+	MaxAttempts:          3,
+	MaxIPAttempts:        10,
+	MaxChallengeAttempts: 3,
+	BaseDelay:            time.Minute,
+	MaxDelay:             time.Hour,
+	Window:               15 * time.Minute,
+}
+
+func TestLockoutDelay(t *testing.T) {
+	testSuites := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{1, 0},
+		{2, 0},
+		{3, time.Minute},
+		{4, 2 * time.Minute},
+		{6, 8 * time.Minute},
+		{20, time.Hour},
+	}
+
+	for _, testSuite := range testSuites {
+		delay := model.LockoutDelay(testSuite.failures, testConfig.MaxAttempts, testConfig.BaseDelay, testConfig.MaxDelay)
+		assert.Equal(t, testSuite.expected, delay)
+	}
+}
+
+func TestService_Check(t *testing.T) {
+	type lockoutRepoMockBehaviour func(r *mock.MockLockoutRepository, now time.Time)
+
+	now := time.Now()
+
+	testSuites := []struct {
+		testName           string
+		lockoutBehaviour   lockoutRepoMockBehaviour
+		expectedRetryAfter time.Duration
+		ExpectedError      error
+	}{
+		{
+			testName: "NotLocked",
+			lockoutBehaviour: func(r *mock.MockLockoutRepository, now time.Time) {
+				r.EXPECT().LockedUntil(gomock.Any(), gomock.Len(2), now).Return(time.Time{}, nil)
+			},
+			expectedRetryAfter: 0,
+			ExpectedError:      nil,
+		},
+		{
+			testName: "Locked",
+			lockoutBehaviour: func(r *mock.MockLockoutRepository, now time.Time) {
+				r.EXPECT().LockedUntil(gomock.Any(), gomock.Len(2), now).Return(now.Add(time.Minute), nil)
+			},
+			expectedRetryAfter: time.Minute,
+			ExpectedError:      e.LoginLockedError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoMock := mock.NewMockLockoutRepository(c)
+			testSuite.lockoutBehaviour(repoMock, now)
+
+			logging.Init()
+			s := NewService(&repository.LockoutRepositoryImpl{LockoutRepository: repoMock}, testConfig, &testutils.Auditor{}, logging.GetLogger())
+			s.now = func() time.Time { return now }
+
+			retryAfter, err := s.Check(context.Background(), "test", "127.0.0.1")
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.expectedRetryAfter, retryAfter)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Fail(t *testing.T) {
+	type lockoutRepoMockBehaviour func(r *mock.MockLockoutRepository, now time.Time)
+
+	now := time.Now()
+	userKey := model.UsernameAttemptKey("Test")
+	ipKey := model.IPAttemptKey("127.0.0.1")
+
+	testSuites := []struct {
+		testName         string
+		lockoutBehaviour lockoutRepoMockBehaviour
+		ExpectedError    error
+	}{
+		{
+			testName: "BelowThreshold",
+			lockoutBehaviour: func(r *mock.MockLockoutRepository, now time.Time) {
+				r.EXPECT().RegisterFailure(gomock.Any(), userKey, now, testConfig.Window).Return(model.LoginAttempt{Failures: 1}, nil)
+				r.EXPECT().RegisterFailure(gomock.Any(), ipKey, now, testConfig.Window).Return(model.LoginAttempt{Failures: 1}, nil)
+				r.EXPECT().Lock(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName: "UsernameLocked",
+			lockoutBehaviour: func(r *mock.MockLockoutRepository, now time.Time) {
+				r.EXPECT().RegisterFailure(gomock.Any(), userKey, now, testConfig.Window).Return(model.LoginAttempt{Failures: 4}, nil)
+				r.EXPECT().Lock(gomock.Any(), userKey, now.Add(2*time.Minute)).Return(nil)
+				r.EXPECT().RegisterFailure(gomock.Any(), ipKey, now, testConfig.Window).Return(model.LoginAttempt{Failures: 4}, nil)
+			},
+			ExpectedError: nil,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoMock := mock.NewMockLockoutRepository(c)
+			testSuite.lockoutBehaviour(repoMock, now)
+
+			logging.Init()
+			s := NewService(&repository.LockoutRepositoryImpl{LockoutRepository: repoMock}, testConfig, &testutils.Auditor{}, logging.GetLogger())
+			s.now = func() time.Time { return now }
+
+			err := s.Fail(context.Background(), "Test", "127.0.0.1")
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_FailChallenge(t *testing.T) {
+	type lockoutRepoMockBehaviour func(r *mock.MockLockoutRepository, now time.Time)
+
+	now := time.Now()
+	challenge := model.TwoFactorChallenge{ID: "abc", UserID: 1, Username: "Test", ExpiresAt: now.Add(5 * time.Minute)}
+	key := model.ChallengeAttemptKey(challenge.ID)
+	window := 5*time.Minute + testConfig.Window
+
+	testSuites := []struct {
+		testName         string
+		lockoutBehaviour lockoutRepoMockBehaviour
+		expectedAudit    int
+		ExpectedError    error
+	}{
+		{
+			testName: "BelowThreshold",
+			lockoutBehaviour: func(r *mock.MockLockoutRepository, now time.Time) {
+				r.EXPECT().RegisterFailure(gomock.Any(), key, now, window).Return(model.LoginAttempt{Failures: 2}, nil)
+				r.EXPECT().Lock(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedAudit: 0,
+			ExpectedError: nil,
+		},
+		{
+			testName: "ChallengeInvalidated",
+			lockoutBehaviour: func(r *mock.MockLockoutRepository, now time.Time) {
+				r.EXPECT().RegisterFailure(gomock.Any(), key, now, window).Return(model.LoginAttempt{Failures: 3}, nil)
+				r.EXPECT().Lock(gomock.Any(), key, challenge.ExpiresAt).Return(nil)
+			},
+			expectedAudit: 1,
+			ExpectedError: nil,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoMock := mock.NewMockLockoutRepository(c)
+			testSuite.lockoutBehaviour(repoMock, now)
+
+			logging.Init()
+			audit := &testutils.Auditor{}
+			s := NewService(&repository.LockoutRepositoryImpl{LockoutRepository: repoMock}, testConfig, audit, logging.GetLogger())
+			s.now = func() time.Time { return now }
+
+			err := s.FailChallenge(context.Background(), challenge, "127.0.0.1")
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.expectedAudit, len(audit.Entries))
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_CheckChallenge(t *testing.T) {
+	now := time.Now()
+	challenge := model.TwoFactorChallenge{ID: "abc", ExpiresAt: now.Add(5 * time.Minute)}
+	key := model.ChallengeAttemptKey(challenge.ID)
+
+	testSuites := []struct {
+		testName      string
+		lockedUntil   time.Time
+		ExpectedError error
+	}{
+		{"ChallengeValid", time.Time{}, nil},
+		{"ChallengeUsedUp", challenge.ExpiresAt, e.ClientTokenError},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoMock := mock.NewMockLockoutRepository(c)
+			repoMock.EXPECT().LockedUntil(gomock.Any(), []string{key}, now).Return(testSuite.lockedUntil, nil)
+
+			logging.Init()
+			s := NewService(&repository.LockoutRepositoryImpl{LockoutRepository: repoMock}, testConfig, &testutils.Auditor{}, logging.GetLogger())
+			s.now = func() time.Time { return now }
+
+			err := s.CheckChallenge(context.Background(), challenge)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
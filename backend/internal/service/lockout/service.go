@@ -0,0 +1,161 @@
+package lockout
+
+import (
+	"context"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/tracing"
+	"time"
+)
+
+// Auditor appends security relevant events to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
+type Service struct {
+	repository *repository.LockoutRepositoryImpl
+	cfg        session.Lockout
+	audit      Auditor
+	logger     logging.Logger
+	now        func() time.Time
+}
+
+func NewService(repository *repository.LockoutRepositoryImpl, cfg session.Lockout, audit Auditor, logger logging.Logger) *Service {
+	return &Service{
+		repository: repository,
+		cfg:        cfg,
+		audit:      audit,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// Check returns e.LoginLockedError along with time left if username or IP
+// is locked
+func (s *Service) Check(ctx context.Context, username, ip string) (time.Duration, error) {
+	ctx, span := tracing.Start(ctx, "lockout.Check")
+	defer span.End()
+
+	now := s.now()
+
+	keys := []string{model.UsernameAttemptKey(username), model.IPAttemptKey(ip)}
+	until, err := s.repository.LockedUntil(ctx, keys, now)
+	if err != nil {
+		return 0, err
+	}
+	if until.IsZero() {
+		return 0, nil
+	}
+
+	return until.Sub(now), e.LoginLockedError
+}
+
+// Fail counts failed login for username and IP and locks them once their
+// thresholds are reached
+func (s *Service) Fail(ctx context.Context, username, ip string) error {
+	ctx, span := tracing.Start(ctx, "lockout.Fail")
+	defer span.End()
+
+	now := s.now()
+
+	thresholds := []struct {
+		key       string
+		threshold int
+	}{
+		{model.UsernameAttemptKey(username), s.cfg.MaxAttempts},
+		{model.IPAttemptKey(ip), s.cfg.MaxIPAttempts},
+	}
+
+	for _, t := range thresholds {
+		a, err := s.repository.RegisterFailure(ctx, t.key, now, s.cfg.Window)
+		if err != nil {
+			return err
+		}
+
+		delay := model.LockoutDelay(a.Failures, t.threshold, s.cfg.BaseDelay, s.cfg.MaxDelay)
+		if delay == 0 {
+			continue
+		}
+		if err := s.repository.Lock(ctx, t.key, now.Add(delay)); err != nil {
+			return err
+		}
+		s.logger.WithContext(ctx).Warnf("Login of %v locked for %v after %v failed attempts", t.key, delay, a.Failures)
+		s.audit.Record(ctx, model.AuditEntry{
+			Action: model.AuditLoginLocked,
+			Target: t.key,
+			IP:     ip,
+			After: model.AuditSummary{
+				"username": username,
+				"failures": a.Failures,
+				"delay":    delay.String(),
+			},
+		})
+	}
+
+	return nil
+}
+
+// CheckChallenge returns e.ClientTokenError if two-factor challenge has
+// used up its attempts
+func (s *Service) CheckChallenge(ctx context.Context, c model.TwoFactorChallenge) error {
+	ctx, span := tracing.Start(ctx, "lockout.CheckChallenge")
+	defer span.End()
+
+	until, err := s.repository.LockedUntil(ctx, []string{model.ChallengeAttemptKey(c.ID)}, s.now())
+	if err != nil {
+		return err
+	}
+	if !until.IsZero() {
+		return e.ClientTokenError
+	}
+
+	return nil
+}
+
+// FailChallenge counts wrong code sent with two-factor challenge and
+// invalidates the challenge once it reaches the threshold
+func (s *Service) FailChallenge(ctx context.Context, c model.TwoFactorChallenge, ip string) error {
+	ctx, span := tracing.Start(ctx, "lockout.FailChallenge")
+	defer span.End()
+
+	now := s.now()
+	key := model.ChallengeAttemptKey(c.ID)
+
+	// challenge expires long before failures could be forgotten
+	a, err := s.repository.RegisterFailure(ctx, key, now, c.ExpiresAt.Sub(now)+s.cfg.Window)
+	if err != nil {
+		return err
+	}
+	if s.cfg.MaxChallengeAttempts <= 0 || a.Failures < s.cfg.MaxChallengeAttempts {
+		return nil
+	}
+	if err := s.repository.Lock(ctx, key, c.ExpiresAt); err != nil {
+		return err
+	}
+	s.logger.WithContext(ctx).Warnf("Two-factor challenge of account %v invalidated after %v wrong codes", c.UserID, a.Failures)
+	s.audit.Record(ctx, model.AuditEntry{
+		UserID: &c.UserID,
+		Action: model.AuditLoginLocked,
+		Target: model.AuditTarget("username", c.Username),
+		IP:     ip,
+		After: model.AuditSummary{
+			"reason":   "two_factor",
+			"failures": a.Failures,
+		},
+	})
+
+	return nil
+}
+
+// Succeed forgets failures of username. Failures of IP are kept, so they
+// can not be reset by logging into attacker's own account.
+func (s *Service) Succeed(ctx context.Context, username string) error {
+	ctx, span := tracing.Start(ctx, "lockout.Succeed")
+	defer span.End()
+
+	return s.repository.Reset(ctx, model.UsernameAttemptKey(username))
+}
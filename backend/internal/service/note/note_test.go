@@ -4,6 +4,7 @@
 package note
 
 import (
+	"context"
 	"database/sql"
 	"github.com/go-playground/assert/v2"
 	"github.com/go-test/deep"
@@ -33,7 +34,7 @@ func TestService_Create(t *testing.T) {
 			testName: "CreateNoteSuccessful",
 			inNote:   testNote,
 			CreateNoteBehaviour: func(r *mock.MockNoteRepository, n *model.Note) {
-				r.EXPECT().Create(0, n).Return(nil)
+				r.EXPECT().Create(gomock.Any(), 0, n).Return(nil)
 			},
 			outNote:       testNote,
 			ExpectedError: nil,
@@ -42,7 +43,7 @@ func TestService_Create(t *testing.T) {
 			testName: "CreateNoteFailure",
 			inNote:   testNote,
 			CreateNoteBehaviour: func(r *mock.MockNoteRepository, n *model.Note) {
-				r.EXPECT().Create(0, n).Return(sql.ErrTxDone)
+				r.EXPECT().Create(gomock.Any(), 0, n).Return(sql.ErrTxDone)
 			},
 			outNote:       testNote,
 			ExpectedError: sql.ErrTxDone,
@@ -60,9 +61,9 @@ func TestService_Create(t *testing.T) {
 			repo := &repository.NoteRepositoryImpl{
 				NoteRepository: repoMock,
 			}
-			mockService := NewService(repo, nil, logging.GetLogger())
+			mockService := NewService(repo, nil, &testutils.Auditor{}, logging.GetLogger())
 
-			err := mockService.Create(0, &testSuite.inNote)
+			err := mockService.Create(context.Background(), 0, &testSuite.inNote)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 		})
@@ -86,6 +87,9 @@ func TestService_GetAll(t *testing.T) {
 	testNoteWithTag := testNote
 	testNoteWithTag.Tags = []model.Tag{testTag}
 
+	testNoteArchived := testNote
+	testNoteArchived.Archived = true
+
 	testSuites := []struct {
 		testName          string
 		GetNotesBehaviour noteRepoMockBehaviour
@@ -93,13 +97,24 @@ func TestService_GetAll(t *testing.T) {
 		outNotes          []model.Note
 		ExpectedError     error
 	}{
+		{
+			testName: "ArchivedNoteIsHidden",
+			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Note{testNoteArchived}, nil)
+			},
+			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Times(0)
+			},
+			outNotes:      []model.Note{},
+			ExpectedError: nil,
+		},
 		{
 			testName: "UserHasNoNotes",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Note{}, nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Note{}, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Times(0)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Times(0)
 			},
 			outNotes:      []model.Note{},
 			ExpectedError: nil,
@@ -107,10 +122,10 @@ func TestService_GetAll(t *testing.T) {
 		{
 			testName: "UserHasNoteWithNoTags",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Note{testNote}, nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Note{testNote}, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{}, nil)
 			},
 			outNotes:      []model.Note{testNoteWithoutTags},
 			ExpectedError: nil,
@@ -118,10 +133,10 @@ func TestService_GetAll(t *testing.T) {
 		{
 			testName: "UserHasNoteWithTag",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Note{testNote}, nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Note{testNote}, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{testTag}, nil)
 			},
 			outNotes:      []model.Note{testNoteWithTag},
 			ExpectedError: nil,
@@ -129,10 +144,10 @@ func TestService_GetAll(t *testing.T) {
 		{
 			testName: "GetNoteError",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
-				r.EXPECT().GetAll(0).Return([]model.Note{}, sql.ErrNoRows)
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Note{}, sql.ErrNoRows)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Times(0)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Times(0)
 			},
 			outNotes:      []model.Note{},
 			ExpectedError: sql.ErrNoRows,
@@ -140,10 +155,10 @@ func TestService_GetAll(t *testing.T) {
 		{
 			testName: "GetTagError",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
-				r.EXPECT().GetAll(0).Return([]model.Note{testNote}, nil)
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Note{testNote}, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{}, sql.ErrNoRows)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{}, sql.ErrNoRows)
 			},
 			outNotes:      []model.Note{},
 			ExpectedError: sql.ErrNoRows,
@@ -168,9 +183,9 @@ func TestService_GetAll(t *testing.T) {
 			tagRepo := &repository.TagRepositoryImpl{
 				TagRepository: tagRepoMock,
 			}
-			mockService := NewService(noteRepo, tagRepo, logging.GetLogger())
+			mockService := NewService(noteRepo, tagRepo, &testutils.Auditor{}, logging.GetLogger())
 
-			got, err := mockService.GetAll(0)
+			got, err := mockService.GetAll(context.Background(), 0, false)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 			if diff := deep.Equal(testSuite.outNotes, got); diff != nil {
@@ -207,10 +222,10 @@ func TestService_GetOne(t *testing.T) {
 		{
 			testName: "UserHasNoNotes",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
-				r.EXPECT().GetOne(UserID, noteID).Return(model.Note{}, e.ClientNoteError)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(model.Note{}, e.ClientNoteError)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Times(0)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Times(0)
 			},
 			outNote:       model.Note{},
 			ExpectedError: e.ClientNoteError,
@@ -218,10 +233,10 @@ func TestService_GetOne(t *testing.T) {
 		{
 			testName: "UserHasNoteWithNoTags",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
-				r.EXPECT().GetOne(UserID, noteID).Return(testNote, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(testNote, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{}, nil)
 			},
 			outNote:       testNoteWithoutTags,
 			ExpectedError: nil,
@@ -229,10 +244,10 @@ func TestService_GetOne(t *testing.T) {
 		{
 			testName: "UserHasNoteWithTag",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
-				r.EXPECT().GetOne(UserID, noteID).Return(testNote, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(testNote, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{testTag}, nil)
 			},
 			outNote:       testNoteWithTag,
 			ExpectedError: nil,
@@ -240,10 +255,10 @@ func TestService_GetOne(t *testing.T) {
 		{
 			testName: "GetTagError",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
-				r.EXPECT().GetOne(UserID, noteID).Return(testNote, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(testNote, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{}, sql.ErrNoRows)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{}, sql.ErrNoRows)
 			},
 			outNote:       model.Note{},
 			ExpectedError: sql.ErrNoRows,
@@ -268,9 +283,9 @@ func TestService_GetOne(t *testing.T) {
 			tagRepo := &repository.TagRepositoryImpl{
 				TagRepository: tagRepoMock,
 			}
-			mockService := NewService(noteRepo, tagRepo, logging.GetLogger())
+			mockService := NewService(noteRepo, tagRepo, &testutils.Auditor{}, logging.GetLogger())
 
-			got, err := mockService.GetOne(0, 0)
+			got, err := mockService.GetOne(context.Background(), 0, 0)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 			if diff := deep.Equal(testSuite.outNote, got); diff != nil {
@@ -297,16 +312,16 @@ func TestService_Delete(t *testing.T) {
 		{
 			testName: "DeletedSuccessfully",
 			DeleteNoteBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
-				r.EXPECT().GetOne(UserID, noteID).Return(testNote, nil)
-				r.EXPECT().Delete(UserID, noteID).Return(nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(testNote, nil)
+				r.EXPECT().Delete(gomock.Any(), UserID, noteID).Return(nil)
 			},
 			ExpectedError: nil,
 		},
 		{
 			testName: "NoteNotFound",
 			DeleteNoteBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
-				r.EXPECT().GetOne(UserID, noteID).Return(model.Note{}, sql.ErrNoRows)
-				r.EXPECT().Delete(UserID, noteID).Times(0)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(model.Note{}, sql.ErrNoRows)
+				r.EXPECT().Delete(gomock.Any(), UserID, noteID).Times(0)
 			},
 			ExpectedError: e.ClientNoteError,
 		},
@@ -323,9 +338,9 @@ func TestService_Delete(t *testing.T) {
 			repo := &repository.NoteRepositoryImpl{
 				NoteRepository: repoMock,
 			}
-			mockService := NewService(repo, nil, logging.GetLogger())
+			mockService := NewService(repo, nil, &testutils.Auditor{}, logging.GetLogger())
 
-			err := mockService.Delete(0, 0)
+			err := mockService.Delete(context.Background(), 0, 0)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 		})
@@ -361,10 +376,10 @@ func TestService_FindByTags(t *testing.T) {
 		{
 			testName: "UserHasNoNotes",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Note{}, nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Note{}, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Times(0)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Times(0)
 			},
 			outNotes:      []model.Note{},
 			ExpectedError: nil,
@@ -372,10 +387,10 @@ func TestService_FindByTags(t *testing.T) {
 		{
 			testName: "UserHasNoteWithNoTags",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Note{testNote}, nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Note{testNote}, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{}, nil)
 			},
 			outNotes:      []model.Note{},
 			ExpectedError: nil,
@@ -383,10 +398,10 @@ func TestService_FindByTags(t *testing.T) {
 		{
 			testName: "UserHasNoteWithTagThatSuits",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Note{testNote}, nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Note{testNote}, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{testTag}, nil)
 			},
 			outNotes:      []model.Note{testNoteWithTag},
 			ExpectedError: nil,
@@ -394,10 +409,10 @@ func TestService_FindByTags(t *testing.T) {
 		{
 			testName: "GetNoteError",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
-				r.EXPECT().GetAll(0).Return([]model.Note{}, sql.ErrNoRows)
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Note{}, sql.ErrNoRows)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Times(0)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Times(0)
 			},
 			outNotes:      []model.Note{},
 			ExpectedError: sql.ErrNoRows,
@@ -405,10 +420,10 @@ func TestService_FindByTags(t *testing.T) {
 		{
 			testName: "GetTagError",
 			GetNotesBehaviour: func(r *mock.MockNoteRepository, UserID int) {
-				r.EXPECT().GetAll(0).Return([]model.Note{testNote}, nil)
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Note{testNote}, nil)
 			},
 			GetTagsBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{}, sql.ErrNoRows)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{}, sql.ErrNoRows)
 			},
 			outNotes:      []model.Note{},
 			ExpectedError: sql.ErrNoRows,
@@ -433,10 +448,10 @@ func TestService_FindByTags(t *testing.T) {
 			tagRepo := &repository.TagRepositoryImpl{
 				TagRepository: tagRepoMock,
 			}
-			mockService := NewService(noteRepo, tagRepo, logging.GetLogger())
+			mockService := NewService(noteRepo, tagRepo, &testutils.Auditor{}, logging.GetLogger())
 
 			tags := []string{"psql_test"}
-			got, err := mockService.FindByTags(0, tags)
+			got, err := mockService.FindByTags(context.Background(), 0, tags, false)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 			if diff := deep.Equal(testSuite.outNotes, got); diff != nil {
@@ -462,35 +477,35 @@ func TestService_Update(t *testing.T) {
 		UpdateNoteBehaviour noteRepoMockBehaviour
 		inNote              model.Note
 		outNote             model.Note
-		needsBodyUpdate     bool
+		mask                model.NoteUpdateMask
 		ExpectedError       error
 	}{
 		{
 			testName: "NoteUpdatedWithoutBody",
 			UpdateNoteBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int, n model.Note) {
-				r.EXPECT().GetOne(UserID, noteID).Return(noteForUpdate, nil)
-				r.EXPECT().Update(UserID, noteForUpdate).Return(nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(noteForUpdate, nil)
+				r.EXPECT().Update(gomock.Any(), UserID, noteForUpdate).Return(nil)
 			},
-			needsBodyUpdate: false,
-			ExpectedError:   nil,
+			mask:          model.NoteUpdateMask{},
+			ExpectedError: nil,
 		},
 		{
 			testName: "NoteUpdatedWithBody",
 			UpdateNoteBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int, n model.Note) {
-				r.EXPECT().GetOne(UserID, noteID).Return(noteForUpdate, nil)
-				r.EXPECT().Update(UserID, noteForUpdate).Return(nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(noteForUpdate, nil)
+				r.EXPECT().Update(gomock.Any(), UserID, noteForUpdate).Return(nil)
 			},
-			needsBodyUpdate: true,
-			ExpectedError:   nil,
+			mask:          model.NoteUpdateMask{Body: true},
+			ExpectedError: nil,
 		},
 		{
 			testName: "NoteDoesNotExist",
 			UpdateNoteBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int, n model.Note) {
-				r.EXPECT().GetOne(UserID, noteID).Return(model.Note{}, e.ClientNoteError)
-				r.EXPECT().Update(UserID, noteForUpdate).Times(0)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(model.Note{}, e.ClientNoteError)
+				r.EXPECT().Update(gomock.Any(), UserID, noteForUpdate).Times(0)
 			},
-			needsBodyUpdate: true,
-			ExpectedError:   e.ClientNoteError,
+			mask:          model.NoteUpdateMask{Body: true},
+			ExpectedError: e.ClientNoteError,
 		},
 	}
 	for _, testSuite := range testSuites {
@@ -505,9 +520,9 @@ func TestService_Update(t *testing.T) {
 			repo := &repository.NoteRepositoryImpl{
 				NoteRepository: repoMock,
 			}
-			mockService := NewService(repo, nil, logging.GetLogger())
+			mockService := NewService(repo, nil, &testutils.Auditor{}, logging.GetLogger())
 
-			err := mockService.Update(0, testSuite.inNote, testSuite.needsBodyUpdate)
+			err := mockService.Update(context.Background(), 0, testSuite.inNote, testSuite.mask)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 		})
@@ -517,3 +532,90 @@ func TestService_Update(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestService_ToggleState(t *testing.T) {
+	type noteRepoMockBehaviour func(r *mock.MockNoteRepository, UserID, noteID int)
+
+	testNote := mother.NoteMother()
+	pinnedNote := testNote
+	pinnedNote.Pinned = true
+	archivedNote := testNote
+	archivedNote.Archived = true
+	archivedPinnedNote := archivedNote
+	archivedPinnedNote.Pinned = true
+
+	testSuites := []struct {
+		testName       string
+		state          model.NoteState
+		StateBehaviour noteRepoMockBehaviour
+		outNote        model.Note
+		ExpectedError  error
+	}{
+		{
+			testName: "NotePinned",
+			state:    model.NoteStatePinned,
+			StateBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(testNote, nil)
+				r.EXPECT().ToggleState(gomock.Any(), UserID, noteID, model.NoteStatePinned).Return(pinnedNote, nil)
+			},
+			outNote:       pinnedNote,
+			ExpectedError: nil,
+		},
+		{
+			testName: "NoteUnpinned",
+			state:    model.NoteStatePinned,
+			StateBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(pinnedNote, nil)
+				r.EXPECT().ToggleState(gomock.Any(), UserID, noteID, model.NoteStatePinned).Return(testNote, nil)
+			},
+			outNote:       testNote,
+			ExpectedError: nil,
+		},
+		{
+			testName: "ConcurrentToggleKept",
+			state:    model.NoteStatePinned,
+			StateBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(testNote, nil)
+				r.EXPECT().ToggleState(gomock.Any(), UserID, noteID, model.NoteStatePinned).Return(archivedPinnedNote, nil)
+			},
+			outNote:       archivedPinnedNote,
+			ExpectedError: nil,
+		},
+		{
+			testName: "NoteDoesNotExist",
+			state:    model.NoteStateArchived,
+			StateBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(model.Note{}, sql.ErrNoRows)
+				r.EXPECT().ToggleState(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			outNote:       model.Note{},
+			ExpectedError: e.ClientNoteError,
+		},
+	}
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoMock := mock.NewMockNoteRepository(c)
+			testSuite.StateBehaviour(repoMock, 0, 0)
+
+			logging.Init()
+			repo := &repository.NoteRepositoryImpl{
+				NoteRepository: repoMock,
+			}
+			mockService := NewService(repo, nil, &testutils.Auditor{}, logging.GetLogger())
+
+			got, err := mockService.ToggleState(context.Background(), 0, 0, testSuite.state)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			if diff := deep.Equal(testSuite.outNote, got); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
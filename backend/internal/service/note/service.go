@@ -1,40 +1,59 @@
 package note
 
 import (
+	"context"
 	"neatly/internal/model"
 	"neatly/internal/repository"
 	"neatly/pkg/e"
 	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"neatly/pkg/tracing"
 )
 
+// Auditor appends changes of notes to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
 type Service struct {
 	notesRepository *repository.NoteRepositoryImpl
 	tagsRepository  *repository.TagRepositoryImpl
+	audit           Auditor
 	logger          logging.Logger
 }
 
-func NewService(notesRepository *repository.NoteRepositoryImpl, tagsRepository *repository.TagRepositoryImpl, logger logging.Logger) *Service {
-	return &Service{notesRepository: notesRepository, tagsRepository: tagsRepository, logger: logger}
+func NewService(notesRepository *repository.NoteRepositoryImpl, tagsRepository *repository.TagRepositoryImpl, audit Auditor,
+	logger logging.Logger) *Service {
+	return &Service{notesRepository: notesRepository, tagsRepository: tagsRepository, audit: audit, logger: logger}
 }
 
-func (s *Service) Create(userID int, n *model.Note) error {
-	err := s.notesRepository.Create(userID, n)
+func (s *Service) Create(ctx context.Context, userID int, n *model.Note) error {
+	ctx, span := tracing.Start(ctx, "note.Create")
+	defer span.End()
+
+	err := s.notesRepository.Create(ctx, userID, n)
 	if err != nil {
 		return err
 	}
+	metrics.NotesCreated.Inc()
+	s.record(ctx, userID, model.AuditNoteCreated, n.ID, nil, n.AuditSummary())
 
 	return nil
 }
 
-func (s *Service) GetAll(userID int) ([]model.Note, error) {
-	notes, err := s.notesRepository.GetAll(userID)
+func (s *Service) GetAll(ctx context.Context, userID int, withArchived bool) ([]model.Note, error) {
+	ctx, span := tracing.Start(ctx, "note.GetAll")
+	defer span.End()
+
+	notes, err := s.notesRepository.GetAll(ctx, userID)
 	if err != nil {
 		return []model.Note{}, err
 	}
+	notes = filterArchived(notes, withArchived)
 
 	for i := 0; i < len(notes); i++ {
 		noteID := notes[i].ID
-		tags, err := s.tagsRepository.GetAllByNote(userID, noteID)
+		tags, err := s.tagsRepository.GetAllByNote(ctx, userID, noteID)
 		if err != nil {
 			return []model.Note{}, err
 		}
@@ -44,13 +63,16 @@ func (s *Service) GetAll(userID int) ([]model.Note, error) {
 	return notes, nil
 }
 
-func (s *Service) GetOne(userID, noteID int) (model.Note, error) {
-	n, err := s.notesRepository.GetOne(userID, noteID)
+func (s *Service) GetOne(ctx context.Context, userID, noteID int) (model.Note, error) {
+	ctx, span := tracing.Start(ctx, "note.GetOne")
+	defer span.End()
+
+	n, err := s.notesRepository.GetOne(ctx, userID, noteID)
 	if err != nil {
 		return n, err
 	}
 
-	tags, err := s.tagsRepository.GetAllByNote(userID, n.ID)
+	tags, err := s.tagsRepository.GetAllByNote(ctx, userID, n.ID)
 	if err != nil {
 		return n, err
 	}
@@ -59,16 +81,27 @@ func (s *Service) GetOne(userID, noteID int) (model.Note, error) {
 	return n, nil
 }
 
-func (s *Service) Delete(userID, noteID int) error {
-	_, err := s.notesRepository.GetOne(userID, noteID)
+func (s *Service) Delete(ctx context.Context, userID, noteID int) error {
+	ctx, span := tracing.Start(ctx, "note.Delete")
+	defer span.End()
+
+	n, err := s.notesRepository.GetOne(ctx, userID, noteID)
 	if err != nil {
 		return e.ClientNoteError
 	}
-	return s.notesRepository.Delete(noteID, userID)
+	if err := s.notesRepository.Delete(ctx, userID, noteID); err != nil {
+		return err
+	}
+	s.record(ctx, userID, model.AuditNoteDeleted, noteID, n.AuditSummary(), nil)
+
+	return nil
 }
 
-func (s *Service) Update(userID int, n model.Note, needBodyUpdate bool) error {
-	prev, err := s.notesRepository.GetOne(userID, n.ID)
+func (s *Service) Update(ctx context.Context, userID int, n model.Note, mask model.NoteUpdateMask) error {
+	ctx, span := tracing.Start(ctx, "note.Update")
+	defer span.End()
+
+	prev, err := s.notesRepository.GetOne(ctx, userID, n.ID)
 	if err != nil {
 		return e.ClientNoteError
 	}
@@ -76,31 +109,79 @@ func (s *Service) Update(userID int, n model.Note, needBodyUpdate bool) error {
 		n.Header = prev.Header
 	}
 
-	if !needBodyUpdate {
+	if !mask.Body {
 		n.Body = prev.Body
 		n.ShortBody = prev.ShortBody
 	}
+	if !mask.Pinned {
+		n.Pinned = prev.Pinned
+	}
+	if !mask.Archived {
+		n.Archived = prev.Archived
+	}
+	if !mask.Favourite {
+		n.Favourite = prev.Favourite
+	}
+
+	if err := s.notesRepository.Update(ctx, userID, n); err != nil {
+		return err
+	}
+	s.record(ctx, userID, model.AuditNoteUpdated, n.ID, prev.AuditSummary(), n.AuditSummary())
+
+	return nil
+}
+
+func (s *Service) ToggleState(ctx context.Context, userID, noteID int, state model.NoteState) (model.Note, error) {
+	ctx, span := tracing.Start(ctx, "note.ToggleState")
+	defer span.End()
+
+	n, err := s.notesRepository.GetOne(ctx, userID, noteID)
+	if err != nil {
+		return n, e.ClientNoteError
+	}
+
+	toggled, err := s.notesRepository.ToggleState(ctx, userID, noteID, state)
+	if err != nil {
+		return n, err
+	}
+	n.Pinned, n.Archived, n.Favourite = toggled.Pinned, toggled.Archived, toggled.Favourite
+	s.logger.WithContext(ctx).Infof("Toggled state %v of note %v", state, noteID)
+
+	prev := n
+	prev.Toggle(state)
+	s.record(ctx, userID, model.AuditNoteUpdated, noteID, prev.AuditSummary(), n.AuditSummary())
+
+	return n, nil
+}
 
-	return s.notesRepository.Update(userID, n)
+func (s *Service) record(ctx context.Context, userID int, action model.AuditAction, noteID int, before, after model.AuditSummary) {
+	entry := model.NewAuditEntry(userID, action, model.AuditTarget("note", noteID))
+	entry.Before = before
+	entry.After = after
+	s.audit.Record(ctx, entry)
 }
 
-func (s *Service) FindByTags(userID int, tagNames []string) ([]model.Note, error) {
-	ns, err := s.notesRepository.GetAll(userID)
+func (s *Service) FindByTags(ctx context.Context, userID int, tagNames []string, withArchived bool) ([]model.Note, error) {
+	ctx, span := tracing.Start(ctx, "note.FindByTags")
+	defer span.End()
+
+	ns, err := s.notesRepository.GetAll(ctx, userID)
 	if err != nil {
 		return ns, err
 	}
+	ns = filterArchived(ns, withArchived)
 
 	var (
 		notesWithAllTags = make([]model.Note, 0)
 	)
 
 	for _, n := range ns {
-		n.Tags, err = s.tagsRepository.GetAllByNote(userID, n.ID)
+		n.Tags, err = s.tagsRepository.GetAllByNote(ctx, userID, n.ID)
 		if err != nil {
 			return notesWithAllTags, err
 		}
 
-		s.logger.Infof("Found tags from note %v: %v", n.ID, n.Tags)
+		s.logger.WithContext(ctx).Infof("Found tags from note %v: %v", n.ID, n.Tags)
 		if n.HasEveryTag(tagNames) {
 			notesWithAllTags = append(notesWithAllTags, n)
 		}
@@ -108,3 +189,17 @@ func (s *Service) FindByTags(userID int, tagNames []string) ([]model.Note, error
 
 	return notesWithAllTags, nil
 }
+
+func filterArchived(ns []model.Note, withArchived bool) []model.Note {
+	if withArchived {
+		return ns
+	}
+
+	filtered := make([]model.Note, 0, len(ns))
+	for _, n := range ns {
+		if !n.Archived {
+			filtered = append(filtered, n)
+		}
+	}
+	return filtered
+}
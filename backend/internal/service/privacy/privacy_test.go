@@ -0,0 +1,121 @@
+//go:build unit
+// +build unit
+
+package privacy
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"testing"
+	"time"
+)
+
+func TestService_ScheduleDeletion(t *testing.T) {
+	type accountRepoMockBehaviour func(r *mock.MockAccountRepository, a model.Account)
+
+	testAccount := mother.AccountMother()
+	grace := 24 * time.Hour
+
+	testSuites := []struct {
+		testName         string
+		inPassword       string
+		accountBehaviour accountRepoMockBehaviour
+		expectedAudit    []model.AuditAction
+		ExpectedError    error
+	}{
+		{
+			testName:   "DeletionScheduled",
+			inPassword: testAccount.Password,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+				r.EXPECT().ScheduleDeletion(gomock.Any(), a.ID, gomock.Any()).Return(nil)
+			},
+			expectedAudit: []model.AuditAction{model.AuditDeletionScheduled},
+			ExpectedError: nil,
+		},
+		{
+			testName:   "PasswordDoesNotMatch",
+			inPassword: "wrong password",
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+				r.EXPECT().ScheduleDeletion(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.ClientPasswordError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			accountMock := mock.NewMockAccountRepository(c)
+			testSuite.accountBehaviour(accountMock, testAccount)
+
+			logging.Init()
+			audit := &testutils.Auditor{}
+			s := NewService(
+				&repository.AccountRepositoryImpl{AccountRepository: accountMock},
+				&repository.ExportRepositoryImpl{ExportRepository: mock.NewMockExportRepository(c)},
+				session.Accounts{DeletionGrace: grace}, audit, logging.GetLogger())
+
+			deleteAfter, err := s.ScheduleDeletion(context.Background(), testAccount.ID, testSuite.inPassword)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			if err == nil {
+				assert.Equal(t, true, deleteAfter.After(time.Now().Add(grace-time.Minute)))
+			}
+			var actions []model.AuditAction
+			for _, entry := range audit.Entries {
+				actions = append(actions, entry.Action)
+			}
+			assert.Equal(t, testSuite.expectedAudit, actions)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Export(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	testNote := model.ExportNote{ID: 1, Header: "header", Body: "body", Color: model.DefaultNoteColor}
+	testTag := mother.TagMother()
+
+	exportMock := mock.NewMockExportRepository(c)
+	exportMock.EXPECT().Export(gomock.Any(), 1).Return(model.Export{
+		Notes:       []model.ExportNote{testNote},
+		Tags:        []model.Tag{testTag},
+		Assignments: []model.TagAssignment{{TagID: testTag.ID, NoteID: testNote.ID}},
+	}, nil)
+
+	logging.Init()
+	s := NewService(
+		&repository.AccountRepositoryImpl{AccountRepository: mock.NewMockAccountRepository(c)},
+		&repository.ExportRepositoryImpl{ExportRepository: exportMock},
+		session.Accounts{}, &testutils.Auditor{}, logging.GetLogger())
+
+	ex, err := s.Export(context.Background(), 1)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, model.ExportFormatVersion, ex.Version)
+	assert.Equal(t, false, ex.ExportedAt.IsZero())
+	assert.Equal(t, 1, len(ex.Assignments))
+
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
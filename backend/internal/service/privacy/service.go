@@ -0,0 +1,93 @@
+package privacy
+
+import (
+	"context"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/tracing"
+	"time"
+)
+
+// Auditor appends security relevant events to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
+type Service struct {
+	accountsRepository *repository.AccountRepositoryImpl
+	exportRepository   *repository.ExportRepositoryImpl
+	cfg                session.Accounts
+	audit              Auditor
+	logger             logging.Logger
+}
+
+func NewService(accountsRepository *repository.AccountRepositoryImpl, exportRepository *repository.ExportRepositoryImpl,
+	cfg session.Accounts, audit Auditor, logger logging.Logger) *Service {
+	return &Service{
+		accountsRepository: accountsRepository,
+		exportRepository:   exportRepository,
+		cfg:                cfg,
+		audit:              audit,
+		logger:             logger,
+	}
+}
+
+func (s *Service) Export(ctx context.Context, userID int) (model.Export, error) {
+	ctx, span := tracing.Start(ctx, "privacy.Export")
+	defer span.End()
+
+	ex, err := s.exportRepository.Export(ctx, userID)
+	if err != nil {
+		return ex, err
+	}
+
+	ex.Version = model.ExportFormatVersion
+	ex.ExportedAt = time.Now().UTC()
+	s.logger.WithContext(ctx).Infof("Data of account %v exported: %v notes, %v tags, %v templates",
+		userID, len(ex.Notes), len(ex.Tags), len(ex.Templates))
+
+	return ex, nil
+}
+
+// ScheduleDeletion confirms password and marks account to be purged when
+// grace period is over. Until then, logging in restores the account.
+func (s *Service) ScheduleDeletion(ctx context.Context, userID int, password string) (time.Time, error) {
+	ctx, span := tracing.Start(ctx, "privacy.ScheduleDeletion")
+	defer span.End()
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := a.CheckPassword(password); err != nil {
+		return time.Time{}, e.ClientPasswordError
+	}
+
+	deleteAfter := time.Now().Add(s.cfg.DeletionGrace).UTC()
+	if err := s.accountsRepository.ScheduleDeletion(ctx, userID, deleteAfter); err != nil {
+		return time.Time{}, err
+	}
+	s.logger.WithContext(ctx).Infof("Account %v scheduled for deletion after %v", userID, deleteAfter)
+	entry := model.NewAuditEntry(userID, model.AuditDeletionScheduled, model.AuditTarget("user", userID))
+	entry.After = model.AuditSummary{"delete_after": deleteAfter}
+	s.audit.Record(ctx, entry)
+
+	return deleteAfter, nil
+}
+
+func (s *Service) PurgeDeleted(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "privacy.PurgeDeleted")
+	defer span.End()
+
+	n, err := s.accountsRepository.PurgeDeleted(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		s.logger.WithContext(ctx).Infof("Purged %v deleted accounts", n)
+	}
+	return n, nil
+}
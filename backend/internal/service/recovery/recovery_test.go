@@ -0,0 +1,240 @@
+//go:build unit
+// +build unit
+
+package recovery
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/mail"
+	"neatly/pkg/testutils"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mailerStub struct {
+	sent []mail.Message
+}
+
+func (m *mailerStub) Send(msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func testConfig() *session.Config {
+	cfg := &session.Config{}
+	cfg.Tokens.PasswordResetTTL = time.Hour
+	cfg.Mail.BaseURL = "http://localhost"
+	return cfg
+}
+
+func TestService_RequestReset(t *testing.T) {
+	type accountRepoMockBehaviour func(r *mock.MockAccountRepository, email string)
+	type tokenRepoMockBehaviour func(r *mock.MockTokenRepository)
+
+	testAccount := mother.AccountMother()
+
+	testSuites := []struct {
+		testName          string
+		inEmail           string
+		accountBehaviour  accountRepoMockBehaviour
+		tokenBehaviour    tokenRepoMockBehaviour
+		expectedMailCount int
+		ExpectedError     error
+	}{
+		{
+			testName: "UnknownEmail",
+			inEmail:  "nobody@example.com",
+			accountBehaviour: func(r *mock.MockAccountRepository, email string) {
+				r.EXPECT().GetByEmail(gomock.Any(), email).Return(nil, nil)
+			},
+			tokenBehaviour: func(r *mock.MockTokenRepository) {
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedMailCount: 0,
+			ExpectedError:     nil,
+		},
+		{
+			testName: "KnownEmail",
+			inEmail:  testAccount.Email,
+			accountBehaviour: func(r *mock.MockAccountRepository, email string) {
+				r.EXPECT().GetByEmail(gomock.Any(), email).Return([]model.Account{testAccount}, nil)
+			},
+			tokenBehaviour: func(r *mock.MockTokenRepository) {
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedMailCount: 1,
+			ExpectedError:     nil,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			accountMock := mock.NewMockAccountRepository(c)
+			tokenMock := mock.NewMockTokenRepository(c)
+			testSuite.accountBehaviour(accountMock, testSuite.inEmail)
+			testSuite.tokenBehaviour(tokenMock)
+
+			logging.Init()
+			mailer := &mailerStub{}
+			s := NewService(
+				&repository.AccountRepositoryImpl{AccountRepository: accountMock},
+				&repository.TokenRepositoryImpl{TokenRepository: tokenMock},
+				&repository.TransactorImpl{Transactor: mock.NewMockTransactor(c)},
+				mailer, testConfig(), &testutils.Auditor{}, logging.GetLogger())
+
+			err := s.RequestReset(context.Background(), testSuite.inEmail)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.expectedMailCount, len(mailer.sent))
+			for _, msg := range mailer.sent {
+				assert.Equal(t, testAccount.Email, msg.To)
+				assert.Equal(t, true, strings.Contains(msg.Body, "token="))
+			}
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Reset(t *testing.T) {
+	type accountRepoMockBehaviour func(r *mock.MockAccountRepository, userID int)
+	type tokenRepoMockBehaviour func(r *mock.MockTokenRepository, token model.AccountToken)
+
+	token, testToken, err := model.NewAccountToken(1, model.TokenKindPasswordReset, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testSuites := []struct {
+		testName         string
+		inToken          string
+		accountBehaviour accountRepoMockBehaviour
+		tokenBehaviour   tokenRepoMockBehaviour
+		ExpectedError    error
+	}{
+		{
+			testName: "ResetSuccessful",
+			inToken:  token,
+			accountBehaviour: func(r *mock.MockAccountRepository, userID int) {
+				r.EXPECT().UpdatePassword(gomock.Any(), userID, gomock.Any()).Return(1, nil)
+			},
+			tokenBehaviour: func(r *mock.MockTokenRepository, t model.AccountToken) {
+				r.EXPECT().Consume(gomock.Any(), model.TokenKindPasswordReset, t.Hash).Return(t, nil)
+				r.EXPECT().Revoke(gomock.Any(), t.UserID, model.TokenKindPasswordReset).Return(nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName: "InvalidToken",
+			inToken:  "invalid",
+			accountBehaviour: func(r *mock.MockAccountRepository, userID int) {
+				r.EXPECT().UpdatePassword(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			tokenBehaviour: func(r *mock.MockTokenRepository, t model.AccountToken) {
+				r.EXPECT().Consume(gomock.Any(), model.TokenKindPasswordReset, model.HashToken("invalid")).
+					Return(model.AccountToken{}, e.ClientTokenError)
+				r.EXPECT().Revoke(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.ClientTokenError,
+		},
+		{
+			// transaction is rolled back, so token can be used again
+			testName: "UpdateFailed",
+			inToken:  token,
+			accountBehaviour: func(r *mock.MockAccountRepository, userID int) {
+				r.EXPECT().UpdatePassword(gomock.Any(), userID, gomock.Any()).Return(0, e.InternalDBError)
+			},
+			tokenBehaviour: func(r *mock.MockTokenRepository, t model.AccountToken) {
+				r.EXPECT().Consume(gomock.Any(), model.TokenKindPasswordReset, t.Hash).Return(t, nil)
+				r.EXPECT().Revoke(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.InternalDBError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			accountMock := mock.NewMockAccountRepository(c)
+			tokenMock := mock.NewMockTokenRepository(c)
+			testSuite.accountBehaviour(accountMock, testToken.UserID)
+			testSuite.tokenBehaviour(tokenMock, testToken)
+
+			// repositories are used only through transaction
+			transactorMock := mock.NewMockTransactor(c)
+			transactorMock.EXPECT().WithinAccountTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, fn func(accounts repository.AccountRepository, tokens repository.TokenRepository) error) error {
+					return fn(accountMock, tokenMock)
+				})
+
+			logging.Init()
+			audit := &testutils.Auditor{}
+			s := NewService(
+				&repository.AccountRepositoryImpl{AccountRepository: mock.NewMockAccountRepository(c)},
+				&repository.TokenRepositoryImpl{TokenRepository: mock.NewMockTokenRepository(c)},
+				&repository.TransactorImpl{Transactor: transactorMock},
+				&mailerStub{}, testConfig(), audit, logging.GetLogger())
+
+			err := s.Reset(context.Background(), testSuite.inToken, "new password")
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.ExpectedError == nil, len(audit.Entries) == 1)
+		})
+	}
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Force(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	testAccount := mother.AccountMother()
+
+	accountMock := mock.NewMockAccountRepository(c)
+	tokenMock := mock.NewMockTokenRepository(c)
+	accountMock.EXPECT().GetOne(gomock.Any(), testAccount.ID).Return(testAccount, nil)
+	accountMock.EXPECT().UpdatePassword(gomock.Any(), testAccount.ID, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, hash string) (int, error) {
+		assert.NotEqual(t, testAccount.PasswordHash, hash)
+		return testAccount.SessionVersion + 1, nil
+	})
+	tokenMock.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	logging.Init()
+	mailer := &mailerStub{}
+	s := NewService(
+		&repository.AccountRepositoryImpl{AccountRepository: accountMock},
+		&repository.TokenRepositoryImpl{TokenRepository: tokenMock},
+		&repository.TransactorImpl{Transactor: mock.NewMockTransactor(c)},
+		mailer, testConfig(), &testutils.Auditor{}, logging.GetLogger())
+
+	err := s.Force(context.Background(), testAccount.ID)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(mailer.sent))
+	assert.Equal(t, true, strings.Contains(mailer.sent[0].Body, "Administrator has reset password"))
+
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
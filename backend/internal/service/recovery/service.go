@@ -0,0 +1,188 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/internal/session"
+	"neatly/pkg/logging"
+	"neatly/pkg/mail"
+	"neatly/pkg/password"
+	"neatly/pkg/tracing"
+)
+
+const (
+	resetPath    = "/password/reset"
+	resetSubject = "Neat.ly password reset"
+	resetBody    = `Hello, %s!
+
+Somebody asked to reset password of your Neat.ly account %q.
+Follow the link below to choose a new password, it expires in %v:
+
+%s%s?token=%s
+
+If it was not you, just ignore this message.
+`
+	forcedBody = `Hello, %s!
+
+Administrator has reset password of your Neat.ly account %q, you have been
+signed out on all devices. Follow the link below to choose a new password,
+it expires in %v:
+
+%s%s?token=%s
+`
+)
+
+// Auditor appends security relevant events to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
+type Service struct {
+	accountsRepository *repository.AccountRepositoryImpl
+	tokensRepository   *repository.TokenRepositoryImpl
+	transactor         *repository.TransactorImpl
+	mailer             mail.Mailer
+	cfg                *session.Config
+	audit              Auditor
+	logger             logging.Logger
+}
+
+func NewService(accountsRepository *repository.AccountRepositoryImpl, tokensRepository *repository.TokenRepositoryImpl,
+	transactor *repository.TransactorImpl, mailer mail.Mailer, cfg *session.Config, audit Auditor, logger logging.Logger) *Service {
+	return &Service{
+		accountsRepository: accountsRepository,
+		tokensRepository:   tokensRepository,
+		transactor:         transactor,
+		mailer:             mailer,
+		cfg:                cfg,
+		audit:              audit,
+		logger:             logger,
+	}
+}
+
+// RequestReset mails reset token to every account registered with email.
+// Unknown emails and mailing failures are only logged, and mailer queues
+// mails instead of sending them in place, so neither response nor its
+// timing tells caller whether account exists.
+func (s *Service) RequestReset(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "recovery.RequestReset")
+	defer span.End()
+
+	accounts, err := s.accountsRepository.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		s.logger.WithContext(ctx).Info("Password reset requested for unknown email")
+		return nil
+	}
+
+	for _, a := range accounts {
+		if err := s.send(ctx, a, resetBody); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Force replaces password of account with random one, which signs it out
+// everywhere, and mails reset link to the owner
+func (s *Service) Force(ctx context.Context, userID int) error {
+	ctx, span := tracing.Start(ctx, "recovery.Force")
+	defer span.End()
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	random, err := model.RandomString()
+	if err != nil {
+		return err
+	}
+	phash, err := model.GeneratePasswordHash(random)
+	if err != nil {
+		return err
+	}
+	if _, err := s.accountsRepository.UpdatePassword(ctx, a.ID, phash); err != nil {
+		return err
+	}
+	s.logger.WithContext(ctx).Infof("Password of account %v has been reset by admin", a.ID)
+
+	return s.send(ctx, a, forcedBody)
+}
+
+// send creates reset token and mails it. Mailing failures are only logged.
+func (s *Service) send(ctx context.Context, a model.Account, body string) error {
+	ttl := s.cfg.Tokens.PasswordResetTTL
+
+	token, t, err := model.NewAccountToken(a.ID, model.TokenKindPasswordReset, ttl)
+	if err != nil {
+		return err
+	}
+	if err := s.tokensRepository.Create(ctx, &t); err != nil {
+		return err
+	}
+	entry := model.NewAuditEntry(a.ID, model.AuditTokenCreated, model.AuditTarget("token", t.ID))
+	entry.After = t.AuditSummary()
+	s.audit.Record(ctx, entry)
+
+	err = s.mailer.Send(mail.Message{
+		To:      a.Email,
+		Subject: resetSubject,
+		Body:    fmt.Sprintf(body, a.Name, a.Username, ttl, s.cfg.Mail.BaseURL, resetPath, token),
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("Can't send password reset mail to account %v: %v", a.ID, err)
+		return nil
+	}
+	s.logger.WithContext(ctx).Infof("Password reset mail sent to account %v", a.ID)
+
+	return nil
+}
+
+// Reset sets new password of account which owns token. Token can be used
+// only once, other reset tokens of the account are revoked. Token is
+// consumed in the same transaction as password is changed, so it stays
+// valid if the change fails.
+func (s *Service) Reset(ctx context.Context, token, pass string) error {
+	ctx, span := tracing.Start(ctx, "recovery.Reset")
+	defer span.End()
+
+	if err := password.Validate(pass); err != nil {
+		return err
+	}
+
+	var userID int
+	err := s.transactor.WithinAccountTransaction(ctx, func(accounts repository.AccountRepository, tokens repository.TokenRepository) error {
+		t, err := tokens.Consume(ctx, model.TokenKindPasswordReset, model.HashToken(token))
+		if err != nil {
+			return err
+		}
+
+		phash, err := model.GeneratePasswordHash(pass)
+		if err != nil {
+			return err
+		}
+
+		if _, err := accounts.UpdatePassword(ctx, t.UserID, phash); err != nil {
+			return err
+		}
+		userID = t.UserID
+		return tokens.Revoke(ctx, t.UserID, model.TokenKindPasswordReset)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.WithContext(ctx).Infof("Password of account %v has been reset", userID)
+	entry := model.NewAuditEntry(userID, model.AuditPasswordChanged, model.AuditTarget("user", userID))
+	entry.ActorID = &userID
+	entry.After = model.AuditSummary{"method": "reset_token"}
+	s.audit.Record(ctx, entry)
+
+	return nil
+}
@@ -1,64 +1,304 @@
 package service
 
 import (
+	"context"
 	"neatly/internal/model"
 	"neatly/internal/repository"
 	"neatly/internal/service/account"
+	"neatly/internal/service/admin"
+	"neatly/internal/service/audit"
+	"neatly/internal/service/batch"
+	"neatly/internal/service/lockout"
 	"neatly/internal/service/note"
+	"neatly/internal/service/privacy"
+	"neatly/internal/service/recovery"
+	"neatly/internal/service/sessions"
+	"neatly/internal/service/sso"
+	"neatly/internal/service/stats"
 	"neatly/internal/service/tag"
+	"neatly/internal/service/template"
+	"neatly/internal/service/twofactor"
+	"neatly/internal/service/verification"
+	"neatly/internal/session"
 	"neatly/pkg/logging"
+	"neatly/pkg/mail"
+	"time"
 )
 
 type AccountService interface {
-	CreateAccount(a *model.Account) error
-	GenerateJWT(a *model.Account) (string, error)
+	CreateAccount(ctx context.Context, a *model.Account) error
+	GenerateJWT(ctx context.Context, a *model.Account, client model.Client) (string, error)
+	GetOne(ctx context.Context, userID int) (model.Account, error)
+	Update(ctx context.Context, userID int, in model.Account, mask model.AccountUpdateMask) (model.Account, error)
+	ChangePassword(ctx context.Context, userID int, current, password string, client model.Client) (string, error)
 }
 
 type AccountServiceImpl struct {
 	AccountService
 }
 
-func NewAccountServiceImpl(repo *repository.AccountRepositoryImpl, logger logging.Logger) *AccountServiceImpl {
+func NewAccountServiceImpl(repo *repository.AccountRepositoryImpl, sessionService *SessionServiceImpl,
+	auditService *AuditServiceImpl, logger logging.Logger) *AccountServiceImpl {
 	return &AccountServiceImpl{
-		AccountService: account.NewService(repo, logger),
+		AccountService: account.NewService(repo, sessionService, auditService, logger),
 	}
 }
 
 type NoteService interface {
-	Create(userID int, n *model.Note) error
-	GetAll(userID int) ([]model.Note, error)
-	GetOne(userID, noteID int) (model.Note, error)
-	Delete(userID, noteID int) error
-	Update(userID int, n model.Note, needBodyUpdate bool) error
-	FindByTags(userID int, tagNames []string) ([]model.Note, error)
+	Create(ctx context.Context, userID int, n *model.Note) error
+	GetAll(ctx context.Context, userID int, withArchived bool) ([]model.Note, error)
+	GetOne(ctx context.Context, userID, noteID int) (model.Note, error)
+	Delete(ctx context.Context, userID, noteID int) error
+	Update(ctx context.Context, userID int, n model.Note, mask model.NoteUpdateMask) error
+	ToggleState(ctx context.Context, userID, noteID int, state model.NoteState) (model.Note, error)
+	FindByTags(ctx context.Context, userID int, tagNames []string, withArchived bool) ([]model.Note, error)
 }
 
 type NoteServiceImpl struct {
 	NoteService
 }
 
-func NewNoteServiceImpl(noteRepo *repository.NoteRepositoryImpl, tagRepo *repository.TagRepositoryImpl, logger logging.Logger) *NoteServiceImpl {
+func NewNoteServiceImpl(noteRepo *repository.NoteRepositoryImpl, tagRepo *repository.TagRepositoryImpl,
+	auditService *AuditServiceImpl, logger logging.Logger) *NoteServiceImpl {
 	return &NoteServiceImpl{
-		NoteService: note.NewService(noteRepo, tagRepo, logger),
+		NoteService: note.NewService(noteRepo, tagRepo, auditService, logger),
 	}
 }
 
 type TagService interface {
-	Create(userID, noteID int, tag *model.Tag) (bool, error)
-	GetAll(userID int) ([]model.Tag, error)
-	GetAllByNote(userID, noteID int) ([]model.Tag, error)
-	GetOne(userID, tagID int) (model.Tag, error)
-	Delete(userID, tagID int) error
-	Update(userID, tagID int, t model.Tag) error
-	Detach(userID, tagID, noteID int) error
+	Create(ctx context.Context, userID, noteID int, tag *model.Tag) (bool, error)
+	GetAll(ctx context.Context, userID int) ([]model.Tag, error)
+	GetAllByNote(ctx context.Context, userID, noteID int) ([]model.Tag, error)
+	GetOne(ctx context.Context, userID, tagID int) (model.Tag, error)
+	Delete(ctx context.Context, userID, tagID int) error
+	Update(ctx context.Context, userID, tagID int, t model.Tag) error
+	Detach(ctx context.Context, userID, tagID, noteID int) error
 }
 
 type TagServiceImpl struct {
 	TagService
 }
 
-func NewTagServiceImpl(noteRepo *repository.NoteRepositoryImpl, tagRepo *repository.TagRepositoryImpl, logger logging.Logger) *TagServiceImpl {
+func NewTagServiceImpl(noteRepo *repository.NoteRepositoryImpl, tagRepo *repository.TagRepositoryImpl,
+	auditService *AuditServiceImpl, logger logging.Logger) *TagServiceImpl {
 	return &TagServiceImpl{
-		TagService: tag.NewService(tagRepo, noteRepo, logger),
+		TagService: tag.NewService(tagRepo, noteRepo, auditService, logger),
+	}
+}
+
+type TemplateService interface {
+	Create(ctx context.Context, userID int, t *model.Template) error
+	GetAll(ctx context.Context, userID int) ([]model.Template, error)
+	GetOne(ctx context.Context, userID, templateID int) (model.Template, error)
+	Update(ctx context.Context, userID, templateID int, t model.Template, needBodyUpdate bool) error
+	Delete(ctx context.Context, userID, templateID int) error
+	Render(ctx context.Context, userID, templateID int) (model.Note, []model.Tag, error)
+	Instantiate(ctx context.Context, userID, templateID int) (model.Note, error)
+}
+
+type TemplateServiceImpl struct {
+	TemplateService
+}
+
+func NewTemplateServiceImpl(templateRepo *repository.TemplateRepositoryImpl, accountRepo *repository.AccountRepositoryImpl,
+	transactor *repository.TransactorImpl, auditService *AuditServiceImpl, logger logging.Logger) *TemplateServiceImpl {
+	return &TemplateServiceImpl{
+		TemplateService: template.NewService(templateRepo, accountRepo, transactor, auditService, logger),
+	}
+}
+
+type BatchService interface {
+	Apply(ctx context.Context, userID int, ops []model.BatchOperation) ([]model.BatchResult, error)
+	Duplicate(ctx context.Context, userID, noteID int) (model.Note, error)
+	Merge(ctx context.Context, userID int, opts model.MergeOptions) (model.Note, error)
+}
+
+type BatchServiceImpl struct {
+	BatchService
+}
+
+func NewBatchServiceImpl(transactor *repository.TransactorImpl, maxOperations int, auditService *AuditServiceImpl,
+	logger logging.Logger) *BatchServiceImpl {
+	return &BatchServiceImpl{
+		BatchService: batch.NewService(transactor, maxOperations, auditService, logger),
+	}
+}
+
+type StatsService interface {
+	Get(ctx context.Context, userID, days, limit int) (model.Stats, error)
+}
+
+type StatsServiceImpl struct {
+	StatsService
+}
+
+func NewStatsServiceImpl(statsRepo *repository.StatsRepositoryImpl, tagRepo *repository.TagRepositoryImpl,
+	logger logging.Logger) *StatsServiceImpl {
+	return &StatsServiceImpl{
+		StatsService: stats.NewService(statsRepo, tagRepo, logger),
+	}
+}
+
+type RecoveryService interface {
+	RequestReset(ctx context.Context, email string) error
+	Reset(ctx context.Context, token, password string) error
+	Force(ctx context.Context, userID int) error
+}
+
+type RecoveryServiceImpl struct {
+	RecoveryService
+}
+
+func NewRecoveryServiceImpl(accountRepo *repository.AccountRepositoryImpl, tokenRepo *repository.TokenRepositoryImpl,
+	transactor *repository.TransactorImpl, mailer mail.Mailer, cfg *session.Config, auditService *AuditServiceImpl,
+	logger logging.Logger) *RecoveryServiceImpl {
+	return &RecoveryServiceImpl{
+		RecoveryService: recovery.NewService(accountRepo, tokenRepo, transactor, mailer, cfg, auditService, logger),
+	}
+}
+
+type VerificationService interface {
+	Send(ctx context.Context, a model.Account) error
+	Resend(ctx context.Context, userID int) error
+	Verify(ctx context.Context, token string) error
+	IsVerified(ctx context.Context, userID int) (bool, error)
+}
+
+type VerificationServiceImpl struct {
+	VerificationService
+}
+
+func NewVerificationServiceImpl(accountRepo *repository.AccountRepositoryImpl, tokenRepo *repository.TokenRepositoryImpl,
+	mailer mail.Mailer, cfg *session.Config, auditService *AuditServiceImpl, logger logging.Logger) *VerificationServiceImpl {
+	return &VerificationServiceImpl{
+		VerificationService: verification.NewService(accountRepo, tokenRepo, mailer, cfg, auditService, logger),
+	}
+}
+
+type PrivacyService interface {
+	Export(ctx context.Context, userID int) (model.Export, error)
+	ScheduleDeletion(ctx context.Context, userID int, password string) (time.Time, error)
+	PurgeDeleted(ctx context.Context) (int, error)
+}
+
+type PrivacyServiceImpl struct {
+	PrivacyService
+}
+
+func NewPrivacyServiceImpl(accountRepo *repository.AccountRepositoryImpl, exportRepo *repository.ExportRepositoryImpl,
+	cfg session.Accounts, auditService *AuditServiceImpl, logger logging.Logger) *PrivacyServiceImpl {
+	return &PrivacyServiceImpl{
+		PrivacyService: privacy.NewService(accountRepo, exportRepo, cfg, auditService, logger),
+	}
+}
+
+type TwoFactorService interface {
+	Enrol(ctx context.Context, userID int) (model.TwoFactorEnrolment, error)
+	Confirm(ctx context.Context, userID int, code string) ([]string, error)
+	Disable(ctx context.Context, userID int, code string) error
+	Challenge(ctx context.Context, challenge string) (model.TwoFactorChallenge, error)
+	Login(ctx context.Context, challenge, code string, client model.Client) (string, error)
+}
+
+type TwoFactorServiceImpl struct {
+	TwoFactorService
+}
+
+func NewTwoFactorServiceImpl(accountRepo *repository.AccountRepositoryImpl, twoFactorRepo *repository.TwoFactorRepositoryImpl,
+	sessionService *SessionServiceImpl, auditService *AuditServiceImpl, logger logging.Logger) *TwoFactorServiceImpl {
+	return &TwoFactorServiceImpl{
+		TwoFactorService: twofactor.NewService(accountRepo, twoFactorRepo, sessionService, auditService, logger),
+	}
+}
+
+type LockoutService interface {
+	Check(ctx context.Context, username, ip string) (time.Duration, error)
+	Fail(ctx context.Context, username, ip string) error
+	CheckChallenge(ctx context.Context, c model.TwoFactorChallenge) error
+	FailChallenge(ctx context.Context, c model.TwoFactorChallenge, ip string) error
+	Succeed(ctx context.Context, username string) error
+}
+
+type LockoutServiceImpl struct {
+	LockoutService
+}
+
+func NewLockoutServiceImpl(lockoutRepo *repository.LockoutRepositoryImpl, cfg session.Lockout, auditService *AuditServiceImpl,
+	logger logging.Logger) *LockoutServiceImpl {
+	return &LockoutServiceImpl{
+		LockoutService: lockout.NewService(lockoutRepo, cfg, auditService, logger),
+	}
+}
+
+type SSOService interface {
+	AuthURL(ctx context.Context) (string, string, error)
+	Callback(ctx context.Context, state, code string, client model.Client) (string, bool, error)
+}
+
+type SSOServiceImpl struct {
+	SSOService
+}
+
+func NewSSOServiceImpl(accountRepo *repository.AccountRepositoryImpl, oidcRepo *repository.OIDCRepositoryImpl,
+	sessionService *SessionServiceImpl, cfg session.OIDC, auditService *AuditServiceImpl, logger logging.Logger) *SSOServiceImpl {
+	return &SSOServiceImpl{
+		SSOService: sso.NewService(accountRepo, oidcRepo, sessionService, cfg, auditService, logger),
+	}
+}
+
+type SessionService interface {
+	Start(ctx context.Context, userID, version int, client model.Client) (string, error)
+	Impersonate(ctx context.Context, adminID, userID, version int, client model.Client) (string, error)
+	GetAll(ctx context.Context, userID int, currentID string) ([]model.Session, error)
+	Revoke(ctx context.Context, userID int, sessionID string) error
+	Validate(ctx context.Context, userID int, sessionID string) error
+}
+
+type SessionServiceImpl struct {
+	SessionService
+}
+
+func NewSessionServiceImpl(sessionRepo *repository.SessionRepositoryImpl, cfg session.Sessions, auditService *AuditServiceImpl,
+	logger logging.Logger) *SessionServiceImpl {
+	return &SessionServiceImpl{
+		SessionService: sessions.NewService(sessionRepo, cfg, auditService, logger),
+	}
+}
+
+type AdminService interface {
+	GetRole(ctx context.Context, userID int) (string, error)
+	Search(ctx context.Context, search model.AdminSearch) ([]model.AdminAccount, error)
+	GetOne(ctx context.Context, userID int) (model.AdminAccount, error)
+	SetDisabled(ctx context.Context, adminID, userID int, disabled bool) error
+	ForcePasswordReset(ctx context.Context, adminID, userID int) error
+	Impersonate(ctx context.Context, adminID, userID int, client model.Client) (string, error)
+	Bootstrap(ctx context.Context, usernames []string) error
+}
+
+type AdminServiceImpl struct {
+	AdminService
+}
+
+func NewAdminServiceImpl(accountRepo *repository.AccountRepositoryImpl, adminRepo *repository.AdminRepositoryImpl,
+	sessionService *SessionServiceImpl, recoveryService *RecoveryServiceImpl, auditService *AuditServiceImpl,
+	logger logging.Logger) *AdminServiceImpl {
+	return &AdminServiceImpl{
+		AdminService: admin.NewService(accountRepo, adminRepo, sessionService, recoveryService, auditService, logger),
+	}
+}
+
+type AuditService interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+	GetByUser(ctx context.Context, userID int, page model.AuditPage) ([]model.AuditEntry, error)
+	GetAll(ctx context.Context, page model.AuditPage) ([]model.AuditEntry, error)
+}
+
+type AuditServiceImpl struct {
+	AuditService
+}
+
+func NewAuditServiceImpl(auditRepo *repository.AuditRepositoryImpl, logger logging.Logger) *AuditServiceImpl {
+	return &AuditServiceImpl{
+		AuditService: audit.NewService(auditRepo, logger),
 	}
 }
@@ -4,6 +4,7 @@
 package service_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
@@ -11,8 +12,11 @@ import (
 	"neatly/internal/model/mother"
 	"neatly/internal/repository"
 	"neatly/internal/service/account"
+	"neatly/internal/service/audit"
 	"neatly/internal/service/note"
+	"neatly/internal/service/sessions"
 	"neatly/internal/service/tag"
+	"neatly/internal/session"
 	"neatly/pkg/dbclient"
 	"neatly/pkg/e"
 	"neatly/pkg/logging"
@@ -37,9 +41,17 @@ func prepareAccountRepo(actions []string, client *dbclient.Client) error {
 	return nil
 }
 
+func newAudit(client *dbclient.Client, logger logging.Logger) *audit.Service {
+	return audit.NewService(repository.NewAuditRepositoryImpl(client, logger), logger)
+}
+
+func newSessions(client *dbclient.Client, logger logging.Logger) *sessions.Service {
+	return sessions.NewService(repository.NewSessionRepositoryImpl(client, logger), session.Sessions{}, newAudit(client, logger), logger)
+}
+
 func CreateNotes(amount int, userID int, repo repository.NoteRepository) error {
 	for i := 0; i < amount; i++ {
-		err := repo.Create(userID, &testNote)
+		err := repo.Create(context.Background(), userID, &testNote)
 		if err != nil {
 			return err
 		}
@@ -52,26 +64,26 @@ func prepareTagRepo(action string, userID, noteID int, repo repository.TagReposi
 	case "CREATE":
 		{
 			t := mother.TagMother()
-			return repo.Create(userID, noteID, &t)
+			return repo.Create(context.Background(), userID, noteID, &t)
 		}
 	case "ASSIGN":
 		{
 			t := mother.TagMother()
-			err := repo.Create(userID, noteID, &t)
+			err := repo.Create(context.Background(), userID, noteID, &t)
 			if err != nil {
 				return err
 			}
-			return repo.Assign(t.ID, noteID, userID)
+			return repo.Assign(context.Background(), t.ID, noteID, userID)
 		}
 	case "ASSIGN WITH LABEL":
 		{
 			t := mother.TagMother()
 			t.Label = "test"
-			err := repo.Create(userID, noteID, &t)
+			err := repo.Create(context.Background(), userID, noteID, &t)
 			if err != nil {
 				return err
 			}
-			return repo.Assign(t.ID, noteID, userID)
+			return repo.Assign(context.Background(), t.ID, noteID, userID)
 		}
 	default:
 		return nil
@@ -119,9 +131,9 @@ func TestIntegration_AccountCreate(t *testing.T) {
 				t.Fatalf("Can't do pre-test action: %s", err)
 			}
 
-			service := account.NewService(repo, logger)
+			service := account.NewService(repo, newSessions(client, logger), newAudit(client, logger), logger)
 
-			err = service.CreateAccount(&testSuite.inAccount)
+			err = service.CreateAccount(context.Background(), &testSuite.inAccount)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -187,9 +199,9 @@ func TestIntegration_GenerateJWT(t *testing.T) {
 				t.Fatalf("Can't do pre-test action: %s", err)
 			}
 
-			service := account.NewService(repo, logger)
+			service := account.NewService(repo, newSessions(client, logger), newAudit(client, logger), logger)
 
-			token, err := service.GenerateJWT(&testSuite.inAccount)
+			token, err := service.GenerateJWT(context.Background(), &testSuite.inAccount, model.Client{})
 			logger.Info(token)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
@@ -247,9 +259,9 @@ func TestIntegration_NoteCreate(t *testing.T) {
 				t.Fatalf("Can't do pre-test action: %s", err)
 			}
 
-			service := note.NewService(nr, tr, logger)
+			service := note.NewService(nr, tr, newAudit(client, logger), logger)
 
-			err = service.Create(testSuite.inID, &testNote)
+			err = service.Create(context.Background(), testSuite.inID, &testNote)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -334,9 +346,9 @@ func TestNoteGetAll(t *testing.T) {
 				t.Fatalf("Can't do pre-test note action: %s", err)
 			}
 
-			service := note.NewService(nr, tr, logger)
+			service := note.NewService(nr, tr, newAudit(client, logger), logger)
 
-			_, err = service.GetAll(testSuite.inID)
+			_, err = service.GetAll(context.Background(), testSuite.inID, false)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -412,9 +424,9 @@ func TestIntegration_NoteGetOne(t *testing.T) {
 				t.Fatalf("Can't do pre-test note action: %s", err)
 			}
 
-			service := note.NewService(nr, tr, logger)
+			service := note.NewService(nr, tr, newAudit(client, logger), logger)
 
-			_, err = service.GetOne(testSuite.inID, 1)
+			_, err = service.GetOne(context.Background(), testSuite.inID, 1)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -490,9 +502,9 @@ func TestIntegration_NoteFindByTag(t *testing.T) {
 				t.Fatalf("Can't do pre-test note action: %s", err)
 			}
 
-			service := note.NewService(nr, tr, logger)
+			service := note.NewService(nr, tr, newAudit(client, logger), logger)
 
-			_, err = service.FindByTags(1, []string{"test"})
+			_, err = service.FindByTags(context.Background(), 1, []string{"test"}, false)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -568,12 +580,12 @@ func TestIntegration_NoteUpdate(t *testing.T) {
 				t.Fatalf("Can't do pre-test note action: %s", err)
 			}
 
-			service := note.NewService(nr, tr, logger)
+			service := note.NewService(nr, tr, newAudit(client, logger), logger)
 
 			un := mother.NoteMother()
 			un.ID = testSuite.inID
 
-			err = service.Update(1, un, false)
+			err = service.Update(context.Background(), 1, un, model.NoteUpdateMask{})
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -663,9 +675,9 @@ func TestIntegration_TagCreate(t *testing.T) {
 				t.Fatalf("Can't do pre-test note action: %s", err)
 			}
 
-			service := tag.NewService(tr, nr, logger)
+			service := tag.NewService(tr, nr, newAudit(client, logger), logger)
 
-			_, err = service.Create(testSuite.inUserID, testSuite.inNoteID, &testSuite.inTag)
+			_, err = service.Create(context.Background(), testSuite.inUserID, testSuite.inNoteID, &testSuite.inTag)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -755,9 +767,9 @@ func TestIntegration_TagGetAll(t *testing.T) {
 				t.Fatalf("Can't do pre-test note action: %s", err)
 			}
 
-			service := tag.NewService(tr, nr, logger)
+			service := tag.NewService(tr, nr, newAudit(client, logger), logger)
 
-			_, err = service.GetAll(testSuite.inUserID)
+			_, err = service.GetAll(context.Background(), testSuite.inUserID)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -847,9 +859,9 @@ func TestIntegration_TagGetAllByNote(t *testing.T) {
 				t.Fatalf("Can't do pre-test note action: %s", err)
 			}
 
-			service := tag.NewService(tr, nr, logger)
+			service := tag.NewService(tr, nr, newAudit(client, logger), logger)
 
-			_, err = service.GetAllByNote(testSuite.inUserID, testSuite.inNoteID)
+			_, err = service.GetAllByNote(context.Background(), testSuite.inUserID, testSuite.inNoteID)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -932,9 +944,9 @@ func TestIntegration_TagGetOne(t *testing.T) {
 				t.Fatalf("Can't do pre-test note action: %s", err)
 			}
 
-			service := tag.NewService(tr, nr, logger)
+			service := tag.NewService(tr, nr, newAudit(client, logger), logger)
 
-			_, err = service.GetOne(testSuite.inUserID, testSuite.inTagID)
+			_, err = service.GetOne(context.Background(), testSuite.inUserID, testSuite.inTagID)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -1020,9 +1032,9 @@ func TestIntegration_TagUpdate(t *testing.T) {
 				t.Fatalf("Can't do pre-test note action: %s", err)
 			}
 
-			service := tag.NewService(tr, nr, logger)
+			service := tag.NewService(tr, nr, newAudit(client, logger), logger)
 
-			err = service.Update(testSuite.inUserID, testSuite.inTagID, newTag)
+			err = service.Update(context.Background(), testSuite.inUserID, testSuite.inTagID, newTag)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
@@ -1129,9 +1141,9 @@ func TestIntegration_TagDetach(t *testing.T) {
 				t.Fatalf("Can't do pre-test note action: %s", err)
 			}
 
-			service := tag.NewService(tr, nr, logger)
+			service := tag.NewService(tr, nr, newAudit(client, logger), logger)
 
-			err = service.Detach(testSuite.inUserID, testSuite.inTagID, testSuite.inNoteID)
+			err = service.Detach(context.Background(), testSuite.inUserID, testSuite.inTagID, testSuite.inNoteID)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 
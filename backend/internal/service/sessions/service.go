@@ -0,0 +1,183 @@
+package sessions
+
+import (
+	"context"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/jwt"
+	"neatly/pkg/logging"
+	"neatly/pkg/tracing"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	userID    int
+	checkedAt time.Time
+}
+
+// Auditor appends security relevant events to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
+type Service struct {
+	repository *repository.SessionRepositoryImpl
+	cfg        session.Sessions
+	audit      Auditor
+	logger     logging.Logger
+	now        func() time.Time
+
+	mu        sync.Mutex
+	cache     map[string]cacheEntry
+	lastSweep time.Time
+}
+
+func NewService(repository *repository.SessionRepositoryImpl, cfg session.Sessions, audit Auditor, logger logging.Logger) *Service {
+	return &Service{
+		repository: repository,
+		cfg:        cfg,
+		audit:      audit,
+		logger:     logger,
+		now:        time.Now,
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// Start records new session of client and returns access token bound to it
+func (s *Service) Start(ctx context.Context, userID, version int, client model.Client) (string, error) {
+	ctx, span := tracing.Start(ctx, "sessions.Start")
+	defer span.End()
+
+	return s.start(ctx, userID, version, client, nil)
+}
+
+// Impersonate starts session of account on behalf of admin, such sessions
+// are marked in session list of the account
+func (s *Service) Impersonate(ctx context.Context, adminID, userID, version int, client model.Client) (string, error) {
+	ctx, span := tracing.Start(ctx, "sessions.Impersonate")
+	defer span.End()
+
+	return s.start(ctx, userID, version, client, &adminID)
+}
+
+func (s *Service) start(ctx context.Context, userID, version int, client model.Client, impersonatorID *int) (string, error) {
+	if err := s.repository.DeleteExpired(ctx, userID, s.now()); err != nil {
+		return "", err
+	}
+
+	sess, err := model.NewSession(userID, client, jwt.AccessTokenTTL)
+	if err != nil {
+		return "", err
+	}
+	sess.ImpersonatorID = impersonatorID
+	if err := s.repository.Create(ctx, sess); err != nil {
+		return "", err
+	}
+	s.logger.WithContext(ctx).Infof("Session started for account %v from %v", userID, client.IP)
+
+	var token string
+	if impersonatorID != nil {
+		token, err = jwt.GenerateImpersonationToken(userID, version, sess.ID, *impersonatorID)
+	} else {
+		token, err = jwt.GenerateAccessToken(userID, version, sess.ID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	entry := model.NewAuditEntry(userID, model.AuditTokenCreated, model.AuditTarget("session", sess.ID))
+	entry.ActorID = &userID
+	if impersonatorID != nil {
+		entry.ActorID = impersonatorID
+	}
+	entry.IP = client.IP
+	entry.After = model.AuditSummary{"kind": "access", "user_agent": client.UserAgent, "expires_at": sess.ExpiresAt}
+	s.audit.Record(ctx, entry)
+
+	return token, nil
+}
+
+// GetAll returns active sessions of account, the one with currentID is marked
+func (s *Service) GetAll(ctx context.Context, userID int, currentID string) ([]model.Session, error) {
+	ctx, span := tracing.Start(ctx, "sessions.GetAll")
+	defer span.End()
+
+	sessions, err := s.repository.GetAll(ctx, userID, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+func (s *Service) Revoke(ctx context.Context, userID int, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "sessions.Revoke")
+	defer span.End()
+
+	s.mu.Lock()
+	delete(s.cache, sessionID)
+	s.mu.Unlock()
+
+	if err := s.repository.Delete(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	s.logger.WithContext(ctx).Infof("Session of account %v revoked", userID)
+
+	return nil
+}
+
+// Validate checks that session exists and belongs to account. Successful
+// checks are cached for configured time, last-seen time is updated on
+// every check which hits database.
+func (s *Service) Validate(ctx context.Context, userID int, sessionID string) error {
+	ctx, span := tracing.Start(ctx, "sessions.Validate")
+	defer span.End()
+
+	if sessionID == "" {
+		return e.SessionRevokedError
+	}
+	now := s.now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[sessionID]
+	s.mu.Unlock()
+	if ok && entry.userID == userID && now.Sub(entry.checkedAt) < s.cfg.CacheTTL {
+		return nil
+	}
+
+	active, err := s.repository.Touch(ctx, userID, sessionID, now)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !active {
+		delete(s.cache, sessionID)
+		return e.SessionRevokedError
+	}
+	s.cache[sessionID] = cacheEntry{userID: userID, checkedAt: now}
+	s.sweep(now)
+
+	return nil
+}
+
+// sweep drops outdated cache entries, caller holds the lock
+func (s *Service) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.cfg.CacheTTL {
+		return
+	}
+	for id, entry := range s.cache {
+		if now.Sub(entry.checkedAt) >= s.cfg.CacheTTL {
+			delete(s.cache, id)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,247 @@
+//go:build unit
+// +build unit
+
+package sessions
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/jwt"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"os"
+	"testing"
+	"time"
+)
+
+var testConfig = session.Sessions{CacheTTL: 30 * time.Second}
+
+func newTestService(r *mock.MockSessionRepository, now *time.Time) *Service {
+	logging.Init()
+	s := NewService(&repository.SessionRepositoryImpl{SessionRepository: r}, testConfig, &testutils.Auditor{}, logging.GetLogger())
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestService_Start(t *testing.T) {
+	err := os.Setenv("CONF_FILE", "../etc/test.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	now := time.Now()
+	a := mother.AccountMother()
+	client := model.Client{UserAgent: "Firefox", IP: "10.0.0.1"}
+
+	var created model.Session
+	r := mock.NewMockSessionRepository(c)
+	r.EXPECT().DeleteExpired(gomock.Any(), a.ID, now).Return(nil)
+	r.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s model.Session) error {
+		created = s
+		return nil
+	})
+
+	token, err := newTestService(r, &now).Start(context.Background(), a.ID, a.SessionVersion, client)
+	assert.Equal(t, nil, err)
+
+	claims, err := jwt.ParseAccessToken(token)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, created.ID, claims.SessionID)
+	assert.Equal(t, a.ID, claims.UserID)
+	assert.Equal(t, client.UserAgent, created.UserAgent)
+	assert.Equal(t, client.IP, created.IP)
+	assert.Equal(t, 0, claims.ImpersonatorID)
+
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Impersonate(t *testing.T) {
+	err := os.Setenv("CONF_FILE", "../etc/test.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	now := time.Now()
+	a := mother.AccountMother()
+	adminID := a.ID + 1
+
+	var created model.Session
+	r := mock.NewMockSessionRepository(c)
+	r.EXPECT().DeleteExpired(gomock.Any(), a.ID, now).Return(nil)
+	r.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s model.Session) error {
+		created = s
+		return nil
+	})
+
+	token, err := newTestService(r, &now).Impersonate(context.Background(), adminID, a.ID, a.SessionVersion, model.Client{})
+	assert.Equal(t, nil, err)
+
+	claims, err := jwt.ParseAccessToken(token)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, a.ID, claims.UserID)
+	assert.Equal(t, adminID, claims.ImpersonatorID)
+	assert.Equal(t, adminID, *created.ImpersonatorID)
+
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Validate(t *testing.T) {
+	type sessionRepoMockBehaviour func(r *mock.MockSessionRepository, s model.Session, now time.Time)
+
+	testSession := mother.SessionMother()
+
+	testSuites := []struct {
+		testName          string
+		inUserID          int
+		inSessionID       string
+		sessionBehaviour  sessionRepoMockBehaviour
+		secondCheckAfter  time.Duration
+		ExpectedError     error
+		ExpectedSecondErr error
+	}{
+		{
+			testName:    "CachedWithinTTL",
+			inUserID:    testSession.UserID,
+			inSessionID: testSession.ID,
+			sessionBehaviour: func(r *mock.MockSessionRepository, s model.Session, now time.Time) {
+				r.EXPECT().Touch(gomock.Any(), s.UserID, s.ID, now).Return(true, nil).Times(1)
+			},
+			secondCheckAfter: 10 * time.Second,
+		},
+		{
+			testName:    "CheckedAgainAfterTTL",
+			inUserID:    testSession.UserID,
+			inSessionID: testSession.ID,
+			sessionBehaviour: func(r *mock.MockSessionRepository, s model.Session, now time.Time) {
+				r.EXPECT().Touch(gomock.Any(), s.UserID, s.ID, now).Return(true, nil)
+				r.EXPECT().Touch(gomock.Any(), s.UserID, s.ID, now.Add(time.Minute)).Return(false, nil)
+			},
+			secondCheckAfter:  time.Minute,
+			ExpectedSecondErr: e.SessionRevokedError,
+		},
+		{
+			testName:    "Revoked",
+			inUserID:    testSession.UserID,
+			inSessionID: testSession.ID,
+			sessionBehaviour: func(r *mock.MockSessionRepository, s model.Session, now time.Time) {
+				r.EXPECT().Touch(gomock.Any(), s.UserID, s.ID, now).Return(false, nil).Times(2)
+			},
+			ExpectedError:     e.SessionRevokedError,
+			ExpectedSecondErr: e.SessionRevokedError,
+		},
+		{
+			testName:    "CacheIsPerUser",
+			inUserID:    testSession.UserID + 1,
+			inSessionID: testSession.ID,
+			sessionBehaviour: func(r *mock.MockSessionRepository, s model.Session, now time.Time) {
+				r.EXPECT().Touch(gomock.Any(), s.UserID+1, s.ID, now).Return(false, nil).Times(2)
+			},
+			ExpectedError:     e.SessionRevokedError,
+			ExpectedSecondErr: e.SessionRevokedError,
+		},
+		{
+			testName:          "TokenWithoutSession",
+			inUserID:          testSession.UserID,
+			inSessionID:       "",
+			sessionBehaviour:  func(r *mock.MockSessionRepository, s model.Session, now time.Time) {},
+			ExpectedError:     e.SessionRevokedError,
+			ExpectedSecondErr: e.SessionRevokedError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			now := time.Now()
+			r := mock.NewMockSessionRepository(c)
+			testSuite.sessionBehaviour(r, testSession, now)
+
+			s := newTestService(r, &now)
+
+			err := s.Validate(context.Background(), testSuite.inUserID, testSuite.inSessionID)
+			assert.Equal(t, testSuite.ExpectedError, err)
+
+			now = now.Add(testSuite.secondCheckAfter)
+			err = s.Validate(context.Background(), testSuite.inUserID, testSuite.inSessionID)
+			assert.Equal(t, testSuite.ExpectedSecondErr, err)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Revoke(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	now := time.Now()
+	testSession := mother.SessionMother()
+
+	r := mock.NewMockSessionRepository(c)
+	gomock.InOrder(
+		r.EXPECT().Touch(gomock.Any(), testSession.UserID, testSession.ID, now).Return(true, nil),
+		r.EXPECT().Delete(gomock.Any(), testSession.UserID, testSession.ID).Return(nil),
+		r.EXPECT().Touch(gomock.Any(), testSession.UserID, testSession.ID, now).Return(false, nil),
+		r.EXPECT().Delete(gomock.Any(), testSession.UserID, "unknown").Return(e.ClientSessionError),
+	)
+
+	s := newTestService(r, &now)
+
+	assert.Equal(t, nil, s.Validate(context.Background(), testSession.UserID, testSession.ID))
+	assert.Equal(t, nil, s.Revoke(context.Background(), testSession.UserID, testSession.ID))
+	assert.Equal(t, e.SessionRevokedError, s.Validate(context.Background(), testSession.UserID, testSession.ID))
+	assert.Equal(t, e.ClientSessionError, s.Revoke(context.Background(), testSession.UserID, "unknown"))
+
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_GetAll(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	now := time.Now()
+	current := mother.SessionMother()
+	other := current
+	other.ID = "other-session"
+
+	r := mock.NewMockSessionRepository(c)
+	r.EXPECT().GetAll(gomock.Any(), current.UserID, now).Return([]model.Session{other, current}, nil)
+
+	sessions, err := newTestService(r, &now).GetAll(context.Background(), current.UserID, current.ID)
+
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(sessions))
+	assert.Equal(t, false, sessions[0].Current)
+	assert.Equal(t, true, sessions[1].Current)
+
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,316 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"neatly/internal/mapper"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/jwt"
+	"neatly/pkg/logging"
+	"neatly/pkg/tracing"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxUsernameAttempts = 5
+	defaultUsername     = "user"
+)
+
+var usernameCleaner = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// SessionStarter records login of client and issues access token bound to
+// the new session
+type SessionStarter interface {
+	Start(ctx context.Context, userID, version int, client model.Client) (string, error)
+}
+
+// Auditor appends security relevant events to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
+type Service struct {
+	accountsRepository *repository.AccountRepositoryImpl
+	oidcRepository     *repository.OIDCRepositoryImpl
+	sessions           SessionStarter
+	cfg                session.OIDC
+	audit              Auditor
+	logger             logging.Logger
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func NewService(accountsRepository *repository.AccountRepositoryImpl, oidcRepository *repository.OIDCRepositoryImpl,
+	sessions SessionStarter, cfg session.OIDC, audit Auditor, logger logging.Logger) *Service {
+	return &Service{
+		accountsRepository: accountsRepository,
+		oidcRepository:     oidcRepository,
+		sessions:           sessions,
+		cfg:                cfg,
+		audit:              audit,
+		logger:             logger,
+	}
+}
+
+// provider discovers issuer on first use, so backend starts even if
+// provider is temporarily down. Failed discovery is retried next time.
+func (s *Service) provider(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.oauth2 != nil {
+		return s.oauth2, s.verifier, nil
+	}
+
+	p, err := oidc.NewProvider(ctx, s.cfg.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't discover OIDC provider: %w", err)
+	}
+
+	s.oauth2 = &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		RedirectURL:  s.cfg.RedirectURL,
+		Endpoint:     p.Endpoint(),
+		Scopes:       s.cfg.Scopes,
+	}
+	s.verifier = p.Verifier(&oidc.Config{ClientID: s.cfg.ClientID})
+
+	return s.oauth2, s.verifier, nil
+}
+
+// AuthURL starts authorization code flow with PKCE and returns provider URL
+// user has to be redirected to, along with state of the flow to be bound
+// to the browser
+func (s *Service) AuthURL(ctx context.Context) (string, string, error) {
+	ctx, span := tracing.Start(ctx, "sso.AuthURL")
+	defer span.End()
+
+	config, _, err := s.provider(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	f, err := model.NewOIDCFlow(s.cfg.FlowTTL)
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.oidcRepository.CreateFlow(ctx, f); err != nil {
+		return "", "", err
+	}
+
+	return config.AuthCodeURL(f.State,
+		oidc.Nonce(f.Nonce),
+		oauth2.SetAuthURLParam("code_challenge", f.Challenge()),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), f.State, nil
+}
+
+// Callback finishes flow started by AuthURL. Account is found by linked
+// identity, then by verified email, otherwise a new one is provisioned.
+// Returns access token, or challenge token if account has 2FA enabled.
+func (s *Service) Callback(ctx context.Context, state, code string, client model.Client) (string, bool, error) {
+	ctx, span := tracing.Start(ctx, "sso.Callback")
+	defer span.End()
+
+	config, verifier, err := s.provider(ctx)
+	if err != nil {
+		return "", false, err
+	}
+
+	f, err := s.oidcRepository.ConsumeFlow(ctx, state)
+	if err != nil {
+		return "", false, err
+	}
+
+	token, err := config.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", f.Verifier))
+	if err != nil {
+		s.logger.WithContext(ctx).Infof("Can't exchange OIDC code: %v", err)
+		return "", false, e.ClientOIDCError
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		s.logger.WithContext(ctx).Info("OIDC token response has no id_token")
+		return "", false, e.ClientOIDCError
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		s.logger.WithContext(ctx).Infof("Can't verify ID token: %v", err)
+		return "", false, e.ClientOIDCError
+	}
+	if idToken.Nonce != f.Nonce {
+		s.logger.WithContext(ctx).Info("ID token nonce does not match")
+		return "", false, e.ClientOIDCError
+	}
+
+	var claims model.OIDCClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return "", false, err
+	}
+
+	userID, err := s.resolve(ctx, idToken.Issuer, claims)
+	if err != nil {
+		return "", false, err
+	}
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return "", false, err
+	}
+	if a.Disabled {
+		s.recordLogin(ctx, a, model.AuditLoginFailed, model.AuditSummary{"reason": "disabled"})
+		return "", false, e.AccountDisabledError
+	}
+	if a.DeleteAfter != nil {
+		if err := s.accountsRepository.CancelDeletion(ctx, a.ID); err != nil {
+			return "", false, err
+		}
+		s.logger.WithContext(ctx).Infof("Deletion of account %v cancelled by login", a.ID)
+		s.recordCancelledDeletion(ctx, a.ID, *a.DeleteAfter)
+	}
+
+	if a.TOTPEnabled {
+		challenge, err := jwt.GenerateChallengeToken(a.ID, a.SessionVersion)
+		return challenge, true, err
+	}
+	access, err := s.sessions.Start(ctx, a.ID, a.SessionVersion, client)
+	if err != nil {
+		return "", false, err
+	}
+	s.recordLogin(ctx, a, model.AuditLoginSucceeded, model.AuditSummary{"method": "oidc", "issuer": idToken.Issuer})
+
+	return access, false, nil
+}
+
+// recordLogin audits login attempt, account is its actor only on success
+func (s *Service) recordLogin(ctx context.Context, a model.Account, action model.AuditAction, summary model.AuditSummary) {
+	entry := model.NewAuditEntry(a.ID, action, model.AuditTarget("username", a.Username))
+	if action == model.AuditLoginSucceeded {
+		entry.ActorID = &a.ID
+	}
+	entry.After = summary
+	s.audit.Record(ctx, entry)
+}
+
+// recordCancelledDeletion audits deletion cancelled by login through
+// provider, account is its actor
+func (s *Service) recordCancelledDeletion(ctx context.Context, userID int, deleteAfter time.Time) {
+	entry := model.NewAuditEntry(userID, model.AuditDeletionCancelled, model.AuditTarget("user", userID))
+	entry.ActorID = &userID
+	entry.Before = model.AuditSummary{"delete_after": deleteAfter}
+	s.audit.Record(ctx, entry)
+}
+
+func (s *Service) resolve(ctx context.Context, issuer string, claims model.OIDCClaims) (int, error) {
+	userID, err := s.oidcRepository.FindUser(ctx, issuer, claims.Subject)
+	if err != nil || userID != 0 {
+		return userID, err
+	}
+
+	email, emailErr := mapper.NormaliseEmail(claims.Email)
+
+	if claims.EmailVerified && emailErr == nil {
+		accounts, err := s.accountsRepository.GetByEmail(ctx, email)
+		if err != nil {
+			return 0, err
+		}
+
+		verified := make([]model.Account, 0, 1)
+		for _, a := range accounts {
+			if a.Verified {
+				verified = append(verified, a)
+			}
+		}
+		if len(verified) == 1 {
+			userID = verified[0].ID
+			s.logger.WithContext(ctx).Infof("Identity %v of %v linked to account %v by email", claims.Subject, issuer, userID)
+			return userID, s.oidcRepository.Link(ctx, userID, issuer, claims.Subject)
+		}
+	}
+
+	if emailErr != nil {
+		email = ""
+	}
+	userID, err = s.provision(ctx, claims, email)
+	if err != nil {
+		return 0, err
+	}
+	s.logger.WithContext(ctx).Infof("Account %v provisioned for identity %v of %v", userID, claims.Subject, issuer)
+
+	return userID, s.oidcRepository.Link(ctx, userID, issuer, claims.Subject)
+}
+
+// provision creates account with unusable random password, user can set
+// one later with password reset
+func (s *Service) provision(ctx context.Context, claims model.OIDCClaims, email string) (int, error) {
+	password, err := model.RandomString()
+	if err != nil {
+		return 0, err
+	}
+	phash, err := model.GeneratePasswordHash(password)
+	if err != nil {
+		return 0, err
+	}
+
+	base := usernameBase(claims, email)
+	a := model.Account{
+		Name:         claims.Name,
+		Email:        email,
+		PasswordHash: phash,
+	}
+	if a.Name == "" {
+		a.Name = base
+	}
+
+	for i := 0; i < maxUsernameAttempts; i++ {
+		a.Username = base
+		if i > 0 {
+			suffix, err := model.RandomString()
+			if err != nil {
+				return 0, err
+			}
+			a.Username = fmt.Sprintf("%s-%s", base, strings.ToLower(suffix[:6]))
+		}
+
+		err = s.accountsRepository.CreateAccount(ctx, &a)
+		if errors.Is(err, e.ClientAccountError) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if claims.EmailVerified && email != "" {
+			if err := s.accountsRepository.SetVerified(ctx, a.ID, true); err != nil {
+				return 0, err
+			}
+		}
+		return a.ID, nil
+	}
+
+	return 0, e.ClientAccountError
+}
+
+func usernameBase(claims model.OIDCClaims, email string) string {
+	candidate := claims.PreferredUsername
+	if candidate == "" && email != "" {
+		candidate = email[:strings.Index(email, "@")]
+	}
+
+	candidate = usernameCleaner.ReplaceAllString(candidate, "")
+	if candidate == "" {
+		return defaultUsername
+	}
+	return candidate
+}
@@ -0,0 +1,355 @@
+//go:build unit
+// +build unit
+
+package sso
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"math/big"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/jwt"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID = "neatly"
+	testCode     = "code"
+	testKeyID    = "test"
+)
+
+// testProvider is a minimal OIDC provider issuing ID token with given claims
+type testProvider struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	claims   model.OIDCClaims
+	nonce    string
+	t        *testing.T
+	verifier string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &testProvider{key: key, t: t}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/keys", p.keys)
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+
+	return p
+}
+
+func (p *testProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	p.writeJSON(w, map[string]interface{}{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/keys",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *testProvider) keys(w http.ResponseWriter, _ *http.Request) {
+	p.writeJSON(w, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": testKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *testProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		p.t.Fatal(err)
+	}
+	p.verifier = r.PostForm.Get("code_verifier")
+	if r.PostForm.Get("code") != testCode {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	p.writeJSON(w, map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     p.idToken(),
+	})
+}
+
+func (p *testProvider) idToken() string {
+	now := time.Now()
+	payload := map[string]interface{}{
+		"iss":                p.server.URL,
+		"aud":                testClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"nonce":              p.nonce,
+		"sub":                p.claims.Subject,
+		"email":              p.claims.Email,
+		"email_verified":     p.claims.EmailVerified,
+		"name":               p.claims.Name,
+		"preferred_username": p.claims.PreferredUsername,
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": testKeyID})
+	body, _ := json.Marshal(payload)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, sum[:])
+	if err != nil {
+		p.t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (p *testProvider) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func TestService_Callback(t *testing.T) {
+	type accountRepoMockBehaviour func(r *mock.MockAccountRepository, a model.Account)
+	type oidcRepoMockBehaviour func(r *mock.MockOIDCRepository, f model.OIDCFlow, a model.Account)
+
+	err := os.Setenv("CONF_FILE", "../etc/test.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := newTestProvider(t)
+	defer p.server.Close()
+
+	flow, err := model.NewOIDCFlow(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := model.OIDCClaims{
+		Subject:           "subject",
+		Email:             "Test@Example.com",
+		EmailVerified:     true,
+		Name:              "Test",
+		PreferredUsername: "test",
+	}
+	unverifiedClaims := claims
+	unverifiedClaims.EmailVerified = false
+
+	account := mother.AccountMother()
+	account.ID = 1
+	account.Email = "test@example.com"
+	account.Verified = true
+
+	withTwoFactor := account
+	withTwoFactor.TOTPEnabled = true
+
+	testSuites := []struct {
+		testName          string
+		inState           string
+		inCode            string
+		claims            model.OIDCClaims
+		nonce             string
+		account           model.Account
+		accountBehaviour  accountRepoMockBehaviour
+		oidcBehaviour     oidcRepoMockBehaviour
+		expectedTwoFactor bool
+		ExpectedError     error
+	}{
+		{
+			testName: "LinkedIdentity",
+			inState:  flow.State,
+			inCode:   testCode,
+			claims:   claims,
+			nonce:    flow.Nonce,
+			account:  account,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			oidcBehaviour: func(r *mock.MockOIDCRepository, f model.OIDCFlow, a model.Account) {
+				r.EXPECT().ConsumeFlow(gomock.Any(), f.State).Return(f, nil)
+				r.EXPECT().FindUser(gomock.Any(), gomock.Any(), claims.Subject).Return(a.ID, nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName: "LinkedByVerifiedEmail",
+			inState:  flow.State,
+			inCode:   testCode,
+			claims:   claims,
+			nonce:    flow.Nonce,
+			account:  account,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetByEmail(gomock.Any(), a.Email).Return([]model.Account{a}, nil)
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			oidcBehaviour: func(r *mock.MockOIDCRepository, f model.OIDCFlow, a model.Account) {
+				r.EXPECT().ConsumeFlow(gomock.Any(), f.State).Return(f, nil)
+				r.EXPECT().FindUser(gomock.Any(), gomock.Any(), claims.Subject).Return(0, nil)
+				r.EXPECT().Link(gomock.Any(), a.ID, gomock.Any(), claims.Subject).Return(nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName: "ProvisionedWhenEmailNotVerified",
+			inState:  flow.State,
+			inCode:   testCode,
+			claims:   unverifiedClaims,
+			nonce:    flow.Nonce,
+			account:  account,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				gomock.InOrder(
+					r.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Return(e.ClientAccountError),
+					r.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, created *model.Account) error {
+						assert.NotEqual(t, created.Username, claims.PreferredUsername)
+						assert.Equal(t, created.Email, a.Email)
+						created.ID = a.ID
+						return nil
+					}),
+				)
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			oidcBehaviour: func(r *mock.MockOIDCRepository, f model.OIDCFlow, a model.Account) {
+				r.EXPECT().ConsumeFlow(gomock.Any(), f.State).Return(f, nil)
+				r.EXPECT().FindUser(gomock.Any(), gomock.Any(), claims.Subject).Return(0, nil)
+				r.EXPECT().Link(gomock.Any(), a.ID, gomock.Any(), claims.Subject).Return(nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName: "TwoFactorChallenge",
+			inState:  flow.State,
+			inCode:   testCode,
+			claims:   claims,
+			nonce:    flow.Nonce,
+			account:  withTwoFactor,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			oidcBehaviour: func(r *mock.MockOIDCRepository, f model.OIDCFlow, a model.Account) {
+				r.EXPECT().ConsumeFlow(gomock.Any(), f.State).Return(f, nil)
+				r.EXPECT().FindUser(gomock.Any(), gomock.Any(), claims.Subject).Return(a.ID, nil)
+			},
+			expectedTwoFactor: true,
+			ExpectedError:     nil,
+		},
+		{
+			testName:         "UnknownState",
+			inState:          "unknown",
+			inCode:           testCode,
+			claims:           claims,
+			nonce:            flow.Nonce,
+			account:          account,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {},
+			oidcBehaviour: func(r *mock.MockOIDCRepository, f model.OIDCFlow, a model.Account) {
+				r.EXPECT().ConsumeFlow(gomock.Any(), "unknown").Return(model.OIDCFlow{}, e.ClientOIDCError)
+			},
+			ExpectedError: e.ClientOIDCError,
+		},
+		{
+			testName:         "NonceMismatch",
+			inState:          flow.State,
+			inCode:           testCode,
+			claims:           claims,
+			nonce:            "replayed",
+			account:          account,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {},
+			oidcBehaviour: func(r *mock.MockOIDCRepository, f model.OIDCFlow, a model.Account) {
+				r.EXPECT().ConsumeFlow(gomock.Any(), f.State).Return(f, nil)
+			},
+			ExpectedError: e.ClientOIDCError,
+		},
+		{
+			testName:         "InvalidCode",
+			inState:          flow.State,
+			inCode:           "invalid",
+			claims:           claims,
+			nonce:            flow.Nonce,
+			account:          account,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {},
+			oidcBehaviour: func(r *mock.MockOIDCRepository, f model.OIDCFlow, a model.Account) {
+				r.EXPECT().ConsumeFlow(gomock.Any(), f.State).Return(f, nil)
+			},
+			ExpectedError: e.ClientOIDCError,
+		},
+	}
+
+	for _, test := range testSuites {
+		t.Run(test.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			accountRepo := mock.NewMockAccountRepository(c)
+			oidcRepo := mock.NewMockOIDCRepository(c)
+			test.accountBehaviour(accountRepo, test.account)
+			test.oidcBehaviour(oidcRepo, flow, test.account)
+
+			p.claims = test.claims
+			p.nonce = test.nonce
+			p.verifier = ""
+
+			logging.Init()
+			s := NewService(
+				&repository.AccountRepositoryImpl{AccountRepository: accountRepo},
+				&repository.OIDCRepositoryImpl{OIDCRepository: oidcRepo},
+				testutils.SessionStarter{},
+				session.OIDC{Issuer: p.server.URL, ClientID: testClientID, Scopes: []string{"openid"}},
+				&testutils.Auditor{},
+				logging.GetLogger())
+
+			token, twoFactor, err := s.Callback(context.Background(), test.inState, test.inCode, model.Client{})
+
+			assert.Equal(t, test.ExpectedError, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, flow.Verifier, p.verifier)
+			assert.Equal(t, test.expectedTwoFactor, twoFactor)
+
+			if twoFactor {
+				claims, err := jwt.ParseChallengeToken(token)
+				assert.Equal(t, nil, err)
+				assert.Equal(t, test.account.ID, claims.UserID)
+			} else {
+				id, err := jwt.GetIdFromToken(token)
+				assert.Equal(t, nil, err)
+				assert.Equal(t, test.account.ID, id)
+			}
+		})
+	}
+
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
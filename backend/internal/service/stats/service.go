@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"context"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/pkg/logging"
+	"neatly/pkg/tracing"
+)
+
+type Service struct {
+	statsRepository *repository.StatsRepositoryImpl
+	tagsRepository  *repository.TagRepositoryImpl
+	logger          logging.Logger
+}
+
+func NewService(statsRepository *repository.StatsRepositoryImpl, tagsRepository *repository.TagRepositoryImpl,
+	logger logging.Logger) *Service {
+	return &Service{statsRepository: statsRepository, tagsRepository: tagsRepository, logger: logger}
+}
+
+// Get collects statistics of user notes, days and limit are clamped to
+// allowed bounds
+func (s *Service) Get(ctx context.Context, userID, days, limit int) (model.Stats, error) {
+	ctx, span := tracing.Start(ctx, "stats.Get")
+	defer span.End()
+
+	var (
+		st  model.Stats
+		err error
+	)
+
+	days = clamp(days, model.DefaultStatsDays, model.MaxStatsDays)
+	limit = clamp(limit, model.DefaultStatsLimit, model.MaxStatsLimit)
+
+	st.NoteTotals, err = s.statsRepository.GetTotals(ctx, userID)
+	if err != nil {
+		return model.Stats{}, err
+	}
+
+	st.EditedPerDay, err = s.statsRepository.GetEditedPerDay(ctx, userID, days)
+	if err != nil {
+		return model.Stats{}, err
+	}
+
+	st.TopTags, err = s.statsRepository.GetTopTags(ctx, userID, limit)
+	if err != nil {
+		return model.Stats{}, err
+	}
+
+	st.Colors, err = s.statsRepository.GetColors(ctx, userID)
+	if err != nil {
+		return model.Stats{}, err
+	}
+
+	st.LastEdited, err = s.statsRepository.GetLastEdited(ctx, userID, limit)
+	if err != nil {
+		return model.Stats{}, err
+	}
+	for i := range st.LastEdited {
+		st.LastEdited[i].Tags, err = s.tagsRepository.GetAllByNote(ctx, userID, st.LastEdited[i].ID)
+		if err != nil {
+			return model.Stats{}, err
+		}
+	}
+
+	s.logger.WithContext(ctx).Infof("Collected stats of user %v over %v days", userID, days)
+	return st, nil
+}
+
+func clamp(v, def, max int) int {
+	switch {
+	case v <= 0:
+		return def
+	case v > max:
+		return max
+	default:
+		return v
+	}
+}
@@ -0,0 +1,123 @@
+//go:build unit
+// +build unit
+
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"github.com/go-playground/assert/v2"
+	"github.com/go-test/deep"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"testing"
+)
+
+func TestService_Get(t *testing.T) {
+	type statsRepoMockBehaviour func(r *mock.MockStatsRepository, userID int)
+	type tagRepoMockBehaviour func(r *mock.MockTagRepository, userID int)
+
+	totals := model.NoteTotals{Notes: 2, Words: 10, Characters: 50}
+	testNote := model.Note{ID: 1, Header: "header"}
+	testTag := model.Tag{ID: 1, Label: "work"}
+	taggedNote := testNote
+	taggedNote.Tags = []model.Tag{testTag}
+	untaggedNote := model.Note{ID: 2, Header: "header", Tags: []model.Tag{}}
+
+	testSuites := []struct {
+		testName       string
+		inDays         int
+		inLimit        int
+		statsBehaviour statsRepoMockBehaviour
+		tagsBehaviour  tagRepoMockBehaviour
+		outTotals      model.NoteTotals
+		outLastEdited  []model.Note
+		ExpectedError  error
+	}{
+		{
+			testName: "DefaultBounds",
+			inDays:   0,
+			inLimit:  0,
+			statsBehaviour: func(r *mock.MockStatsRepository, userID int) {
+				r.EXPECT().GetTotals(gomock.Any(), userID).Return(totals, nil)
+				r.EXPECT().GetEditedPerDay(gomock.Any(), userID, model.DefaultStatsDays).Return([]model.DayActivity{}, nil)
+				r.EXPECT().GetTopTags(gomock.Any(), userID, model.DefaultStatsLimit).Return([]model.TagUsage{}, nil)
+				r.EXPECT().GetColors(gomock.Any(), userID).Return([]model.ColorUsage{}, nil)
+				r.EXPECT().GetLastEdited(gomock.Any(), userID, model.DefaultStatsLimit).Return([]model.Note{testNote, {ID: 2, Header: "header"}}, nil)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository, userID int) {
+				r.EXPECT().GetAllByNote(gomock.Any(), userID, 1).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), userID, 2).Return([]model.Tag{}, nil)
+			},
+			outTotals:     totals,
+			outLastEdited: []model.Note{taggedNote, untaggedNote},
+			ExpectedError: nil,
+		},
+		{
+			testName: "BoundsAreClamped",
+			inDays:   10000,
+			inLimit:  10000,
+			statsBehaviour: func(r *mock.MockStatsRepository, userID int) {
+				r.EXPECT().GetTotals(gomock.Any(), userID).Return(totals, nil)
+				r.EXPECT().GetEditedPerDay(gomock.Any(), userID, model.MaxStatsDays).Return([]model.DayActivity{}, nil)
+				r.EXPECT().GetTopTags(gomock.Any(), userID, model.MaxStatsLimit).Return([]model.TagUsage{}, nil)
+				r.EXPECT().GetColors(gomock.Any(), userID).Return([]model.ColorUsage{}, nil)
+				r.EXPECT().GetLastEdited(gomock.Any(), userID, model.MaxStatsLimit).Return([]model.Note{}, nil)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository, userID int) {},
+			outTotals:     totals,
+			outLastEdited: []model.Note{},
+			ExpectedError: nil,
+		},
+		{
+			testName: "RepositoryError",
+			inDays:   7,
+			inLimit:  3,
+			statsBehaviour: func(r *mock.MockStatsRepository, userID int) {
+				r.EXPECT().GetTotals(gomock.Any(), userID).Return(totals, nil)
+				r.EXPECT().GetEditedPerDay(gomock.Any(), userID, 7).Return(nil, sql.ErrConnDone)
+				r.EXPECT().GetTopTags(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			tagsBehaviour: func(r *mock.MockTagRepository, userID int) {},
+			outTotals:     model.NoteTotals{},
+			ExpectedError: sql.ErrConnDone,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoMock := mock.NewMockStatsRepository(c)
+			tagRepoMock := mock.NewMockTagRepository(c)
+			testSuite.statsBehaviour(repoMock, 0)
+			testSuite.tagsBehaviour(tagRepoMock, 0)
+
+			logging.Init()
+			repo := &repository.StatsRepositoryImpl{
+				StatsRepository: repoMock,
+			}
+			tagRepo := &repository.TagRepositoryImpl{
+				TagRepository: tagRepoMock,
+			}
+			mockService := NewService(repo, tagRepo, logging.GetLogger())
+
+			got, err := mockService.Get(context.Background(), 0, testSuite.inDays, testSuite.inLimit)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.outTotals, got.NoteTotals)
+			if diff := deep.Equal(testSuite.outLastEdited, got.LastEdited); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,87 +1,124 @@
 package tag
 
 import (
+	"context"
 	"neatly/internal/model"
 	"neatly/internal/repository"
 	"neatly/pkg/e"
 	"neatly/pkg/logging"
+	"neatly/pkg/tracing"
 	"strings"
 )
 
+// Auditor appends changes of tags to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
 type Service struct {
 	tagsRepository  *repository.TagRepositoryImpl
 	notesRepository *repository.NoteRepositoryImpl
+	audit           Auditor
 	logger          logging.Logger
 }
 
-func NewService(tagsRepository *repository.TagRepositoryImpl, notesRepository *repository.NoteRepositoryImpl, logger logging.Logger) *Service {
-	return &Service{tagsRepository: tagsRepository, notesRepository: notesRepository, logger: logger}
+func NewService(tagsRepository *repository.TagRepositoryImpl, notesRepository *repository.NoteRepositoryImpl, audit Auditor,
+	logger logging.Logger) *Service {
+	return &Service{tagsRepository: tagsRepository, notesRepository: notesRepository, audit: audit, logger: logger}
 }
 
-func (s *Service) Create(userID, noteID int, t *model.Tag) (bool, error) {
-	_, err := s.notesRepository.GetOne(userID, noteID)
+func (s *Service) Create(ctx context.Context, userID, noteID int, t *model.Tag) (bool, error) {
+	ctx, span := tracing.Start(ctx, "tag.Create")
+	defer span.End()
+
+	_, err := s.notesRepository.GetOne(ctx, userID, noteID)
 	if err != nil {
 		return false, e.ClientNoteError
 	}
 
-	tags, err := s.tagsRepository.GetAll(userID)
+	tags, err := s.tagsRepository.GetAll(ctx, userID)
 	if err != nil {
 		return false, err
 	}
 
 	modified := false
-	unique, tuID := s.checkIfUnique(tags, *t)
+	unique, tuID := s.checkIfUnique(ctx, tags, *t)
 	if !unique {
-		s.logger.Infof("Tag with ID %v is not unique", tuID)
-		assigned, err := s.checkIfAssigned(tuID, noteID, userID)
+		s.logger.WithContext(ctx).Infof("Tag with ID %v is not unique", tuID)
+		assigned, err := s.checkIfAssigned(ctx, tuID, noteID, userID)
 		if err != nil {
 			return modified, err
 		}
 		if !assigned {
 			modified = true
-			s.logger.Infof("Tag with ID %v is not assigned to note %v", tuID, noteID)
+			s.logger.WithContext(ctx).Infof("Tag with ID %v is not assigned to note %v", tuID, noteID)
 			t.ID = tuID
-			err := s.tagsRepository.Assign(tuID, noteID, userID)
+			err := s.tagsRepository.Assign(ctx, tuID, noteID, userID)
 			return modified, err
 		}
 		t.ID = tuID
 		return modified, nil
 	}
 
-	s.logger.Infof("Tag with ID %v is inuque and will be assigned to note with ID %v", t.ID, noteID)
+	s.logger.WithContext(ctx).Infof("Tag with ID %v is inuque and will be assigned to note with ID %v", t.ID, noteID)
 	modified = true
-	err = s.tagsRepository.Create(userID, noteID, t)
+	err = s.tagsRepository.Create(ctx, userID, noteID, t)
 	if err != nil {
 		return false, err
 	}
-	err = s.tagsRepository.Assign(t.ID, noteID, userID)
+	s.record(ctx, userID, model.AuditTagCreated, t.ID, nil, t.AuditSummary())
+	err = s.tagsRepository.Assign(ctx, t.ID, noteID, userID)
 	return modified, err
 }
 
-func (s *Service) GetAll(userID int) ([]model.Tag, error) {
-	return s.tagsRepository.GetAll(userID)
+func (s *Service) GetAll(ctx context.Context, userID int) ([]model.Tag, error) {
+	ctx, span := tracing.Start(ctx, "tag.GetAll")
+	defer span.End()
+
+	return s.tagsRepository.GetAll(ctx, userID)
 }
 
-func (s *Service) GetAllByNote(userID, noteID int) ([]model.Tag, error) {
-	_, err := s.notesRepository.GetOne(userID, noteID)
+func (s *Service) GetAllByNote(ctx context.Context, userID, noteID int) ([]model.Tag, error) {
+	ctx, span := tracing.Start(ctx, "tag.GetAllByNote")
+	defer span.End()
+
+	_, err := s.notesRepository.GetOne(ctx, userID, noteID)
 
 	if err != nil {
 		return []model.Tag{}, e.ClientNoteError
 	}
 
-	return s.tagsRepository.GetAllByNote(userID, noteID)
+	return s.tagsRepository.GetAllByNote(ctx, userID, noteID)
 }
 
-func (s *Service) GetOne(userID, tagID int) (model.Tag, error) {
-	return s.tagsRepository.GetOne(userID, tagID)
+func (s *Service) GetOne(ctx context.Context, userID, tagID int) (model.Tag, error) {
+	ctx, span := tracing.Start(ctx, "tag.GetOne")
+	defer span.End()
+
+	return s.tagsRepository.GetOne(ctx, userID, tagID)
 }
 
-func (s *Service) Delete(userID, tagID int) error {
-	return s.tagsRepository.Delete(userID, tagID)
+func (s *Service) Delete(ctx context.Context, userID, tagID int) error {
+	ctx, span := tracing.Start(ctx, "tag.Delete")
+	defer span.End()
+
+	t, err := s.tagsRepository.GetOne(ctx, userID, tagID)
+	if err != nil {
+		return e.ClientTagError
+	}
+	if err := s.tagsRepository.Delete(ctx, userID, tagID); err != nil {
+		return err
+	}
+	s.record(ctx, userID, model.AuditTagDeleted, tagID, t.AuditSummary(), nil)
+
+	return nil
 }
 
-func (s *Service) Update(userID, tagID int, t model.Tag) error {
-	tp, err := s.tagsRepository.GetOne(userID, tagID)
+func (s *Service) Update(ctx context.Context, userID, tagID int, t model.Tag) error {
+	ctx, span := tracing.Start(ctx, "tag.Update")
+	defer span.End()
+
+	tp, err := s.tagsRepository.GetOne(ctx, userID, tagID)
 	if err != nil {
 		return e.ClientTagError
 	}
@@ -90,20 +127,28 @@ func (s *Service) Update(userID, tagID int, t model.Tag) error {
 		t.Label = tp.Label
 	}
 
-	return s.tagsRepository.Update(userID, tagID, t)
+	if err := s.tagsRepository.Update(ctx, userID, tagID, t); err != nil {
+		return err
+	}
+	s.record(ctx, userID, model.AuditTagUpdated, tagID, tp.AuditSummary(), t.AuditSummary())
+
+	return nil
 }
 
-func (s *Service) Detach(userID, tagID, noteID int) error {
-	inNote, err := s.notesRepository.GetOne(userID, noteID)
+func (s *Service) Detach(ctx context.Context, userID, tagID, noteID int) error {
+	ctx, span := tracing.Start(ctx, "tag.Detach")
+	defer span.End()
+
+	inNote, err := s.notesRepository.GetOne(ctx, userID, noteID)
 	if err != nil {
 		return e.ClientNoteError
 	}
-	inTag, err := s.tagsRepository.GetOne(userID, tagID)
+	inTag, err := s.tagsRepository.GetOne(ctx, userID, tagID)
 	if err != nil {
 		return e.ClientTagError
 	}
 
-	inNote.Tags, err = s.tagsRepository.GetAllByNote(userID, noteID)
+	inNote.Tags, err = s.tagsRepository.GetAllByNote(ctx, userID, noteID)
 	if err != nil {
 		return err
 	}
@@ -113,20 +158,20 @@ func (s *Service) Detach(userID, tagID, noteID int) error {
 	)
 
 	if inNote.HasSpecificTag(inTag.Label) {
-		s.logger.Info("Detaching tag...")
-		err = s.tagsRepository.Detach(userID, tagID, noteID)
+		s.logger.WithContext(ctx).Info("Detaching tag...")
+		err = s.tagsRepository.Detach(ctx, userID, tagID, noteID)
 	} else {
-		s.logger.Info("Tag is not attached to this note.")
+		s.logger.WithContext(ctx).Info("Tag is not attached to this note.")
 		return nil
 	}
 
-	ns, err := s.notesRepository.GetAll(userID)
+	ns, err := s.notesRepository.GetAll(ctx, userID)
 	if err != nil {
 		return err
 	}
 	for _, n := range ns {
 		if n.ID != noteID {
-			n.Tags, err = s.tagsRepository.GetAllByNote(userID, n.ID)
+			n.Tags, err = s.tagsRepository.GetAllByNote(ctx, userID, n.ID)
 			if n.HasSpecificTag(inTag.Label) {
 				attachedToMany = true
 			}
@@ -134,32 +179,41 @@ func (s *Service) Detach(userID, tagID, noteID int) error {
 	}
 
 	if !attachedToMany {
-		s.logger.Info("Tag is attached to one note and should be deleted.")
-		err = s.tagsRepository.Delete(userID, tagID)
-		return err
+		s.logger.WithContext(ctx).Info("Tag is attached to one note and should be deleted.")
+		if err := s.tagsRepository.Delete(ctx, userID, tagID); err != nil {
+			return err
+		}
+		s.record(ctx, userID, model.AuditTagDeleted, tagID, inTag.AuditSummary(), nil)
 	}
 	return nil
 }
 
-func (s *Service) checkIfUnique(tags []model.Tag, tu model.Tag) (bool, int) {
+func (s *Service) record(ctx context.Context, userID int, action model.AuditAction, tagID int, before, after model.AuditSummary) {
+	entry := model.NewAuditEntry(userID, action, model.AuditTarget("tag", tagID))
+	entry.Before = before
+	entry.After = after
+	s.audit.Record(ctx, entry)
+}
+
+func (s *Service) checkIfUnique(ctx context.Context, tags []model.Tag, tu model.Tag) (bool, int) {
 	for _, t := range tags {
 		if strings.Compare(t.Label, tu.Label) == 0 {
-			s.logger.Infof("Found matching tag with id %v", t.ID)
+			s.logger.WithContext(ctx).Infof("Found matching tag with id %v", t.ID)
 			return false, t.ID
 		}
 	}
 	return true, 0
 }
 
-func (s *Service) checkIfAssigned(tagID, noteID, userID int) (bool, error) {
-	tags, err := s.tagsRepository.GetAllByNote(userID, noteID)
+func (s *Service) checkIfAssigned(ctx context.Context, tagID, noteID, userID int) (bool, error) {
+	tags, err := s.tagsRepository.GetAllByNote(ctx, userID, noteID)
 	if err != nil {
 		return false, err
 	}
 
 	for _, t := range tags {
 		if t.ID == tagID {
-			s.logger.Infof("Found matching tag %v assigned to note %v", tagID, noteID)
+			s.logger.WithContext(ctx).Infof("Found matching tag %v assigned to note %v", tagID, noteID)
 			return true, nil
 		}
 	}
@@ -4,6 +4,7 @@
 package tag
 
 import (
+	"context"
 	"github.com/go-playground/assert/v2"
 	"github.com/golang/mock/gomock"
 	"neatly/internal/model"
@@ -41,13 +42,13 @@ func TestService_Create(t *testing.T) {
 			inUserID: 0,
 			inNoteID: 0,
 			notesRepoBehaviour: func(r *mock.MockNoteRepository, UserID, NoteID int) {
-				r.EXPECT().GetOne(UserID, NoteID).Return(testNote, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, NoteID).Return(testNote, nil)
 			},
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Tag{testTag}, nil)
-				r.EXPECT().GetAllByNote(UserID, NoteID).Times(0)
-				r.EXPECT().Assign(0, NoteID, UserID).Return(nil)
-				r.EXPECT().Create(UserID, NoteID, &testTagUnique).Return(nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Times(0)
+				r.EXPECT().Assign(gomock.Any(), 0, NoteID, UserID).Return(nil)
+				r.EXPECT().Create(gomock.Any(), UserID, NoteID, &testTagUnique).Return(nil)
 			},
 			inTag:         testTagUnique,
 			ExpectedError: nil,
@@ -57,13 +58,13 @@ func TestService_Create(t *testing.T) {
 			inUserID: 0,
 			inNoteID: 0,
 			notesRepoBehaviour: func(r *mock.MockNoteRepository, UserID, NoteID int) {
-				r.EXPECT().GetOne(UserID, NoteID).Return(testNote, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, NoteID).Return(testNote, nil)
 			},
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Tag{testTag}, nil)
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{}, nil)
-				r.EXPECT().Assign(0, NoteID, UserID).Return(nil)
-				r.EXPECT().Create(UserID, NoteID, &testTag).Times(0)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{}, nil)
+				r.EXPECT().Assign(gomock.Any(), 0, NoteID, UserID).Return(nil)
+				r.EXPECT().Create(gomock.Any(), UserID, NoteID, &testTag).Times(0)
 			},
 			inTag:         testTag,
 			ExpectedError: nil,
@@ -73,13 +74,13 @@ func TestService_Create(t *testing.T) {
 			inUserID: 0,
 			inNoteID: 0,
 			notesRepoBehaviour: func(r *mock.MockNoteRepository, UserID, NoteID int) {
-				r.EXPECT().GetOne(UserID, NoteID).Return(testNote, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, NoteID).Return(testNote, nil)
 			},
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Tag{testTag}, nil)
-				r.EXPECT().GetAllByNote(UserID, NoteID).Return([]model.Tag{testTag}, nil)
-				r.EXPECT().Assign(0, NoteID, UserID).Times(0)
-				r.EXPECT().Create(UserID, NoteID, &testTag).Times(0)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, NoteID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().Assign(gomock.Any(), 0, NoteID, UserID).Times(0)
+				r.EXPECT().Create(gomock.Any(), UserID, NoteID, &testTag).Times(0)
 			},
 			inTag:         testTag,
 			ExpectedError: nil,
@@ -89,7 +90,7 @@ func TestService_Create(t *testing.T) {
 			inUserID: 0,
 			inNoteID: 0,
 			notesRepoBehaviour: func(r *mock.MockNoteRepository, UserID, NoteID int) {
-				r.EXPECT().GetOne(UserID, NoteID).Return(model.Note{}, e.ClientNoteError)
+				r.EXPECT().GetOne(gomock.Any(), UserID, NoteID).Return(model.Note{}, e.ClientNoteError)
 			},
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, NoteID int) {
 			},
@@ -116,9 +117,9 @@ func TestService_Create(t *testing.T) {
 			tagRepo := &repository.TagRepositoryImpl{
 				TagRepository: tagRepoMock,
 			}
-			mockService := NewService(tagRepo, noteRepo, logging.GetLogger())
+			mockService := NewService(tagRepo, noteRepo, &testutils.Auditor{}, logging.GetLogger())
 
-			_, err := mockService.Create(0, 0, &testSuite.inTag)
+			_, err := mockService.Create(context.Background(), 0, 0, &testSuite.inTag)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 		})
@@ -144,7 +145,7 @@ func TestService_GetAll(t *testing.T) {
 			testName: "FoundAtLeastOneTag",
 			inUserID: 0,
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Tag{testTag}, nil)
 			},
 			ExpectedError: nil,
 		},
@@ -152,7 +153,7 @@ func TestService_GetAll(t *testing.T) {
 			testName: "FoundNoTags",
 			inUserID: 0,
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID int) {
-				r.EXPECT().GetAll(UserID).Return([]model.Tag{}, nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return([]model.Tag{}, nil)
 			},
 			ExpectedError: nil,
 		},
@@ -170,9 +171,9 @@ func TestService_GetAll(t *testing.T) {
 			tagRepo := &repository.TagRepositoryImpl{
 				TagRepository: tagRepoMock,
 			}
-			mockService := NewService(tagRepo, nil, logging.GetLogger())
+			mockService := NewService(tagRepo, nil, &testutils.Auditor{}, logging.GetLogger())
 
-			_, err := mockService.GetAll(testSuite.inUserID)
+			_, err := mockService.GetAll(context.Background(), testSuite.inUserID)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 		})
@@ -202,10 +203,10 @@ func TestService_GetAllByNote(t *testing.T) {
 			inUserID: 0,
 			inNoteID: 0,
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, noteID int) {
-				r.EXPECT().GetAllByNote(UserID, noteID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, noteID).Return([]model.Tag{testTag}, nil)
 			},
 			noteRepoBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
-				r.EXPECT().GetOne(UserID, noteID).Return(model.Note{}, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(model.Note{}, nil)
 			},
 			ExpectedError: nil,
 		},
@@ -213,10 +214,10 @@ func TestService_GetAllByNote(t *testing.T) {
 			testName: "FoundNoTags",
 			inUserID: 0,
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, noteID int) {
-				r.EXPECT().GetAllByNote(UserID, noteID).Return([]model.Tag{}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, noteID).Return([]model.Tag{}, nil)
 			},
 			noteRepoBehaviour: func(r *mock.MockNoteRepository, UserID, noteID int) {
-				r.EXPECT().GetOne(UserID, noteID).Return(model.Note{}, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, noteID).Return(model.Note{}, nil)
 			},
 			ExpectedError: nil,
 		},
@@ -242,9 +243,9 @@ func TestService_GetAllByNote(t *testing.T) {
 				NoteRepository: noteRepoMock,
 			}
 
-			mockService := NewService(tagRepo, noteRepo, logging.GetLogger())
+			mockService := NewService(tagRepo, noteRepo, &testutils.Auditor{}, logging.GetLogger())
 
-			_, err := mockService.GetAllByNote(testSuite.inUserID, testSuite.inNoteID)
+			_, err := mockService.GetAllByNote(context.Background(), testSuite.inUserID, testSuite.inNoteID)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 		})
@@ -272,7 +273,7 @@ func TestService_GetOne(t *testing.T) {
 			inUserID: 0,
 			inTagID:  0,
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, tagID int) {
-				r.EXPECT().GetOne(UserID, tagID).Return(testTag, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, tagID).Return(testTag, nil)
 			},
 			ExpectedError: nil,
 		},
@@ -280,7 +281,7 @@ func TestService_GetOne(t *testing.T) {
 			testName: "FoundNoTags",
 			inUserID: 0,
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, tagID int) {
-				r.EXPECT().GetOne(UserID, tagID).Return(model.Tag{}, e.ClientTagError)
+				r.EXPECT().GetOne(gomock.Any(), UserID, tagID).Return(model.Tag{}, e.ClientTagError)
 			},
 			ExpectedError: e.ClientTagError,
 		},
@@ -298,9 +299,9 @@ func TestService_GetOne(t *testing.T) {
 			tagRepo := &repository.TagRepositoryImpl{
 				TagRepository: tagRepoMock,
 			}
-			mockService := NewService(tagRepo, nil, logging.GetLogger())
+			mockService := NewService(tagRepo, nil, &testutils.Auditor{}, logging.GetLogger())
 
-			_, err := mockService.GetOne(testSuite.inUserID, testSuite.inTagID)
+			_, err := mockService.GetOne(context.Background(), testSuite.inUserID, testSuite.inTagID)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 		})
@@ -340,8 +341,8 @@ func TestService_Update(t *testing.T) {
 			inUserID: 0,
 			inTagID:  0,
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, tagID int) {
-				r.EXPECT().GetOne(UserID, tagID).Return(testTagBeforeUpdate, nil)
-				r.EXPECT().Update(UserID, tagID, testTagColorUpdateFull).Return(nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, tagID).Return(testTagBeforeUpdate, nil)
+				r.EXPECT().Update(gomock.Any(), UserID, tagID, testTagColorUpdateFull).Return(nil)
 			},
 			inTag:         testTagColorUpdate,
 			ExpectedError: nil,
@@ -350,8 +351,8 @@ func TestService_Update(t *testing.T) {
 			testName: "NeedNameUpdate",
 			inUserID: 0,
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, tagID int) {
-				r.EXPECT().GetOne(UserID, tagID).Return(testTagBeforeUpdate, nil)
-				r.EXPECT().Update(UserID, tagID, testTagNameUpdateFull).Return(nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, tagID).Return(testTagBeforeUpdate, nil)
+				r.EXPECT().Update(gomock.Any(), UserID, tagID, testTagNameUpdateFull).Return(nil)
 			},
 			inTag:         testTagNameUpdate,
 			ExpectedError: nil,
@@ -360,7 +361,7 @@ func TestService_Update(t *testing.T) {
 			testName: "TagNotFound",
 			inUserID: 0,
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, tagID int) {
-				r.EXPECT().GetOne(UserID, tagID).Return(model.Tag{}, e.ClientTagError)
+				r.EXPECT().GetOne(gomock.Any(), UserID, tagID).Return(model.Tag{}, e.ClientTagError)
 			},
 			inTag:         testTagNameUpdate,
 			ExpectedError: e.ClientTagError,
@@ -379,9 +380,9 @@ func TestService_Update(t *testing.T) {
 			tagRepo := &repository.TagRepositoryImpl{
 				TagRepository: tagRepoMock,
 			}
-			mockService := NewService(tagRepo, nil, logging.GetLogger())
+			mockService := NewService(tagRepo, nil, &testutils.Auditor{}, logging.GetLogger())
 
-			err := mockService.Update(testSuite.inUserID, testSuite.inTagID, testSuite.inTag)
+			err := mockService.Update(context.Background(), testSuite.inUserID, testSuite.inTagID, testSuite.inTag)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 		})
@@ -401,15 +402,33 @@ func TestService_Delete(t *testing.T) {
 		inTagID           int
 		tagsRepoBehaviour tagRepoMockBehaviour
 		ExpectedError     error
+		expectedEntries   []model.AuditEntry
 	}{
 		{
 			testName: "DeletedSuccessfully",
 			inUserID: 0,
 			inTagID:  0,
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, tagID int) {
-				r.EXPECT().Delete(UserID, tagID).Return(nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, tagID).Return(model.Tag{ID: tagID, Label: "work"}, nil)
+				r.EXPECT().Delete(gomock.Any(), UserID, tagID).Return(nil)
 			},
 			ExpectedError: nil,
+			expectedEntries: []model.AuditEntry{{
+				UserID: new(int),
+				Action: model.AuditTagDeleted,
+				Target: "tag:0",
+				Before: model.AuditSummary{"label": "work"},
+			}},
+		},
+		{
+			testName: "TagNotFound",
+			inUserID: 0,
+			inTagID:  0,
+			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, tagID int) {
+				r.EXPECT().GetOne(gomock.Any(), UserID, tagID).Return(model.Tag{}, e.ClientTagError)
+				r.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.ClientTagError,
 		},
 	}
 	for _, testSuite := range testSuites {
@@ -425,11 +444,13 @@ func TestService_Delete(t *testing.T) {
 			tagRepo := &repository.TagRepositoryImpl{
 				TagRepository: tagRepoMock,
 			}
-			mockService := NewService(tagRepo, nil, logging.GetLogger())
+			audit := &testutils.Auditor{}
+			mockService := NewService(tagRepo, nil, audit, logging.GetLogger())
 
-			err := mockService.Delete(testSuite.inUserID, testSuite.inTagID)
+			err := mockService.Delete(context.Background(), testSuite.inUserID, testSuite.inTagID)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.expectedEntries, audit.Entries)
 		})
 	}
 	err := testutils.CleanupLogs()
@@ -473,15 +494,15 @@ func TestService_Detach(t *testing.T) {
 			inTagID:  0,
 			inNoteID: 0,
 			notesRepoBehaviour: func(r *mock.MockNoteRepository, UserID, NoteID int) {
-				r.EXPECT().GetOne(UserID, NoteID).Return(testNoteWithTag, nil)
-				r.EXPECT().GetAll(UserID).Return(testNotesSliceOneFits, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, NoteID).Return(testNoteWithTag, nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return(testNotesSliceOneFits, nil)
 			},
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, NoteID, tagID int) {
-				r.EXPECT().GetAllByNote(UserID, testNoteWithTag.ID).Return([]model.Tag{testTag}, nil)
-				r.EXPECT().GetAllByNote(UserID, testNoteNoTag.ID).Return([]model.Tag{}, nil)
-				r.EXPECT().GetOne(UserID, tagID).Return(testTag, nil)
-				r.EXPECT().Detach(UserID, tagID, NoteID).Return(nil)
-				r.EXPECT().Delete(UserID, tagID).Return(nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, testNoteWithTag.ID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, testNoteNoTag.ID).Return([]model.Tag{}, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, tagID).Return(testTag, nil)
+				r.EXPECT().Detach(gomock.Any(), UserID, tagID, NoteID).Return(nil)
+				r.EXPECT().Delete(gomock.Any(), UserID, tagID).Return(nil)
 			},
 			ExpectedError: nil,
 		},
@@ -491,15 +512,15 @@ func TestService_Detach(t *testing.T) {
 			inTagID:  0,
 			inNoteID: 0,
 			notesRepoBehaviour: func(r *mock.MockNoteRepository, UserID, NoteID int) {
-				r.EXPECT().GetOne(UserID, NoteID).Return(testNoteWithTag, nil)
-				r.EXPECT().GetAll(UserID).Return(testNotesSliceMultipleFits, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, NoteID).Return(testNoteWithTag, nil)
+				r.EXPECT().GetAll(gomock.Any(), UserID).Return(testNotesSliceMultipleFits, nil)
 			},
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, NoteID, tagID int) {
-				r.EXPECT().GetAllByNote(UserID, testNoteWithTag.ID).Return([]model.Tag{testTag}, nil)
-				r.EXPECT().GetAllByNote(UserID, otherNoteWithTag.ID).Return([]model.Tag{testTag}, nil)
-				r.EXPECT().GetAllByNote(UserID, testNoteNoTag.ID).Return([]model.Tag{}, nil)
-				r.EXPECT().GetOne(UserID, tagID).Return(testTag, nil)
-				r.EXPECT().Detach(UserID, tagID, NoteID).Return(nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, testNoteWithTag.ID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, otherNoteWithTag.ID).Return([]model.Tag{testTag}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, testNoteNoTag.ID).Return([]model.Tag{}, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, tagID).Return(testTag, nil)
+				r.EXPECT().Detach(gomock.Any(), UserID, tagID, NoteID).Return(nil)
 			},
 			ExpectedError: nil,
 		},
@@ -509,11 +530,11 @@ func TestService_Detach(t *testing.T) {
 			inTagID:  0,
 			inNoteID: 1,
 			notesRepoBehaviour: func(r *mock.MockNoteRepository, UserID, NoteID int) {
-				r.EXPECT().GetOne(UserID, NoteID).Return(testNoteWithTag, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, NoteID).Return(testNoteWithTag, nil)
 			},
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, NoteID, tagID int) {
-				r.EXPECT().GetOne(UserID, tagID).Return(model.Tag{}, nil)
-				r.EXPECT().GetAllByNote(UserID, testNoteNoTag.ID).Return([]model.Tag{}, nil)
+				r.EXPECT().GetOne(gomock.Any(), UserID, tagID).Return(model.Tag{}, nil)
+				r.EXPECT().GetAllByNote(gomock.Any(), UserID, testNoteNoTag.ID).Return([]model.Tag{}, nil)
 			},
 			ExpectedError: nil,
 		},
@@ -523,7 +544,7 @@ func TestService_Detach(t *testing.T) {
 			inTagID:  0,
 			inNoteID: 1,
 			notesRepoBehaviour: func(r *mock.MockNoteRepository, UserID, NoteID int) {
-				r.EXPECT().GetOne(UserID, NoteID).Return(model.Note{}, e.ClientNoteError)
+				r.EXPECT().GetOne(gomock.Any(), UserID, NoteID).Return(model.Note{}, e.ClientNoteError)
 			},
 			tagsRepoBehaviour: func(r *mock.MockTagRepository, UserID, NoteID, tagID int) {
 
@@ -550,9 +571,9 @@ func TestService_Detach(t *testing.T) {
 			tagRepo := &repository.TagRepositoryImpl{
 				TagRepository: tagRepoMock,
 			}
-			mockService := NewService(tagRepo, noteRepo, logging.GetLogger())
+			mockService := NewService(tagRepo, noteRepo, &testutils.Auditor{}, logging.GetLogger())
 
-			err := mockService.Detach(testSuite.inUserID, testSuite.inTagID, testSuite.inNoteID)
+			err := mockService.Detach(context.Background(), testSuite.inUserID, testSuite.inTagID, testSuite.inNoteID)
 
 			assert.Equal(t, testSuite.ExpectedError, err)
 		})
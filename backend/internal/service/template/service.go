@@ -0,0 +1,189 @@
+package template
+
+import (
+	"context"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"neatly/pkg/tracing"
+	"time"
+)
+
+// Auditor appends notes and tags created from templates to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
+type Service struct {
+	templatesRepository *repository.TemplateRepositoryImpl
+	accountsRepository  *repository.AccountRepositoryImpl
+	transactor          *repository.TransactorImpl
+	audit               Auditor
+	logger              logging.Logger
+}
+
+func NewService(templatesRepository *repository.TemplateRepositoryImpl, accountsRepository *repository.AccountRepositoryImpl,
+	transactor *repository.TransactorImpl, audit Auditor, logger logging.Logger) *Service {
+	return &Service{
+		templatesRepository: templatesRepository,
+		accountsRepository:  accountsRepository,
+		transactor:          transactor,
+		audit:               audit,
+		logger:              logger,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, userID int, t *model.Template) error {
+	ctx, span := tracing.Start(ctx, "template.Create")
+	defer span.End()
+
+	return s.templatesRepository.Create(ctx, userID, t)
+}
+
+func (s *Service) GetAll(ctx context.Context, userID int) ([]model.Template, error) {
+	ctx, span := tracing.Start(ctx, "template.GetAll")
+	defer span.End()
+
+	return s.templatesRepository.GetAll(ctx, userID)
+}
+
+func (s *Service) GetOne(ctx context.Context, userID, templateID int) (model.Template, error) {
+	ctx, span := tracing.Start(ctx, "template.GetOne")
+	defer span.End()
+
+	return s.templatesRepository.GetOne(ctx, userID, templateID)
+}
+
+func (s *Service) Update(ctx context.Context, userID, templateID int, t model.Template, needBodyUpdate bool) error {
+	ctx, span := tracing.Start(ctx, "template.Update")
+	defer span.End()
+
+	prev, err := s.templatesRepository.GetOne(ctx, userID, templateID)
+	if err != nil {
+		return err
+	}
+
+	t.ID = templateID
+	if t.Header == "" {
+		t.Header = prev.Header
+	}
+	if t.Color == "" {
+		t.Color = prev.Color
+	}
+	if t.Tags == nil {
+		t.Tags = prev.Tags
+	}
+	if !needBodyUpdate {
+		t.Body = prev.Body
+	}
+
+	return s.templatesRepository.Update(ctx, userID, t)
+}
+
+func (s *Service) Delete(ctx context.Context, userID, templateID int) error {
+	ctx, span := tracing.Start(ctx, "template.Delete")
+	defer span.End()
+
+	_, err := s.templatesRepository.GetOne(ctx, userID, templateID)
+	if err != nil {
+		return err
+	}
+	return s.templatesRepository.Delete(ctx, userID, templateID)
+}
+
+// Render builds note and its tags from template, filling placeholders with
+// data of the account which owns the template.
+func (s *Service) Render(ctx context.Context, userID, templateID int) (model.Note, []model.Tag, error) {
+	ctx, span := tracing.Start(ctx, "template.Render")
+	defer span.End()
+
+	t, err := s.templatesRepository.GetOne(ctx, userID, templateID)
+	if err != nil {
+		return model.Note{}, nil, err
+	}
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return model.Note{}, nil, err
+	}
+
+	n := t.Render(a, time.Now())
+	s.logger.WithContext(ctx).Infof("Rendered note from template %v", templateID)
+
+	tags := make([]model.Tag, 0, len(t.Tags))
+	for _, label := range t.Tags {
+		tags = append(tags, model.Tag{Label: label})
+	}
+
+	return n, tags, nil
+}
+
+// Instantiate creates note with its tags from template in one transaction,
+// so note is not left behind when one of its tags can't be created
+func (s *Service) Instantiate(ctx context.Context, userID, templateID int) (model.Note, error) {
+	ctx, span := tracing.Start(ctx, "template.Instantiate")
+	defer span.End()
+
+	n, noteTags, err := s.Render(ctx, userID, templateID)
+	if err != nil {
+		return model.Note{}, err
+	}
+
+	var created []model.Tag
+	err = s.transactor.WithinTransaction(ctx, func(notes repository.NoteRepository, tags repository.TagRepository) error {
+		if err := notes.Create(ctx, userID, &n); err != nil {
+			return err
+		}
+
+		all, err := tags.GetAll(ctx, userID)
+		if err != nil {
+			return err
+		}
+		known := make(map[string]int, len(all))
+		for _, t := range all {
+			known[t.Label] = t.ID
+		}
+
+		assigned := make(map[int]bool, len(noteTags))
+		for i := range noteTags {
+			t := &noteTags[i]
+			if id, ok := known[t.Label]; ok {
+				t.ID = id
+			} else {
+				if err := tags.Create(ctx, userID, n.ID, t); err != nil {
+					return err
+				}
+				known[t.Label] = t.ID
+				created = append(created, *t)
+			}
+
+			if assigned[t.ID] {
+				continue
+			}
+			if err := tags.Assign(ctx, t.ID, n.ID, userID); err != nil {
+				return err
+			}
+			assigned[t.ID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return model.Note{}, err
+	}
+	n.Tags = noteTags
+
+	metrics.NotesCreated.Inc()
+	s.logger.WithContext(ctx).Infof("Note %v created from template %v", n.ID, templateID)
+	entry := model.NewAuditEntry(userID, model.AuditNoteCreated, model.AuditTarget("note", n.ID))
+	entry.After = n.AuditSummary()
+	entry.After["template"] = templateID
+	s.audit.Record(ctx, entry)
+	for _, t := range created {
+		entry := model.NewAuditEntry(userID, model.AuditTagCreated, model.AuditTarget("tag", t.ID))
+		entry.After = t.AuditSummary()
+		s.audit.Record(ctx, entry)
+	}
+
+	return n, nil
+}
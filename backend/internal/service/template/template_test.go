@@ -0,0 +1,281 @@
+//go:build unit
+// +build unit
+
+package template
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"testing"
+	"time"
+)
+
+func TestService_Update(t *testing.T) {
+	type templateRepoMockBehaviour func(r *mock.MockTemplateRepository, userID int, tp model.Template)
+
+	testTemplate := mother.TemplateMother()
+	newBody := testTemplate
+	newBody.Body = "new body"
+
+	testSuites := []struct {
+		testName          string
+		inTemplate        model.Template
+		needBodyUpdate    bool
+		templateBehaviour templateRepoMockBehaviour
+		ExpectedError     error
+	}{
+		{
+			testName:       "UpdateKeepsPreviousValues",
+			inTemplate:     model.Template{},
+			needBodyUpdate: false,
+			templateBehaviour: func(r *mock.MockTemplateRepository, userID int, tp model.Template) {
+				r.EXPECT().GetOne(gomock.Any(), userID, tp.ID).Return(tp, nil)
+				r.EXPECT().Update(gomock.Any(), userID, tp).Return(nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName:       "UpdateBody",
+			inTemplate:     model.Template{Body: newBody.Body},
+			needBodyUpdate: true,
+			templateBehaviour: func(r *mock.MockTemplateRepository, userID int, tp model.Template) {
+				r.EXPECT().GetOne(gomock.Any(), userID, tp.ID).Return(tp, nil)
+				r.EXPECT().Update(gomock.Any(), userID, newBody).Return(nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName:       "TemplateNotFound",
+			inTemplate:     model.Template{},
+			needBodyUpdate: false,
+			templateBehaviour: func(r *mock.MockTemplateRepository, userID int, tp model.Template) {
+				r.EXPECT().GetOne(gomock.Any(), userID, tp.ID).Return(model.Template{}, e.ClientTemplateError)
+				r.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.ClientTemplateError,
+		},
+		{
+			testName:       "DatabaseError",
+			inTemplate:     model.Template{},
+			needBodyUpdate: false,
+			templateBehaviour: func(r *mock.MockTemplateRepository, userID int, tp model.Template) {
+				r.EXPECT().GetOne(gomock.Any(), userID, tp.ID).Return(model.Template{}, sql.ErrConnDone)
+				r.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: sql.ErrConnDone,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repoMock := mock.NewMockTemplateRepository(c)
+			testSuite.templateBehaviour(repoMock, 0, testTemplate)
+
+			logging.Init()
+			repo := &repository.TemplateRepositoryImpl{
+				TemplateRepository: repoMock,
+			}
+			mockService := NewService(repo, nil, nil, &testutils.Auditor{}, logging.GetLogger())
+
+			err := mockService.Update(context.Background(), 0, testTemplate.ID, testSuite.inTemplate, testSuite.needBodyUpdate)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Render(t *testing.T) {
+	type templateRepoMockBehaviour func(r *mock.MockTemplateRepository, userID, templateID int)
+	type accountRepoMockBehaviour func(r *mock.MockAccountRepository, userID int)
+
+	testAccount := mother.AccountMother()
+	testTemplate := mother.TemplateMother()
+	testTemplate.Header = "Daily log {{date}}"
+	testTemplate.Body = "Written by {{username}}"
+	testTemplate.Tags = []string{"daily", "log"}
+
+	testSuites := []struct {
+		testName          string
+		templateBehaviour templateRepoMockBehaviour
+		accountBehaviour  accountRepoMockBehaviour
+		outHeader         string
+		outBody           string
+		outTags           int
+		ExpectedError     error
+	}{
+		{
+			testName: "RenderSuccessful",
+			templateBehaviour: func(r *mock.MockTemplateRepository, userID, templateID int) {
+				r.EXPECT().GetOne(gomock.Any(), userID, templateID).Return(testTemplate, nil)
+			},
+			accountBehaviour: func(r *mock.MockAccountRepository, userID int) {
+				r.EXPECT().GetOne(gomock.Any(), userID).Return(testAccount, nil)
+			},
+			outHeader:     "Daily log " + time.Now().Format("2006-01-02"),
+			outBody:       "Written by " + testAccount.Username,
+			outTags:       2,
+			ExpectedError: nil,
+		},
+		{
+			testName: "TemplateNotFound",
+			templateBehaviour: func(r *mock.MockTemplateRepository, userID, templateID int) {
+				r.EXPECT().GetOne(gomock.Any(), userID, templateID).Return(model.Template{}, e.ClientTemplateError)
+			},
+			accountBehaviour: func(r *mock.MockAccountRepository, userID int) {
+				r.EXPECT().GetOne(gomock.Any(), userID).Times(0)
+			},
+			outHeader:     "",
+			outBody:       "",
+			outTags:       0,
+			ExpectedError: e.ClientTemplateError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			templateRepoMock := mock.NewMockTemplateRepository(c)
+			accountRepoMock := mock.NewMockAccountRepository(c)
+			testSuite.templateBehaviour(templateRepoMock, 0, testTemplate.ID)
+			testSuite.accountBehaviour(accountRepoMock, 0)
+
+			logging.Init()
+			templateRepo := &repository.TemplateRepositoryImpl{
+				TemplateRepository: templateRepoMock,
+			}
+			accountRepo := &repository.AccountRepositoryImpl{
+				AccountRepository: accountRepoMock,
+			}
+			mockService := NewService(templateRepo, accountRepo, nil, &testutils.Auditor{}, logging.GetLogger())
+
+			n, tags, err := mockService.Render(context.Background(), 0, testTemplate.ID)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.outHeader, n.Header)
+			assert.Equal(t, testSuite.outBody, n.Body)
+			assert.Equal(t, testSuite.outTags, len(tags))
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Instantiate(t *testing.T) {
+	type tagsMockBehaviour func(r *mock.MockTagRepository)
+
+	testAccount := mother.AccountMother()
+	testTemplate := mother.TemplateMother()
+	testTemplate.ID = 1
+	testTemplate.Tags = []string{"daily", "log"}
+	knownTag := mother.TagMother()
+	knownTag.ID = 1
+	knownTag.Label = "daily"
+	tagErr := errors.New("connection refused")
+
+	testSuites := []struct {
+		testName      string
+		tagsBehaviour tagsMockBehaviour
+		expectedAudit []model.AuditAction
+		ExpectedError error
+	}{
+		{
+			testName: "NoteWithTagsCreated",
+			tagsBehaviour: func(r *mock.MockTagRepository) {
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Tag{knownTag}, nil)
+				r.EXPECT().Assign(gomock.Any(), knownTag.ID, 2, 0).Return(nil)
+				r.EXPECT().Create(gomock.Any(), 0, 2, gomock.Any()).DoAndReturn(func(_ context.Context, _, _ int, tag *model.Tag) error {
+					tag.ID = 2
+					return nil
+				})
+				r.EXPECT().Assign(gomock.Any(), 2, 2, 0).Return(nil)
+			},
+			expectedAudit: []model.AuditAction{model.AuditNoteCreated, model.AuditTagCreated},
+			ExpectedError: nil,
+		},
+		{
+			testName: "TagCreationFails",
+			tagsBehaviour: func(r *mock.MockTagRepository) {
+				r.EXPECT().GetAll(gomock.Any(), 0).Return([]model.Tag{knownTag}, nil)
+				r.EXPECT().Assign(gomock.Any(), knownTag.ID, 2, 0).Return(nil)
+				r.EXPECT().Create(gomock.Any(), 0, 2, gomock.Any()).Return(tagErr)
+			},
+			expectedAudit: nil,
+			ExpectedError: tagErr,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			templateRepoMock := mock.NewMockTemplateRepository(c)
+			templateRepoMock.EXPECT().GetOne(gomock.Any(), 0, testTemplate.ID).Return(testTemplate, nil)
+			accountRepoMock := mock.NewMockAccountRepository(c)
+			accountRepoMock.EXPECT().GetOne(gomock.Any(), 0).Return(testAccount, nil)
+			notesMock := mock.NewMockNoteRepository(c)
+			notesMock.EXPECT().Create(gomock.Any(), 0, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, n *model.Note) error {
+				n.ID = 2
+				return nil
+			})
+			tagsMock := mock.NewMockTagRepository(c)
+			testSuite.tagsBehaviour(tagsMock)
+
+			// error returned from transaction rolls back created note
+			var txErr error
+			transactorMock := mock.NewMockTransactor(c)
+			transactorMock.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, fn func(notes repository.NoteRepository, tags repository.TagRepository) error) error {
+					txErr = fn(notesMock, tagsMock)
+					return txErr
+				})
+
+			logging.Init()
+			audit := &testutils.Auditor{}
+			mockService := NewService(
+				&repository.TemplateRepositoryImpl{TemplateRepository: templateRepoMock},
+				&repository.AccountRepositoryImpl{AccountRepository: accountRepoMock},
+				&repository.TransactorImpl{Transactor: transactorMock},
+				audit, logging.GetLogger())
+
+			n, err := mockService.Instantiate(context.Background(), 0, testTemplate.ID)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.ExpectedError, txErr)
+			if err == nil {
+				assert.Equal(t, 2, n.ID)
+				assert.Equal(t, 2, len(n.Tags))
+			}
+			var actions []model.AuditAction
+			for _, entry := range audit.Entries {
+				actions = append(actions, entry.Action)
+			}
+			assert.Equal(t, testSuite.expectedAudit, actions)
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,256 @@
+package twofactor
+
+import (
+	"context"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/pkg/e"
+	"neatly/pkg/jwt"
+	"neatly/pkg/logging"
+	"neatly/pkg/metrics"
+	"neatly/pkg/totp"
+	"neatly/pkg/tracing"
+	"time"
+)
+
+const issuer = "Neat.ly"
+
+// SessionStarter records login of client and issues access token bound to
+// the new session
+type SessionStarter interface {
+	Start(ctx context.Context, userID, version int, client model.Client) (string, error)
+}
+
+// Auditor appends security relevant events to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
+type Service struct {
+	accountsRepository  *repository.AccountRepositoryImpl
+	twoFactorRepository *repository.TwoFactorRepositoryImpl
+	sessions            SessionStarter
+	audit               Auditor
+	logger              logging.Logger
+	now                 func() time.Time
+}
+
+func NewService(accountsRepository *repository.AccountRepositoryImpl, twoFactorRepository *repository.TwoFactorRepositoryImpl,
+	sessions SessionStarter, audit Auditor, logger logging.Logger) *Service {
+	return &Service{
+		accountsRepository:  accountsRepository,
+		twoFactorRepository: twoFactorRepository,
+		sessions:            sessions,
+		audit:               audit,
+		logger:              logger,
+		now:                 time.Now,
+	}
+}
+
+// Enrol generates new secret for account. 2FA is not enabled until the
+// secret is confirmed with a code.
+func (s *Service) Enrol(ctx context.Context, userID int) (model.TwoFactorEnrolment, error) {
+	ctx, span := tracing.Start(ctx, "twofactor.Enrol")
+	defer span.End()
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return model.TwoFactorEnrolment{}, err
+	}
+	if a.TOTPEnabled {
+		return model.TwoFactorEnrolment{}, e.ClientTwoFactorEnabledError
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return model.TwoFactorEnrolment{}, err
+	}
+	if err := s.twoFactorRepository.SetSecret(ctx, userID, secret); err != nil {
+		return model.TwoFactorEnrolment{}, err
+	}
+	s.logger.WithContext(ctx).Infof("Two-factor enrolment started for account %v", userID)
+
+	return model.TwoFactorEnrolment{
+		Secret: secret,
+		URI:    totp.URI(issuer, a.Username, secret),
+	}, nil
+}
+
+// Confirm enables 2FA if code matches pending secret and returns recovery
+// codes. Codes are shown only once, only their hashes are stored.
+func (s *Service) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "twofactor.Confirm")
+	defer span.End()
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if a.TOTPEnabled {
+		return nil, e.ClientTwoFactorEnabledError
+	}
+	if a.TOTPSecret == "" {
+		return nil, e.ClientTwoFactorEnrolError
+	}
+
+	if err := s.checkTOTP(ctx, a, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := model.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, c := range codes {
+		hashes = append(hashes, model.HashToken(model.NormaliseRecoveryCode(c)))
+	}
+
+	if err := s.twoFactorRepository.Enable(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	s.logger.WithContext(ctx).Infof("Two-factor authentication enabled for account %v", userID)
+
+	return codes, nil
+}
+
+// Disable turns 2FA off, code or recovery code is required
+func (s *Service) Disable(ctx context.Context, userID int, code string) error {
+	ctx, span := tracing.Start(ctx, "twofactor.Disable")
+	defer span.End()
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !a.TOTPEnabled {
+		return e.ClientTwoFactorDisabledError
+	}
+
+	if err := s.check(ctx, a, code); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepository.Disable(ctx, userID); err != nil {
+		return err
+	}
+	s.logger.WithContext(ctx).Infof("Two-factor authentication disabled for account %v", userID)
+
+	return nil
+}
+
+// Challenge describes login waiting for second factor, so attempts made
+// with challenge token can be throttled before any code is checked
+func (s *Service) Challenge(ctx context.Context, challenge string) (model.TwoFactorChallenge, error) {
+	ctx, span := tracing.Start(ctx, "twofactor.Challenge")
+	defer span.End()
+
+	claims, err := jwt.ParseChallengeToken(challenge)
+	if err != nil {
+		s.logger.WithContext(ctx).Info(err)
+		return model.TwoFactorChallenge{}, e.ClientTokenError
+	}
+
+	a, err := s.accountsRepository.GetOne(ctx, claims.UserID)
+	if err != nil {
+		return model.TwoFactorChallenge{}, err
+	}
+
+	return model.TwoFactorChallenge{
+		ID:        claims.ID,
+		UserID:    a.ID,
+		Username:  a.Username,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// Login exchanges challenge token issued after password check and a code
+// for access token. Pending deletion of account is cancelled only once the
+// second factor is verified.
+func (s *Service) Login(ctx context.Context, challenge, code string, client model.Client) (string, error) {
+	ctx, span := tracing.Start(ctx, "twofactor.Login")
+	defer span.End()
+
+	claims, err := jwt.ParseChallengeToken(challenge)
+	if err != nil {
+		s.logger.WithContext(ctx).Info(err)
+		return "", e.ClientTokenError
+	}
+
+	a, err := s.accountsRepository.GetOne(ctx, claims.UserID)
+	if err != nil {
+		return "", err
+	}
+	if a.SessionVersion != claims.Version {
+		return "", e.SessionRevokedError
+	}
+	if a.Disabled {
+		return "", e.AccountDisabledError
+	}
+	if !a.TOTPEnabled {
+		return "", e.ClientTwoFactorDisabledError
+	}
+
+	if err := s.check(ctx, a, code); err != nil {
+		metrics.LoginsFailed.WithLabelValues("two_factor").Inc()
+		s.recordLogin(ctx, a, model.AuditLoginFailed, model.AuditSummary{"reason": "two_factor"})
+		return "", err
+	}
+	s.logger.WithContext(ctx).Infof("Account %v passed two-factor authentication", a.ID)
+
+	if a.DeleteAfter != nil {
+		if err := s.accountsRepository.CancelDeletion(ctx, a.ID); err != nil {
+			return "", err
+		}
+		s.logger.WithContext(ctx).Infof("Deletion of account %v cancelled by login", a.ID)
+		s.recordCancelledDeletion(ctx, a.ID, *a.DeleteAfter)
+	}
+
+	token, err := s.sessions.Start(ctx, a.ID, a.SessionVersion, client)
+	if err != nil {
+		return "", err
+	}
+	s.recordLogin(ctx, a, model.AuditLoginSucceeded, model.AuditSummary{"method": "two_factor"})
+
+	return token, nil
+}
+
+// recordLogin audits login attempt, account is its actor only on success
+func (s *Service) recordLogin(ctx context.Context, a model.Account, action model.AuditAction, summary model.AuditSummary) {
+	entry := model.NewAuditEntry(a.ID, action, model.AuditTarget("username", a.Username))
+	if action == model.AuditLoginSucceeded {
+		entry.ActorID = &a.ID
+	}
+	entry.After = summary
+	s.audit.Record(ctx, entry)
+}
+
+// recordCancelledDeletion audits deletion cancelled once second factor
+// passed, account is its actor
+func (s *Service) recordCancelledDeletion(ctx context.Context, userID int, deleteAfter time.Time) {
+	entry := model.NewAuditEntry(userID, model.AuditDeletionCancelled, model.AuditTarget("user", userID))
+	entry.ActorID = &userID
+	entry.Before = model.AuditSummary{"delete_after": deleteAfter}
+	s.audit.Record(ctx, entry)
+}
+
+// check accepts either TOTP or one of recovery codes
+func (s *Service) check(ctx context.Context, a model.Account, code string) error {
+	if len(code) == totp.Digits {
+		return s.checkTOTP(ctx, a, code)
+	}
+
+	err := s.twoFactorRepository.UseRecoveryCode(ctx, a.ID, model.HashToken(model.NormaliseRecoveryCode(code)))
+	if err == nil {
+		s.logger.WithContext(ctx).Infof("Recovery code of account %v used", a.ID)
+	}
+	return err
+}
+
+func (s *Service) checkTOTP(ctx context.Context, a model.Account, code string) error {
+	step, ok := totp.Validate(a.TOTPSecret, code, s.now())
+	if !ok {
+		return e.ClientTwoFactorCodeError
+	}
+	return s.twoFactorRepository.UseStep(ctx, a.ID, step)
+}
@@ -0,0 +1,348 @@
+//go:build unit
+// +build unit
+
+package twofactor
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/pkg/e"
+	"neatly/pkg/jwt"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"neatly/pkg/totp"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestService(accounts *mock.MockAccountRepository, twoFactor *mock.MockTwoFactorRepository, now time.Time) *Service {
+	logging.Init()
+	s := NewService(
+		&repository.AccountRepositoryImpl{AccountRepository: accounts},
+		&repository.TwoFactorRepositoryImpl{TwoFactorRepository: twoFactor},
+		testutils.SessionStarter{},
+		&testutils.Auditor{},
+		logging.GetLogger())
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestService_Confirm(t *testing.T) {
+	type accountRepoMockBehaviour func(r *mock.MockAccountRepository, a model.Account)
+	type twoFactorRepoMockBehaviour func(r *mock.MockTwoFactorRepository, a model.Account)
+
+	now := time.Now()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending := mother.AccountMother()
+	pending.TOTPSecret = secret
+	enabled := pending
+	enabled.TOTPEnabled = true
+
+	testSuites := []struct {
+		testName           string
+		inAccount          model.Account
+		inCode             string
+		accountBehaviour   accountRepoMockBehaviour
+		twoFactorBehaviour twoFactorRepoMockBehaviour
+		expectedCodes      int
+		ExpectedError      error
+	}{
+		{
+			testName:  "ConfirmSuccessful",
+			inAccount: pending,
+			inCode:    code,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			twoFactorBehaviour: func(r *mock.MockTwoFactorRepository, a model.Account) {
+				r.EXPECT().UseStep(gomock.Any(), a.ID, totp.Step(now)).Return(nil)
+				r.EXPECT().Enable(gomock.Any(), a.ID, gomock.Len(model.RecoveryCodesCount)).Return(nil)
+			},
+			expectedCodes: model.RecoveryCodesCount,
+			ExpectedError: nil,
+		},
+		{
+			testName:  "WrongCode",
+			inAccount: pending,
+			inCode:    "000000",
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			twoFactorBehaviour: func(r *mock.MockTwoFactorRepository, a model.Account) {
+				r.EXPECT().Enable(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.ClientTwoFactorCodeError,
+		},
+		{
+			testName:  "EnrolmentNotStarted",
+			inAccount: mother.AccountMother(),
+			inCode:    code,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			twoFactorBehaviour: func(r *mock.MockTwoFactorRepository, a model.Account) {
+				r.EXPECT().Enable(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.ClientTwoFactorEnrolError,
+		},
+		{
+			testName:  "AlreadyEnabled",
+			inAccount: enabled,
+			inCode:    code,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			twoFactorBehaviour: func(r *mock.MockTwoFactorRepository, a model.Account) {
+				r.EXPECT().Enable(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			ExpectedError: e.ClientTwoFactorEnabledError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			accountMock := mock.NewMockAccountRepository(c)
+			twoFactorMock := mock.NewMockTwoFactorRepository(c)
+			testSuite.accountBehaviour(accountMock, testSuite.inAccount)
+			testSuite.twoFactorBehaviour(twoFactorMock, testSuite.inAccount)
+
+			s := newTestService(accountMock, twoFactorMock, now)
+
+			codes, err := s.Confirm(context.Background(), testSuite.inAccount.ID, testSuite.inCode)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.expectedCodes, len(codes))
+		})
+	}
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Login(t *testing.T) {
+	type accountRepoMockBehaviour func(r *mock.MockAccountRepository, a model.Account)
+	type twoFactorRepoMockBehaviour func(r *mock.MockTwoFactorRepository, a model.Account)
+
+	err := os.Setenv("CONF_FILE", "../etc/test.yml")
+	if err != nil {
+		t.Fatalf("Can't set config path: %s", err)
+	}
+
+	now := time.Now()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testAccount := mother.AccountMother()
+	testAccount.TOTPSecret = secret
+	testAccount.TOTPEnabled = true
+	deleteAfter := now.Add(time.Hour)
+	pendingDeletion := testAccount
+	pendingDeletion.DeleteAfter = &deleteAfter
+
+	challenge, err := jwt.GenerateChallengeToken(testAccount.ID, testAccount.SessionVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken, err := jwt.GenerateAccessToken(testAccount.ID, testAccount.SessionVersion, mother.SessionMother().ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryCode := "abcde-fghjk"
+
+	testSuites := []struct {
+		testName           string
+		inChallenge        string
+		inCode             string
+		accountBehaviour   accountRepoMockBehaviour
+		twoFactorBehaviour twoFactorRepoMockBehaviour
+		expectedNoToken    bool
+		expectedCancelled  bool
+		ExpectedError      error
+	}{
+		{
+			testName:    "LoginWithTOTP",
+			inChallenge: challenge,
+			inCode:      code,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			twoFactorBehaviour: func(r *mock.MockTwoFactorRepository, a model.Account) {
+				r.EXPECT().UseStep(gomock.Any(), a.ID, totp.Step(now)).Return(nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName:    "LoginWithRecoveryCode",
+			inChallenge: challenge,
+			inCode:      "ABCDE FGHJK",
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			twoFactorBehaviour: func(r *mock.MockTwoFactorRepository, a model.Account) {
+				r.EXPECT().UseRecoveryCode(gomock.Any(), a.ID, model.HashToken(model.NormaliseRecoveryCode(recoveryCode))).Return(nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName:    "PendingDeletionCancelled",
+			inChallenge: challenge,
+			inCode:      code,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(pendingDeletion, nil)
+				r.EXPECT().CancelDeletion(gomock.Any(), a.ID).Return(nil)
+			},
+			twoFactorBehaviour: func(r *mock.MockTwoFactorRepository, a model.Account) {
+				r.EXPECT().UseStep(gomock.Any(), a.ID, totp.Step(now)).Return(nil)
+			},
+			expectedCancelled: true,
+			ExpectedError:     nil,
+		},
+		{
+			testName:    "WrongCodeKeepsPendingDeletion",
+			inChallenge: challenge,
+			inCode:      "000000",
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(pendingDeletion, nil)
+				r.EXPECT().CancelDeletion(gomock.Any(), gomock.Any()).Times(0)
+			},
+			twoFactorBehaviour: func(r *mock.MockTwoFactorRepository, a model.Account) {
+				r.EXPECT().UseStep(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedNoToken: true,
+			ExpectedError:   e.ClientTwoFactorCodeError,
+		},
+		{
+			testName:    "CodeReplayed",
+			inChallenge: challenge,
+			inCode:      code,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			twoFactorBehaviour: func(r *mock.MockTwoFactorRepository, a model.Account) {
+				r.EXPECT().UseStep(gomock.Any(), a.ID, totp.Step(now)).Return(e.ClientTwoFactorCodeError)
+			},
+			expectedNoToken: true,
+			ExpectedError:   e.ClientTwoFactorCodeError,
+		},
+		{
+			testName:    "AccessTokenIsNotChallenge",
+			inChallenge: accessToken,
+			inCode:      code,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), gomock.Any()).Times(0)
+			},
+			twoFactorBehaviour: func(r *mock.MockTwoFactorRepository, a model.Account) {},
+			expectedNoToken:    true,
+			ExpectedError:      e.ClientTokenError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			accountMock := mock.NewMockAccountRepository(c)
+			twoFactorMock := mock.NewMockTwoFactorRepository(c)
+			testSuite.accountBehaviour(accountMock, testAccount)
+			testSuite.twoFactorBehaviour(twoFactorMock, testAccount)
+
+			s := newTestService(accountMock, twoFactorMock, now)
+
+			token, err := s.Login(context.Background(), testSuite.inChallenge, testSuite.inCode, model.Client{})
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.expectedNoToken, token == "")
+			// wrong code may be sent by anyone, so failure has no actor
+			cancelled := false
+			for _, entry := range s.audit.(*testutils.Auditor).Entries {
+				if entry.Action == model.AuditDeletionCancelled {
+					cancelled = true
+					continue
+				}
+				assert.Equal(t, entry.Action == model.AuditLoginSucceeded, entry.ActorID != nil)
+			}
+			assert.Equal(t, testSuite.expectedCancelled, cancelled)
+		})
+	}
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Challenge(t *testing.T) {
+	err := os.Setenv("CONF_FILE", "../etc/test.yml")
+	if err != nil {
+		t.Fatalf("Can't set config path: %s", err)
+	}
+
+	testAccount := mother.AccountMother()
+	testAccount.TOTPEnabled = true
+
+	challenge, err := jwt.GenerateChallengeToken(testAccount.ID, testAccount.SessionVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken, err := jwt.GenerateAccessToken(testAccount.ID, testAccount.SessionVersion, mother.SessionMother().ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testSuites := []struct {
+		testName      string
+		inChallenge   string
+		calls         int
+		outUsername   string
+		ExpectedError error
+	}{
+		{"ValidChallenge", challenge, 1, testAccount.Username, nil},
+		{"AccessTokenIsNotChallenge", accessToken, 0, "", e.ClientTokenError},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			accountMock := mock.NewMockAccountRepository(c)
+			accountMock.EXPECT().GetOne(gomock.Any(), testAccount.ID).Return(testAccount, nil).Times(testSuite.calls)
+
+			s := newTestService(accountMock, mock.NewMockTwoFactorRepository(c), time.Now())
+
+			got, err := s.Challenge(context.Background(), testSuite.inChallenge)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.outUsername, got.Username)
+			assert.Equal(t, testSuite.ExpectedError == nil, got.ID != "")
+		})
+	}
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
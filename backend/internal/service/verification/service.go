@@ -0,0 +1,133 @@
+package verification
+
+import (
+	"context"
+	"fmt"
+	"neatly/internal/model"
+	"neatly/internal/repository"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/mail"
+	"neatly/pkg/tracing"
+)
+
+const (
+	verifyPath    = "/accounts/verify"
+	verifySubject = "Neat.ly email verification"
+	verifyBody    = `Hello, %s!
+
+Please confirm that %q is the email of your Neat.ly account %q.
+Follow the link below, it expires in %v:
+
+%s%s?token=%s
+
+If you did not register at Neat.ly, just ignore this message.
+`
+)
+
+// Auditor appends security relevant events to audit log
+type Auditor interface {
+	Record(ctx context.Context, entry model.AuditEntry)
+}
+
+type Service struct {
+	accountsRepository *repository.AccountRepositoryImpl
+	tokensRepository   *repository.TokenRepositoryImpl
+	mailer             mail.Mailer
+	cfg                *session.Config
+	audit              Auditor
+	logger             logging.Logger
+}
+
+func NewService(accountsRepository *repository.AccountRepositoryImpl, tokensRepository *repository.TokenRepositoryImpl,
+	mailer mail.Mailer, cfg *session.Config, audit Auditor, logger logging.Logger) *Service {
+	return &Service{
+		accountsRepository: accountsRepository,
+		tokensRepository:   tokensRepository,
+		mailer:             mailer,
+		cfg:                cfg,
+		audit:              audit,
+		logger:             logger,
+	}
+}
+
+// Send mails verification link to account email. Links sent earlier stop
+// working. Mailing failures are only logged, user can ask to resend link.
+func (s *Service) Send(ctx context.Context, a model.Account) error {
+	ctx, span := tracing.Start(ctx, "verification.Send")
+	defer span.End()
+
+	if err := s.tokensRepository.Revoke(ctx, a.ID, model.TokenKindEmailVerification); err != nil {
+		return err
+	}
+
+	ttl := s.cfg.Tokens.EmailVerificationTTL
+	token, t, err := model.NewAccountToken(a.ID, model.TokenKindEmailVerification, ttl)
+	if err != nil {
+		return err
+	}
+	if err := s.tokensRepository.Create(ctx, &t); err != nil {
+		return err
+	}
+	entry := model.NewAuditEntry(a.ID, model.AuditTokenCreated, model.AuditTarget("token", t.ID))
+	entry.After = t.AuditSummary()
+	s.audit.Record(ctx, entry)
+
+	err = s.mailer.Send(mail.Message{
+		To:      a.Email,
+		Subject: verifySubject,
+		Body:    fmt.Sprintf(verifyBody, a.Name, a.Email, a.Username, ttl, s.cfg.Mail.BaseURL, verifyPath, token),
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Errorf("Can't send verification mail to account %v: %v", a.ID, err)
+		return nil
+	}
+	s.logger.WithContext(ctx).Infof("Verification mail sent to account %v", a.ID)
+
+	return nil
+}
+
+func (s *Service) Resend(ctx context.Context, userID int) error {
+	ctx, span := tracing.Start(ctx, "verification.Resend")
+	defer span.End()
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if a.Verified {
+		return e.ClientVerifiedError
+	}
+
+	return s.Send(ctx, a)
+}
+
+// Verify marks email of account which owns token as verified
+func (s *Service) Verify(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "verification.Verify")
+	defer span.End()
+
+	t, err := s.tokensRepository.Consume(ctx, model.TokenKindEmailVerification, model.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if err := s.accountsRepository.SetVerified(ctx, t.UserID, true); err != nil {
+		return err
+	}
+	s.logger.WithContext(ctx).Infof("Email of account %v has been verified", t.UserID)
+
+	return s.tokensRepository.Revoke(ctx, t.UserID, model.TokenKindEmailVerification)
+}
+
+func (s *Service) IsVerified(ctx context.Context, userID int) (bool, error) {
+	ctx, span := tracing.Start(ctx, "verification.IsVerified")
+	defer span.End()
+
+	a, err := s.accountsRepository.GetOne(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return a.Verified, nil
+}
@@ -0,0 +1,178 @@
+//go:build unit
+// +build unit
+
+package verification
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/internal/repository"
+	"neatly/internal/repository/mock"
+	"neatly/internal/session"
+	"neatly/pkg/e"
+	"neatly/pkg/logging"
+	"neatly/pkg/mail"
+	"neatly/pkg/testutils"
+	"testing"
+	"time"
+)
+
+type mailerStub struct {
+	sent []mail.Message
+}
+
+func (m *mailerStub) Send(msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func testConfig() *session.Config {
+	cfg := &session.Config{}
+	cfg.Tokens.EmailVerificationTTL = time.Hour
+	cfg.Mail.BaseURL = "http://localhost"
+	return cfg
+}
+
+func TestService_Resend(t *testing.T) {
+	type accountRepoMockBehaviour func(r *mock.MockAccountRepository, a model.Account)
+	type tokenRepoMockBehaviour func(r *mock.MockTokenRepository, a model.Account)
+
+	testAccount := mother.AccountMother()
+	verifiedAccount := testAccount
+	verifiedAccount.Verified = true
+
+	testSuites := []struct {
+		testName          string
+		inAccount         model.Account
+		accountBehaviour  accountRepoMockBehaviour
+		tokenBehaviour    tokenRepoMockBehaviour
+		expectedMailCount int
+		ExpectedError     error
+	}{
+		{
+			testName:  "ResendSuccessful",
+			inAccount: testAccount,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			tokenBehaviour: func(r *mock.MockTokenRepository, a model.Account) {
+				r.EXPECT().Revoke(gomock.Any(), a.ID, model.TokenKindEmailVerification).Return(nil)
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedMailCount: 1,
+			ExpectedError:     nil,
+		},
+		{
+			testName:  "AlreadyVerified",
+			inAccount: verifiedAccount,
+			accountBehaviour: func(r *mock.MockAccountRepository, a model.Account) {
+				r.EXPECT().GetOne(gomock.Any(), a.ID).Return(a, nil)
+			},
+			tokenBehaviour: func(r *mock.MockTokenRepository, a model.Account) {
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedMailCount: 0,
+			ExpectedError:     e.ClientVerifiedError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			accountMock := mock.NewMockAccountRepository(c)
+			tokenMock := mock.NewMockTokenRepository(c)
+			testSuite.accountBehaviour(accountMock, testSuite.inAccount)
+			testSuite.tokenBehaviour(tokenMock, testSuite.inAccount)
+
+			logging.Init()
+			mailer := &mailerStub{}
+			s := NewService(
+				&repository.AccountRepositoryImpl{AccountRepository: accountMock},
+				&repository.TokenRepositoryImpl{TokenRepository: tokenMock},
+				mailer, testConfig(), &testutils.Auditor{}, logging.GetLogger())
+
+			err := s.Resend(context.Background(), testSuite.inAccount.ID)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+			assert.Equal(t, testSuite.expectedMailCount, len(mailer.sent))
+		})
+	}
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Verify(t *testing.T) {
+	type accountRepoMockBehaviour func(r *mock.MockAccountRepository, userID int)
+	type tokenRepoMockBehaviour func(r *mock.MockTokenRepository, token model.AccountToken)
+
+	token, testToken, err := model.NewAccountToken(1, model.TokenKindEmailVerification, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testSuites := []struct {
+		testName         string
+		inToken          string
+		accountBehaviour accountRepoMockBehaviour
+		tokenBehaviour   tokenRepoMockBehaviour
+		ExpectedError    error
+	}{
+		{
+			testName: "VerifySuccessful",
+			inToken:  token,
+			accountBehaviour: func(r *mock.MockAccountRepository, userID int) {
+				r.EXPECT().SetVerified(gomock.Any(), userID, true).Return(nil)
+			},
+			tokenBehaviour: func(r *mock.MockTokenRepository, t model.AccountToken) {
+				r.EXPECT().Consume(gomock.Any(), model.TokenKindEmailVerification, t.Hash).Return(t, nil)
+				r.EXPECT().Revoke(gomock.Any(), t.UserID, model.TokenKindEmailVerification).Return(nil)
+			},
+			ExpectedError: nil,
+		},
+		{
+			testName: "InvalidToken",
+			inToken:  "invalid",
+			accountBehaviour: func(r *mock.MockAccountRepository, userID int) {
+				r.EXPECT().SetVerified(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			tokenBehaviour: func(r *mock.MockTokenRepository, t model.AccountToken) {
+				r.EXPECT().Consume(gomock.Any(), model.TokenKindEmailVerification, model.HashToken("invalid")).
+					Return(model.AccountToken{}, e.ClientTokenError)
+			},
+			ExpectedError: e.ClientTokenError,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			accountMock := mock.NewMockAccountRepository(c)
+			tokenMock := mock.NewMockTokenRepository(c)
+			testSuite.accountBehaviour(accountMock, testToken.UserID)
+			testSuite.tokenBehaviour(tokenMock, testToken)
+
+			logging.Init()
+			s := NewService(
+				&repository.AccountRepositoryImpl{AccountRepository: accountMock},
+				&repository.TokenRepositoryImpl{TokenRepository: tokenMock},
+				&mailerStub{}, testConfig(), &testutils.Auditor{}, logging.GetLogger())
+
+			err := s.Verify(context.Background(), testSuite.inToken)
+
+			assert.Equal(t, testSuite.ExpectedError, err)
+		})
+	}
+	err = testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,10 +1,10 @@
 package session
 
 import (
-	"github.com/ilyakaznacheev/cleanenv"
 	"neatly/pkg/logging"
 	"os"
 	"sync"
+	"time"
 )
 
 type DB struct {
@@ -17,40 +17,288 @@ type DB struct {
 	MigrationsPath string `yaml:"migrations_path"`
 }
 
+// Listen defines address of API server. Client IP is taken from
+// X-Forwarded-For only for requests coming from TrustedProxies (IPs or
+// CIDRs), e.g. nginx in front of replicas. Zero timeout means no timeout.
 type Listen struct {
-	Port   string `yaml:"port"`
-	BindIP string `yaml:"bind_ip"`
+	Port           string        `yaml:"port"`
+	BindIP         string        `yaml:"bind_ip"`
+	TrustedProxies []string      `yaml:"trusted_proxies"`
+	ReadTimeout    time.Duration `yaml:"read_timeout" env-default:"15s"`
+	WriteTimeout   time.Duration `yaml:"write_timeout" env-default:"15s"`
+	IdleTimeout    time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	TLS            TLS           `yaml:"tls"`
+}
+
+// TLS makes API server serve HTTPS and HTTP/2. Certificate files are reread
+// on SIGHUP. When RedirectPort is set, plain HTTP requests on it are
+// redirected to HTTPS.
+type TLS struct {
+	Enabled      bool   `yaml:"enabled" env-default:"false"`
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	RedirectPort string `yaml:"redirect_port"`
 }
 
 type JWT struct {
 	Secret string `yaml:"secret"`
 }
 
+// Cookie defines cookie carrying access token after login. Empty Domain
+// makes it host-only. SameSite is lax, strict or none, none requires Secure.
+type Cookie struct {
+	Name     string        `yaml:"name" env-default:"token"`
+	Domain   string        `yaml:"domain"`
+	Secure   bool          `yaml:"secure" env-default:"false"`
+	SameSite string        `yaml:"same_site" env-default:"lax"`
+	MaxAge   time.Duration `yaml:"max_age" env-default:"10h"`
+}
+
+const (
+	SameSiteLax    = "lax"
+	SameSiteStrict = "strict"
+	SameSiteNone   = "none"
+)
+
+// CORS defines which browser origins may call API. Origin * can't be
+// combined with AllowCredentials, browsers reject such responses.
+type CORS struct {
+	AllowOrigins     []string      `yaml:"allow_origins" env-default:"*"`
+	AllowMethods     []string      `yaml:"allow_methods" env-default:"GET,POST,PUT,PATCH,DELETE,HEAD,OPTIONS"`
+	AllowHeaders     []string      `yaml:"allow_headers" env-default:"Authorization,Origin,Content-Length,Content-Type,X-Request-ID,X-CSRF-Token"`
+	AllowCredentials bool          `yaml:"allow_credentials" env-default:"false"`
+	MaxAge           time.Duration `yaml:"max_age" env-default:"12h"`
+}
+
+// SecurityHeaders are added to every API response. Headers with empty value
+// and HSTS with zero max age are not sent.
+type SecurityHeaders struct {
+	Enabled               bool          `yaml:"enabled" env-default:"true"`
+	ContentSecurityPolicy string        `yaml:"content_security_policy" env-default:"default-src 'none'; frame-ancestors 'none'"`
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age" env-default:"8760h"`
+	HSTSIncludeSubdomains bool          `yaml:"hsts_include_subdomains" env-default:"false"`
+	ReferrerPolicy        string        `yaml:"referrer_policy" env-default:"no-referrer"`
+}
+
 type Swagger struct {
 	Host string `yaml:"host"`
 }
 
+// Mail configures delivery of account mails. Mails are sent in background
+// from queue of QueueSize messages.
+type Mail struct {
+	Driver    string `yaml:"driver" env-default:"log"`
+	Host      string `yaml:"host"`
+	Port      string `yaml:"port"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+	From      string `yaml:"from" env-default:"noreply@neat.ly"`
+	Dir       string `yaml:"dir" env-default:"build/mail"`
+	BaseURL   string `yaml:"base_url" env-default:"http://localhost:5173"`
+	QueueSize int    `yaml:"queue_size" env-default:"100"`
+}
+
+type Tokens struct {
+	PasswordResetTTL     time.Duration `yaml:"password_reset_ttl" env-default:"1h"`
+	EmailVerificationTTL time.Duration `yaml:"email_verification_ttl" env-default:"24h"`
+}
+
+const (
+	UnverifiedAccessFull     = "full"
+	UnverifiedAccessReadOnly = "read_only"
+)
+
+// Verification defines what accounts with unverified email are allowed to do
+type Verification struct {
+	UnverifiedAccess string `yaml:"unverified_access" env-default:"full"`
+}
+
+// Accounts defines how long deleted accounts can be restored by logging in
+// and how often expired ones are purged
+type Accounts struct {
+	DeletionGrace time.Duration `yaml:"deletion_grace" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Lockout defines how failed logins are throttled. Attempts are counted
+// separately per username and per client IP, wrong two-factor codes count
+// as failed logins too. Two-factor challenge is invalidated after
+// MaxChallengeAttempts wrong codes.
+type Lockout struct {
+	MaxAttempts          int           `yaml:"max_attempts" env-default:"5"`
+	MaxIPAttempts        int           `yaml:"max_ip_attempts" env-default:"20"`
+	MaxChallengeAttempts int           `yaml:"max_challenge_attempts" env-default:"3"`
+	BaseDelay            time.Duration `yaml:"base_delay" env-default:"30s"`
+	MaxDelay             time.Duration `yaml:"max_delay" env-default:"1h"`
+	Window               time.Duration `yaml:"window" env-default:"15m"`
+}
+
+// OIDC configures single sign-on with OpenID Connect provider. Local login
+// keeps working when it is enabled.
+type OIDC struct {
+	Enabled      bool          `yaml:"enabled" env-default:"false"`
+	Issuer       string        `yaml:"issuer"`
+	ClientID     string        `yaml:"client_id"`
+	ClientSecret string        `yaml:"client_secret"`
+	RedirectURL  string        `yaml:"redirect_url"`
+	Scopes       []string      `yaml:"scopes" env-default:"openid,email,profile"`
+	FrontendURL  string        `yaml:"frontend_url" env-default:"http://localhost:5173/oidc"`
+	FlowTTL      time.Duration `yaml:"flow_ttl" env-default:"10m"`
+}
+
+// Password defines policy for new passwords and argon2id parameters of
+// stored hashes, memory is in KiB. Hashes created with other parameters or
+// by bcrypt are upgraded on next successful login.
+type Password struct {
+	MinLength    int  `yaml:"min_length" env-default:"8"`
+	MinClasses   int  `yaml:"min_classes" env-default:"2"`
+	RejectCommon bool `yaml:"reject_common" env-default:"true"`
+	Memory       int  `yaml:"memory" env-default:"19456"`
+	Iterations   int  `yaml:"iterations" env-default:"2"`
+	Parallelism  int  `yaml:"parallelism" env-default:"1"`
+}
+
+// Sessions defines for how long successful session check is cached, so
+// authentication does not hit database on every request. Sessions revoked
+// on other instance keep working for at most this long.
+type Sessions struct {
+	CacheTTL time.Duration `yaml:"cache_ttl" env-default:"30s"`
+}
+
+// Metrics configures Prometheus endpoint. When ListenAddress is empty
+// /metrics is served by API server, otherwise by separate one on this
+// address, so it can be kept out of public network.
+type Metrics struct {
+	Enabled       bool   `yaml:"enabled" env-default:"true"`
+	ListenAddress string `yaml:"listen_address"`
+}
+
+// Health configures readiness probe. On shutdown readiness fails for
+// DrainDelay before server stops, so load balancers can take instance out.
+type Health struct {
+	Timeout    time.Duration `yaml:"timeout" env-default:"2s"`
+	DrainDelay time.Duration `yaml:"drain_delay" env-default:"5s"`
+}
+
+// Shutdown limits how long graceful shutdown may take: readiness drain
+// delay, waiting for in-flight requests and background workers together
+type Shutdown struct {
+	Timeout time.Duration `yaml:"timeout" env-default:"20s"`
+}
+
+// Logging defines log level, format (text or json) and outputs (stdout,
+// stderr, file). File is rotated at MaxSize megabytes, MaxBackups old files
+// are kept for at most MaxAge days.
+type Logging struct {
+	Level      string   `yaml:"level" env-default:"info"`
+	Format     string   `yaml:"format" env-default:"text"`
+	Outputs    []string `yaml:"outputs" env-default:"stdout,file"`
+	File       string   `yaml:"file" env-default:"build/logs/all.log"`
+	MaxSize    int      `yaml:"max_size" env-default:"100"`
+	MaxBackups int      `yaml:"max_backups" env-default:"5"`
+	MaxAge     int      `yaml:"max_age" env-default:"30"`
+	Compress   bool     `yaml:"compress" env-default:"false"`
+}
+
+// Tracing configures OpenTelemetry spans export, Exporter is otlp (gRPC
+// collector on Endpoint) or stdout. SampleRatio applies only to traces
+// started here, traces continued from incoming requests keep their decision.
+type Tracing struct {
+	Enabled     bool    `yaml:"enabled" env-default:"false"`
+	Exporter    string  `yaml:"exporter" env-default:"otlp"`
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4317"`
+	Insecure    bool    `yaml:"insecure" env-default:"true"`
+	ServiceName string  `yaml:"service_name" env-default:"neatly"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+}
+
+// RateLimit configures token buckets per route group. Store is memory,
+// which limits every replica on its own, or postgres shared by replicas.
+// Buckets idle long enough to be full are purged every PurgeInterval.
+type RateLimit struct {
+	Enabled       bool             `yaml:"enabled" env-default:"true"`
+	Store         string           `yaml:"store" env-default:"memory"`
+	PurgeInterval time.Duration    `yaml:"purge_interval" env-default:"10m"`
+	Groups        []RateLimitGroup `yaml:"groups"`
+}
+
+// RateLimitGroup lets Burst requests at once to routes starting with Path
+// and Requests more every Period. Key is user (falling back to IP for
+// anonymous requests) or ip. The first group matching request is used.
+type RateLimitGroup struct {
+	Name     string        `yaml:"name"`
+	Path     string        `yaml:"path"`
+	Methods  []string      `yaml:"methods"`
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	Burst    int           `yaml:"burst"`
+	Key      string        `yaml:"key"`
+}
+
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+)
+
+// Admin lists accounts which are granted admin role on startup, so the first
+// admin doesn't need manual SQL. Removing username from the list doesn't
+// revoke the role.
+type Admin struct {
+	BootstrapUsernames []string `yaml:"bootstrap_usernames"`
+}
+
+type Batch struct {
+	MaxOperations int `yaml:"max_operations" env-default:"100"`
+}
+
 type Config struct {
-	IsDebug *bool   `yaml:"is_debug"`
-	DB      DB      `yaml:"db"`
-	Listen  Listen  `yaml:"listen"`
-	JWT     JWT     `yaml:"jwt"`
-	Swagger Swagger `yaml:"swagger"`
+	IsDebug         *bool           `yaml:"is_debug"`
+	DB              DB              `yaml:"db"`
+	Listen          Listen          `yaml:"listen"`
+	JWT             JWT             `yaml:"jwt"`
+	Cookie          Cookie          `yaml:"cookie"`
+	CORS            CORS            `yaml:"cors"`
+	SecurityHeaders SecurityHeaders `yaml:"security_headers"`
+	Swagger         Swagger         `yaml:"swagger"`
+	Batch           Batch           `yaml:"batch"`
+	Mail            Mail            `yaml:"mail"`
+	Tokens          Tokens          `yaml:"tokens"`
+	Verification    Verification    `yaml:"verification"`
+	Accounts        Accounts        `yaml:"accounts"`
+	Lockout         Lockout         `yaml:"lockout"`
+	OIDC            OIDC            `yaml:"oidc"`
+	Password        Password        `yaml:"password"`
+	Sessions        Sessions        `yaml:"sessions"`
+	Metrics         Metrics         `yaml:"metrics"`
+	Health          Health          `yaml:"health"`
+	Shutdown        Shutdown        `yaml:"shutdown"`
+	Logging         Logging         `yaml:"logging"`
+	Tracing         Tracing         `yaml:"tracing"`
+	RateLimit       RateLimit       `yaml:"rate_limit"`
+	Admin           Admin           `yaml:"admin"`
 }
 
 var instance *Config
 var once sync.Once
 
+// GetConfig returns config set by SetConfig. If there is none, e.g. in
+// tests, config is loaded from CONF_FILE and env vars.
 func GetConfig() *Config {
 	once.Do(func() {
-		logger := logging.GetLogger()
-		logger.Infof("Reading application config from %v", os.Getenv("CONF_FILE"))
-		instance = &Config{}
-		if err := cleanenv.ReadConfig(os.Getenv("CONF_FILE"), instance); err != nil {
-			help, _ := cleanenv.GetDescription(instance, nil)
-			logger.Info(help)
-			logger.Fatal(err)
+		cfg, err := Load(Options{ConfigFile: os.Getenv("CONF_FILE")})
+		if err == nil {
+			err = cfg.Validate()
 		}
+		if err != nil {
+			logging.GetLogger().Fatal(err)
+		}
+		instance = cfg
 	})
 	return instance
 }
+
+// SetConfig makes GetConfig return cfg, which main loads from command line
+func SetConfig(cfg *Config) {
+	once.Do(func() {})
+	instance = cfg
+}
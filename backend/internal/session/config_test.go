@@ -0,0 +1,172 @@
+//go:build unit
+// +build unit
+
+package session
+
+import (
+	"bytes"
+	"github.com/go-playground/assert/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+is_debug: false
+listen:
+  port: "8080"
+db:
+  host: "file-host"
+  dbname: "neatly"
+  password: "db-password"
+jwt:
+  secret: "file-secret"
+metrics:
+  enabled: false
+lockout:
+  max_attempts: 3
+`
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_Layers(t *testing.T) {
+	t.Setenv("NEATLY_DB_HOST", "env-host")
+	t.Setenv("NEATLY_LOCKOUT_WINDOW", "5m")
+	t.Setenv("NEATLY_LISTEN_TRUSTED_PROXIES", "10.0.0.1, 172.16.0.0/12")
+
+	opts, err := ParseArgs([]string{"--lockout.window=1m", "--db.port", "6543", writeConfig(t, testConfig)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// defaults
+	assert.Equal(t, 20, cfg.Lockout.MaxIPAttempts)
+	assert.Equal(t, "memory", cfg.RateLimit.Store)
+	// file, false in file wins over true default
+	assert.Equal(t, 3, cfg.Lockout.MaxAttempts)
+	assert.Equal(t, false, cfg.Metrics.Enabled)
+	// env
+	assert.Equal(t, "env-host", cfg.DB.Host)
+	assert.Equal(t, []string{"10.0.0.1", "172.16.0.0/12"}, cfg.Listen.TrustedProxies)
+	// flags
+	assert.Equal(t, time.Minute, cfg.Lockout.Window)
+	assert.Equal(t, "6543", cfg.DB.Port)
+
+	assert.Equal(t, nil, cfg.Validate())
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	testSuites := []struct {
+		testName string
+		config   string
+		args     []string
+		expected string
+	}{
+		{
+			testName: "UnknownKey",
+			config:   "db:\n  hots: localhost\n",
+			expected: "field hots not found",
+		},
+		{
+			testName: "InvalidFlag",
+			config:   testConfig,
+			args:     []string{"--lockout.window=often"},
+			expected: "flag --lockout.window",
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			opts, err := ParseArgs(append(testSuite.args, writeConfig(t, testSuite.config)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Load(opts)
+			assert.NotEqual(t, nil, err)
+			assert.Equal(t, true, strings.Contains(err.Error(), testSuite.expected))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testSuites := []struct {
+		testName string
+		env      map[string]string
+		expected []string
+	}{
+		{
+			testName: "DefaultSecretInDebug",
+			env:      map[string]string{"NEATLY_IS_DEBUG": "true", "NEATLY_JWT_SECRET": insecureJWTSecret},
+		},
+		{
+			testName: "DefaultSecret",
+			env:      map[string]string{"NEATLY_JWT_SECRET": insecureJWTSecret},
+			expected: []string{"jwt.secret: default secret is allowed only when is_debug is true"},
+		},
+		{
+			testName: "SeveralProblems",
+			env: map[string]string{
+				"NEATLY_LISTEN_PORT":          "http",
+				"NEATLY_RATE_LIMIT_STORE":     "redis",
+				"NEATLY_TRACING_ENABLED":      "true",
+				"NEATLY_TRACING_SAMPLE_RATIO": "2",
+			},
+			expected: []string{
+				`listen.port: "http" is not a valid port`,
+				"tracing.sample_ratio: must be between 0 and 1",
+				`rate_limit.store: must be "memory" or "postgres"`,
+			},
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			for k, v := range testSuite.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load(Options{ConfigFile: writeConfig(t, testConfig)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = cfg.Validate()
+			if testSuite.expected == nil {
+				assert.Equal(t, nil, err)
+				return
+			}
+			verr, ok := err.(*ValidationError)
+			assert.Equal(t, true, ok)
+			assert.Equal(t, testSuite.expected, verr.Problems)
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	cfg, err := Load(Options{ConfigFile: writeConfig(t, testConfig)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Print(&buf, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	assert.Equal(t, false, strings.Contains(out, "db-password"))
+	assert.Equal(t, false, strings.Contains(out, "file-secret"))
+	assert.Equal(t, true, strings.Contains(out, "host: file-host"))
+	assert.Equal(t, "db-password", cfg.DB.Password)
+}
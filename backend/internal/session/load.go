@@ -0,0 +1,247 @@
+package session
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"neatly/pkg/logging"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	envPrefix  = "NEATLY_"
+	defaultTag = "env-default"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Options are taken from command line. Config file is set by --config flag,
+// by the only positional argument or by CONF_FILE env var, in this order.
+// Overrides hold values of flags named after config keys, e.g. --db.host.
+type Options struct {
+	ConfigFile  string
+	PrintConfig bool
+	Overrides   map[string]string
+}
+
+// ParseArgs parses command line without program name. Every config key
+// except lists of sections can be set by flag, see --help.
+func ParseArgs(args []string) (Options, error) {
+	opts := Options{Overrides: make(map[string]string)}
+
+	fs := flag.NewFlagSet("neatly", flag.ContinueOnError)
+	fs.StringVar(&opts.ConfigFile, "config", os.Getenv("CONF_FILE"), "path to YAML config file")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print config with secrets redacted, validate it and exit")
+
+	walk(reflect.ValueOf(&Config{}).Elem(), nil, func(path []string, _ reflect.Value, tag reflect.StructTag) {
+		name := flagName(path)
+		usage := fmt.Sprintf("overrides %s, same as %s env var", name, envName(path))
+		if def, ok := tag.Lookup(defaultTag); ok {
+			usage += fmt.Sprintf(" (default %q)", def)
+		}
+		fs.Func(name, usage, func(s string) error {
+			opts.Overrides[name] = s
+			return nil
+		})
+	})
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 1 {
+		return opts, fmt.Errorf("unexpected arguments %v, only config file can be passed", fs.Args())
+	}
+	if fs.NArg() == 1 && !isFlagSet(fs, "config") {
+		opts.ConfigFile = fs.Arg(0)
+	}
+	return opts, nil
+}
+
+// Load builds config in layers, each overriding the previous one: defaults
+// from env-default tags, config file, NEATLY_* env vars named after config
+// keys (e.g. NEATLY_DB_HOST for db.host) and command line flags. Lists are
+// comma separated in env vars and flags. Config is not validated here.
+func Load(opts Options) (*Config, error) {
+	cfg := &Config{}
+	root := reflect.ValueOf(cfg).Elem()
+
+	var err error
+	walk(root, nil, func(path []string, v reflect.Value, tag reflect.StructTag) {
+		if def, ok := tag.Lookup(defaultTag); ok && err == nil {
+			if e := setValue(v, def); e != nil {
+				err = fmt.Errorf("default of %s: %w", flagName(path), e)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ConfigFile != "" {
+		if err := readFile(opts.ConfigFile, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	walk(root, nil, func(path []string, v reflect.Value, _ reflect.StructTag) {
+		if s, ok := os.LookupEnv(envName(path)); ok && err == nil {
+			if e := setValue(v, s); e != nil {
+				err = fmt.Errorf("env var %s: %w", envName(path), e)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	walk(root, nil, func(path []string, v reflect.Value, _ reflect.StructTag) {
+		if s, ok := opts.Overrides[flagName(path)]; ok && err == nil {
+			if e := setValue(v, s); e != nil {
+				err = fmt.Errorf("flag --%s: %w", flagName(path), e)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// readFile decodes file over defaults already set in cfg, keys missing in
+// file keep defaults and unknown keys are rejected, so typos don't go
+// unnoticed
+func readFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("can't read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && err != io.EOF {
+		return fmt.Errorf("can't parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Print writes cfg as YAML with secrets replaced, so it can be shared
+func Print(w io.Writer, cfg *Config) error {
+	redacted := *cfg
+	walk(reflect.ValueOf(&redacted).Elem(), nil, func(path []string, v reflect.Value, _ reflect.StructTag) {
+		if v.Kind() == reflect.String && v.String() != "" && logging.IsSensitive(path[len(path)-1]) {
+			v.SetString(logging.Redacted)
+		}
+	})
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(redacted); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// walk calls fn for every field of config which can be set from a string,
+// path holds YAML keys leading to the field
+func walk(v reflect.Value, path []string, fn func(path []string, v reflect.Value, tag reflect.StructTag)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), key)
+
+		fv := v.Field(i)
+		switch {
+		case fv.Kind() == reflect.Struct:
+			walk(fv, fieldPath, fn)
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct:
+			// lists of sections can only be set in config file
+		default:
+			fn(fieldPath, fv, f.Tag)
+		}
+	}
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		items := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, part := range strings.Split(s, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			item := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(item, part); err != nil {
+				return err
+			}
+			items = reflect.Append(items, item)
+		}
+		v.Set(items)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func envName(path []string) string {
+	return envPrefix + strings.ToUpper(strings.Join(path, "_"))
+}
+
+func flagName(path []string) string {
+	return strings.Join(path, ".")
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
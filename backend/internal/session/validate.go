@@ -0,0 +1,190 @@
+package session
+
+import (
+	"fmt"
+	"neatly/pkg/ratelimit"
+	"neatly/pkg/tracing"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// insecureJWTSecret is the secret shipped in example configs, anyone can
+// sign tokens with it
+const insecureJWTSecret = "$ecr3t"
+
+// ValidationError lists every problem found in config, so all of them can
+// be fixed at once
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) add(key, format string, args ...interface{}) {
+	e.Problems = append(e.Problems, key+": "+fmt.Sprintf(format, args...))
+}
+
+func (c *Config) Debug() bool {
+	return c.IsDebug != nil && *c.IsDebug
+}
+
+// Validate checks values which would otherwise fail late or silently, e.g.
+// on first request
+func (c *Config) Validate() error {
+	v := &ValidationError{}
+
+	if !validPort(c.Listen.Port) {
+		v.add("listen.port", "%q is not a valid port", c.Listen.Port)
+	}
+	if c.Listen.BindIP != "" && c.Listen.BindIP != "localhost" && net.ParseIP(c.Listen.BindIP) == nil {
+		v.add("listen.bind_ip", "%q is not an IP", c.Listen.BindIP)
+	}
+	if c.Listen.ReadTimeout < 0 || c.Listen.WriteTimeout < 0 || c.Listen.IdleTimeout < 0 {
+		v.add("listen", "timeouts can't be negative")
+	}
+	if c.Listen.TLS.Enabled {
+		if c.Listen.TLS.CertFile == "" || c.Listen.TLS.KeyFile == "" {
+			v.add("listen.tls", "cert_file and key_file are required when tls is enabled")
+		}
+		if c.Listen.TLS.RedirectPort != "" && !validPort(c.Listen.TLS.RedirectPort) {
+			v.add("listen.tls.redirect_port", "%q is not a valid port", c.Listen.TLS.RedirectPort)
+		}
+	}
+	for _, proxy := range c.Listen.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				v.add("listen.trusted_proxies", "%q is neither IP nor CIDR", proxy)
+			}
+		}
+	}
+
+	if c.DB.Host == "" {
+		v.add("db.host", "is required")
+	}
+	if c.DB.DBName == "" {
+		v.add("db.dbname", "is required")
+	}
+
+	switch {
+	case c.JWT.Secret == "":
+		v.add("jwt.secret", "is required")
+	case c.JWT.Secret == insecureJWTSecret && !c.Debug():
+		v.add("jwt.secret", "default secret is allowed only when is_debug is true")
+	}
+
+	switch c.Cookie.SameSite {
+	case SameSiteLax, SameSiteStrict:
+	case SameSiteNone:
+		if !c.Cookie.Secure {
+			v.add("cookie.same_site", "none requires secure cookie")
+		}
+	default:
+		v.add("cookie.same_site", "must be %q, %q or %q", SameSiteLax, SameSiteStrict, SameSiteNone)
+	}
+	if c.Cookie.Name == "" {
+		v.add("cookie.name", "is required")
+	}
+
+	for _, origin := range c.CORS.AllowOrigins {
+		switch {
+		case origin == "*":
+			if c.CORS.AllowCredentials {
+				v.add("cors.allow_origins", "* can't be used with allow_credentials")
+			}
+		case !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://"):
+			v.add("cors.allow_origins", "%q must be * or start with http:// or https://", origin)
+		}
+	}
+	if len(c.CORS.AllowOrigins) == 0 {
+		v.add("cors.allow_origins", "at least one origin is required")
+	}
+
+	if c.Verification.UnverifiedAccess != UnverifiedAccessFull && c.Verification.UnverifiedAccess != UnverifiedAccessReadOnly {
+		v.add("verification.unverified_access", "must be %q or %q", UnverifiedAccessFull, UnverifiedAccessReadOnly)
+	}
+
+	if c.OIDC.Enabled {
+		if c.OIDC.Issuer == "" {
+			v.add("oidc.issuer", "is required when oidc is enabled")
+		}
+		if c.OIDC.ClientID == "" {
+			v.add("oidc.client_id", "is required when oidc is enabled")
+		}
+		if c.OIDC.RedirectURL == "" {
+			v.add("oidc.redirect_url", "is required when oidc is enabled")
+		}
+	}
+
+	if c.Password.MinLength < 1 {
+		v.add("password.min_length", "must be positive")
+	}
+	if c.Password.Memory < 1 || c.Password.Iterations < 1 || c.Password.Parallelism < 1 || c.Password.Parallelism > 255 {
+		v.add("password", "memory and iterations must be positive, parallelism between 1 and 255")
+	}
+
+	if c.Health.Timeout <= 0 {
+		v.add("health.timeout", "must be positive")
+	}
+	if c.Shutdown.Timeout <= 0 {
+		v.add("shutdown.timeout", "must be positive")
+	}
+	if c.Accounts.PurgeInterval <= 0 {
+		v.add("accounts.purge_interval", "must be positive")
+	}
+
+	if c.Tracing.Enabled {
+		if c.Tracing.Exporter != tracing.ExporterOTLP && c.Tracing.Exporter != tracing.ExporterStdout {
+			v.add("tracing.exporter", "must be %q or %q", tracing.ExporterOTLP, tracing.ExporterStdout)
+		}
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			v.add("tracing.sample_ratio", "must be between 0 and 1")
+		}
+	}
+
+	if c.RateLimit.Enabled {
+		c.validateRateLimit(v)
+	}
+
+	if len(v.Problems) > 0 {
+		return v
+	}
+	return nil
+}
+
+func (c *Config) validateRateLimit(v *ValidationError) {
+	if c.RateLimit.Store != RateLimitStoreMemory && c.RateLimit.Store != RateLimitStorePostgres {
+		v.add("rate_limit.store", "must be %q or %q", RateLimitStoreMemory, RateLimitStorePostgres)
+	}
+	if c.RateLimit.Store == RateLimitStorePostgres && c.RateLimit.PurgeInterval <= 0 {
+		v.add("rate_limit.purge_interval", "must be positive")
+	}
+
+	names := make(map[string]bool)
+	for i, g := range c.RateLimit.Groups {
+		key := fmt.Sprintf("rate_limit.groups[%d]", i)
+		if g.Name == "" {
+			v.add(key+".name", "is required")
+		} else if names[g.Name] {
+			v.add(key+".name", "%q is used by another group", g.Name)
+		}
+		names[g.Name] = true
+
+		if !strings.HasPrefix(g.Path, "/") {
+			v.add(key+".path", "must start with /")
+		}
+		if g.Requests < 1 || g.Period <= 0 || g.Burst < 1 {
+			v.add(key, "requests, period and burst must be positive")
+		}
+		if g.Key != ratelimit.KeyByUser && g.Key != ratelimit.KeyByIP {
+			v.add(key+".key", "must be %q or %q", ratelimit.KeyByUser, ratelimit.KeyByIP)
+		}
+	}
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
@@ -5,17 +5,21 @@ import (
 	"neatly/internal/session"
 	"neatly/pkg/logging"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
 type Client struct {
 	DB *sqlx.DB
 }
 
+// NewClient connects to database through instrumented driver, so every
+// query gets a span in trace of request it was made for
 func NewClient(cfg session.DB) (*Client, error) {
-	db, err := sqlx.Open("postgres", fmt.Sprintf(
+	sqlDB, err := otelsql.Open("postgres", fmt.Sprintf(
 		"host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
 		cfg.Host,
 		cfg.Port,
@@ -23,11 +27,12 @@ func NewClient(cfg session.DB) (*Client, error) {
 		cfg.DBName,
 		cfg.Password,
 		cfg.SSLMode,
-	))
+	), otelsql.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBName(cfg.DBName)))
 	if err != nil {
 		logging.GetLogger().Info("Error while connecting to db")
 		return nil, err
 	}
+	db := sqlx.NewDb(sqlDB, "postgres")
 
 	err = db.Ping()
 	if err != nil {
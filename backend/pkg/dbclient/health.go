@@ -0,0 +1,76 @@
+package dbclient
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Check pings database, it lets Client be registered as health checker
+func (c *Client) Check(ctx context.Context) error {
+	return c.DB.PingContext(ctx)
+}
+
+// MigrationChecker verifies that schema is at the latest migration found in
+// migrationsPath and is not left dirty by failed migration
+type MigrationChecker struct {
+	client  *Client
+	version uint
+}
+
+func NewMigrationChecker(client *Client, migrationsPath string) (*MigrationChecker, error) {
+	version, err := latestMigration(migrationsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MigrationChecker{client: client, version: version}, nil
+}
+
+func (m *MigrationChecker) Check(ctx context.Context) error {
+	var (
+		version uint
+		dirty   bool
+	)
+	query := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+	if err := m.client.DB.QueryRowContext(ctx, query).Scan(&version, &dirty); err != nil {
+		return err
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("migration %d is dirty", version)
+	case version != m.version:
+		return fmt.Errorf("schema version is %d, expected %d", version, m.version)
+	}
+	return nil
+}
+
+// latestMigration returns the highest version among NNN_name.up.sql files
+func latestMigration(migrationsPath string) (uint, error) {
+	entries, err := os.ReadDir(migrationsPath)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".up.sql") {
+			continue
+		}
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations found in %s", migrationsPath)
+	}
+	return latest, nil
+}
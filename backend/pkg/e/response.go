@@ -8,9 +8,38 @@ import (
 var (
 	ClientNoteError      = errors.New("note does not exist or does not belong to user")
 	ClientTagError       = errors.New("tag does not exist or does not belong to user")
+	ClientTemplateError  = errors.New("template does not exist or does not belong to user")
 	ClientAuthorizeError = errors.New("user with this credentials can not be found")
 	ClientAccountError   = errors.New("username already exists")
+	ClientProfileError   = errors.New("name and username can not be empty")
+	ClientPasswordError  = errors.New("current password does not match")
+	SessionRevokedError  = errors.New("session has been revoked, please log in again")
+	LoginLockedError     = errors.New("too many failed login attempts, try again later")
+	ClientOIDCError      = errors.New("single sign-on failed, please try again")
+	ClientTokenError     = errors.New("token is invalid or has expired")
+	ClientEmailError     = errors.New("email is not valid")
+	ClientVerifiedError  = errors.New("email is already verified")
+	UnverifiedError      = errors.New("email is not verified, account is read-only")
+	ClientBatchError     = errors.New("batch request is invalid")
+	BatchAbortedError    = errors.New("batch operation failed, no changes were applied")
 	InternalDBError      = errors.New("database error occurred")
+
+	ClientTwoFactorCodeError     = errors.New("two-factor code is invalid or has already been used")
+	ClientTwoFactorEnabledError  = errors.New("two-factor authentication is already enabled")
+	ClientTwoFactorDisabledError = errors.New("two-factor authentication is not enabled")
+	ClientTwoFactorEnrolError    = errors.New("two-factor enrolment has not been started")
+
+	ClientPasswordPolicyError = errors.New("password is too weak")
+	ClientSessionError        = errors.New("session does not exist or does not belong to user")
+
+	AccountDisabledError = errors.New("account is disabled")
+	AdminRequiredError   = errors.New("admin role is required")
+	ClientUserError      = errors.New("user does not exist")
+	ClientAdminError     = errors.New("operation is not allowed on admin accounts or own account")
+	ImpersonationError   = errors.New("operation is not allowed in impersonated session")
+
+	RateLimitedError = errors.New("too many requests, try again later")
+	CSRFError        = errors.New("CSRF token is missing or invalid")
 )
 
 func NewErrorResponse(ctx *gin.Context, status int, err error) {
@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrShuttingDown = errors.New("shutting down")
+
+// HealthChecker reports whether dependency is usable, ctx is cancelled when
+// check takes longer than registry timeout
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc lets plain function be registered as HealthChecker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Registry keeps dependencies which have to be healthy for instance to
+// receive traffic
+type Registry struct {
+	mu         sync.RWMutex
+	checkers   map[string]HealthChecker
+	timeout    time.Duration
+	drainDelay time.Duration
+	draining   int32
+}
+
+func NewRegistry(timeout, drainDelay time.Duration) *Registry {
+	return &Registry{
+		checkers:   map[string]HealthChecker{},
+		timeout:    timeout,
+		drainDelay: drainDelay,
+	}
+}
+
+func (r *Registry) Register(name string, checker HealthChecker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers[name] = checker
+}
+
+// Ready runs all checks concurrently and returns result of each of them,
+// nil error means check passed
+func (r *Registry) Ready(ctx context.Context) (map[string]error, bool) {
+	if atomic.LoadInt32(&r.draining) == 1 {
+		return map[string]error{"shutdown": ErrShuttingDown}, false
+	}
+
+	r.mu.RLock()
+	checkers := make(map[string]HealthChecker, len(r.checkers))
+	for name, checker := range r.checkers {
+		checkers[name] = checker
+	}
+	r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string]error, len(checkers))
+	)
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker HealthChecker) {
+			defer wg.Done()
+			err := checker.Check(ctx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, checker)
+	}
+	wg.Wait()
+
+	ready := true
+	for _, err := range results {
+		if err != nil {
+			ready = false
+		}
+	}
+	return results, ready
+}
+
+// Drain makes readiness fail and waits for drain delay, so load balancers
+// stop routing to instance before its server stops accepting connections
+func (r *Registry) Drain(ctx context.Context) error {
+	atomic.StoreInt32(&r.draining, 1)
+
+	timer := time.NewTimer(r.drainDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,86 @@
+//go:build unit
+// +build unit
+
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Ready(t *testing.T) {
+	failing := errors.New("connection refused")
+	ok := CheckerFunc(func(context.Context) error { return nil })
+	slow := CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	testSuites := []struct {
+		testName        string
+		checkers        map[string]HealthChecker
+		expectedReady   bool
+		expectedResults map[string]error
+	}{
+		{
+			testName:        "NoCheckers",
+			checkers:        map[string]HealthChecker{},
+			expectedReady:   true,
+			expectedResults: map[string]error{},
+		},
+		{
+			testName:        "AllPassed",
+			checkers:        map[string]HealthChecker{"database": ok, "migrations": ok},
+			expectedReady:   true,
+			expectedResults: map[string]error{"database": nil, "migrations": nil},
+		},
+		{
+			testName: "OneFailed",
+			checkers: map[string]HealthChecker{
+				"database":   ok,
+				"migrations": CheckerFunc(func(context.Context) error { return failing }),
+			},
+			expectedReady:   false,
+			expectedResults: map[string]error{"database": nil, "migrations": failing},
+		},
+		{
+			testName:        "TimedOut",
+			checkers:        map[string]HealthChecker{"database": slow},
+			expectedReady:   false,
+			expectedResults: map[string]error{"database": context.DeadlineExceeded},
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			r := NewRegistry(10*time.Millisecond, 0)
+			for name, checker := range testSuite.checkers {
+				r.Register(name, checker)
+			}
+
+			results, ready := r.Ready(context.Background())
+
+			assert.Equal(t, testSuite.expectedReady, ready)
+			assert.Equal(t, testSuite.expectedResults, results)
+		})
+	}
+}
+
+func TestRegistry_Drain(t *testing.T) {
+	r := NewRegistry(time.Second, 0)
+	r.Register("database", CheckerFunc(func(context.Context) error { return nil }))
+
+	_, ready := r.Ready(context.Background())
+	assert.Equal(t, true, ready)
+
+	if err := r.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	results, ready := r.Ready(context.Background())
+	assert.Equal(t, false, ready)
+	assert.Equal(t, map[string]error{"shutdown": ErrShuttingDown}, results)
+}
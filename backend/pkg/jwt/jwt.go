@@ -1,6 +1,10 @@
 package jwt
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"github.com/cristalhq/jwt/v3"
@@ -9,15 +13,53 @@ import (
 )
 
 const (
-	tokenTTL = 12 * time.Hour
+	AccessTokenTTL = 12 * time.Hour
+	challengeTTL   = 5 * time.Minute
+
+	purposeChallenge = "2fa"
+	purposeCSRF      = "csrf"
 )
 
+// UserClaims carries session version of account. Access tokens carry ID of
+// server-side session record, and the record decides whether token is still
+// valid. Challenge tokens are rejected once account session version changes.
+// Tokens with purpose can not be used for access. ImpersonatorID is set in
+// tokens admin got by impersonating account.
 type UserClaims struct {
 	jwt.RegisteredClaims
-	UserID int
+	UserID         int
+	Version        int
+	SessionID      string `json:",omitempty"`
+	Purpose        string `json:",omitempty"`
+	ImpersonatorID int    `json:",omitempty"`
+}
+
+func GenerateAccessToken(id, version int, sessionID string) (string, error) {
+	return generate(UserClaims{UserID: id, Version: version, SessionID: sessionID}, AccessTokenTTL)
+}
+
+// GenerateImpersonationToken issues access token of account for admin
+// impersonatorID
+func GenerateImpersonationToken(id, version int, sessionID string, impersonatorID int) (string, error) {
+	claims := UserClaims{UserID: id, Version: version, SessionID: sessionID, ImpersonatorID: impersonatorID}
+	return generate(claims, AccessTokenTTL)
+}
+
+// GenerateChallengeToken issues short-lived token which proves that password
+// has been checked and second factor is expected. Token has random ID, so
+// wrong codes sent with it can be counted.
+func GenerateChallengeToken(id, version int) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	claims := UserClaims{UserID: id, Version: version, Purpose: purposeChallenge}
+	claims.ID = hex.EncodeToString(buf)
+
+	return generate(claims, challengeTTL)
 }
 
-func GenerateAccessToken(id int) (string, error) {
+func generate(claims UserClaims, ttl time.Duration) (string, error) {
 	key := []byte(session.GetConfig().JWT.Secret)
 
 	signer, err := jwt.NewSignerHS(jwt.HS256, key)
@@ -25,12 +67,10 @@ func GenerateAccessToken(id int) (string, error) {
 		return "", err
 	}
 	builder := jwt.NewBuilder(signer)
-	claims := UserClaims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenTTL)),
-		},
-		UserID: id,
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        claims.ID,
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 	}
 
 	token, err := builder.Build(claims)
@@ -42,25 +82,68 @@ func GenerateAccessToken(id int) (string, error) {
 }
 
 func GetIdFromToken(token string) (int, error) {
+	uc, err := ParseAccessToken(token)
+	if err != nil {
+		return 0, err
+	}
+	return uc.UserID, nil
+}
+
+func ParseAccessToken(token string) (UserClaims, error) {
+	uc, err := parse(token)
+	if err != nil {
+		return uc, err
+	}
+	if uc.Purpose != "" {
+		return uc, errors.New("token can not be used for access")
+	}
+	return uc, nil
+}
+
+func ParseChallengeToken(token string) (UserClaims, error) {
+	uc, err := parse(token)
+	if err != nil {
+		return uc, err
+	}
+	if uc.Purpose != purposeChallenge || uc.ID == "" {
+		return uc, errors.New("token is not a challenge token")
+	}
+	return uc, nil
+}
+
+// CSRFToken is derived from session, so it can be checked without being
+// stored. Requests authenticated by cookie have to send it in header.
+func CSRFToken(sessionID string) string {
+	mac := hmac.New(sha256.New, []byte(session.GetConfig().JWT.Secret))
+	mac.Write([]byte(purposeCSRF + ":" + sessionID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func ValidCSRFToken(sessionID, token string) bool {
+	return hmac.Equal([]byte(CSRFToken(sessionID)), []byte(token))
+}
+
+func parse(token string) (UserClaims, error) {
+	var uc UserClaims
+
 	key := []byte(session.GetConfig().JWT.Secret)
 	verifier, err := jwt.NewVerifierHS(jwt.HS256, key)
 	if err != nil {
-		return 0, err
+		return uc, err
 	}
 
 	tok, err := jwt.ParseAndVerifyString(token, verifier)
 	if err != nil {
-		return 0, err
+		return uc, err
 	}
 
-	var uc UserClaims
 	err = json.Unmarshal(tok.RawClaims(), &uc)
 	if err != nil {
-		return 0, err
+		return uc, err
 	}
 	if valid := uc.IsValidAt(time.Now()); !valid {
-		return 0, errors.New("token has been expired")
+		return uc, errors.New("token has been expired")
 	}
 
-	return uc.UserID, nil
+	return uc, nil
 }
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	userIDKey
+	clientIPKey
+)
+
+const (
+	RequestIDField = "request_id"
+	UserIDField    = "user_id"
+	TraceIDField   = "trace_id"
+	SpanIDField    = "span_id"
+)
+
+// WithRequestID stores ID of request in ctx, so it is added to every entry
+// logged with this ctx
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithUserID stores ID of authenticated user in ctx, so it is added to
+// every entry logged with this ctx
+func WithUserID(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+func UserID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(userIDKey).(int)
+	return id, ok
+}
+
+// WithClientIP stores IP of client in ctx for audit log. Unlike IDs it is
+// not added to log entries.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey).(string)
+	return ip
+}
+
+// contextHook copies request, user and trace IDs from context of entry,
+// which is set by Logger.WithContext
+type contextHook struct{}
+
+func (contextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (contextHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id := RequestID(entry.Context); id != "" {
+		entry.Data[RequestIDField] = id
+	}
+	if id, ok := UserID(entry.Context); ok {
+		entry.Data[UserIDField] = id
+	}
+	if sc := trace.SpanContextFromContext(entry.Context); sc.IsValid() {
+		entry.Data[TraceIDField] = sc.TraceID().String()
+		entry.Data[SpanIDField] = sc.SpanID().String()
+	}
+	return nil
+}
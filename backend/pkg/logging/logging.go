@@ -3,32 +3,44 @@ package logging
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-type writerHook struct {
-	Writer    []io.Writer
-	LogLevels []logrus.Level
-}
+const (
+	FormatText = "text"
+	FormatJSON = "json"
 
-func (hook *writerHook) Fire(entry *logrus.Entry) error {
-	line, err := entry.String()
-	if err != nil {
-		return err
-	}
-	for _, w := range hook.Writer {
-		_, err = w.Write([]byte(line))
-	}
-	return err
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+// Config defines where and how logs are written. File output is rotated
+// when it grows over MaxSize megabytes, MaxBackups old files are kept for at
+// most MaxAge days.
+type Config struct {
+	Level      string
+	Format     string
+	Outputs    []string
+	File       string
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+	Compress   bool
 }
 
-func (hook *writerHook) Levels() []logrus.Level {
-	return hook.LogLevels
+// DefaultConfig is used until application config is read, and by tests. It
+// writes to stdout only, so no log file is created before config says so.
+var DefaultConfig = Config{
+	Level:   "trace",
+	Format:  FormatText,
+	Outputs: []string{OutputStdout},
 }
 
 var e *logrus.Entry
@@ -46,36 +58,67 @@ func (l *Logger) GetLoggerWithField(k string, v interface{}) Logger {
 }
 
 func Init() {
+	if err := Configure(DefaultConfig); err != nil {
+		panic(err)
+	}
+}
+
+// Configure replaces global logger, loggers got from GetLogger before keep
+// writing with previous settings
+func Configure(cfg Config) error {
 	l := logrus.New()
 	l.SetReportCaller(true)
-	l.Formatter = &logrus.TextFormatter{
-		CallerPrettyfier: func(f *runtime.Frame) (string, string) {
-			filename := path.Base(f.File)
-			return fmt.Sprintf("%s:%d", filename, f.Line), fmt.Sprintf("%s()", f.Function)
-		},
-		DisableColors: false,
-		FullTimestamp: true,
-	}
 
-	err := os.MkdirAll("build/logs", 0755)
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	l.SetLevel(level)
 
-	if err != nil || os.IsExist(err) {
-		panic("can't create log dir. no configured logging to files")
-	} else {
-		allFile, err := os.OpenFile("build/logs/all.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0660)
-		if err != nil {
-			panic(err)
+	callerPrettyfier := func(f *runtime.Frame) (string, string) {
+		filename := path.Base(f.File)
+		return fmt.Sprintf("%s:%d", filename, f.Line), fmt.Sprintf("%s()", f.Function)
+	}
+	switch cfg.Format {
+	case FormatText:
+		l.Formatter = &logrus.TextFormatter{
+			CallerPrettyfier: callerPrettyfier,
+			DisableColors:    false,
+			FullTimestamp:    true,
 		}
+	case FormatJSON:
+		l.Formatter = &logrus.JSONFormatter{CallerPrettyfier: callerPrettyfier}
+	default:
+		return fmt.Errorf("unknown log format %q", cfg.Format)
+	}
 
-		l.SetOutput(ioutil.Discard)
-
-		l.AddHook(&writerHook{
-			Writer:    []io.Writer{allFile, os.Stdout},
-			LogLevels: logrus.AllLevels,
-		})
+	writers := make([]io.Writer, 0, len(cfg.Outputs))
+	for _, output := range cfg.Outputs {
+		switch output {
+		case OutputStdout:
+			writers = append(writers, os.Stdout)
+		case OutputStderr:
+			writers = append(writers, os.Stderr)
+		case OutputFile:
+			if err := os.MkdirAll(filepath.Dir(cfg.File), 0755); err != nil {
+				return fmt.Errorf("can't create log dir: %w", err)
+			}
+			writers = append(writers, &lumberjack.Logger{
+				Filename:   cfg.File,
+				MaxSize:    cfg.MaxSize,
+				MaxBackups: cfg.MaxBackups,
+				MaxAge:     cfg.MaxAge,
+				Compress:   cfg.Compress,
+			})
+		default:
+			return fmt.Errorf("unknown log output %q", output)
+		}
 	}
+	l.SetOutput(io.MultiWriter(writers...))
 
-	l.SetLevel(logrus.TraceLevel)
+	l.AddHook(contextHook{})
+	l.AddHook(redactHook{})
 
 	e = logrus.NewEntry(l)
+	return nil
 }
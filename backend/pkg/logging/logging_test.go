@@ -0,0 +1,86 @@
+//go:build unit
+// +build unit
+
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-playground/assert/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigure_JSON(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "logs", "all.log")
+	err := Configure(Config{Level: "info", Format: FormatJSON, Outputs: []string{OutputFile}, File: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithUserID(WithRequestID(context.Background(), "req-1"), 7)
+	logger := GetLogger()
+	logger.WithContext(ctx).WithField("password_hash", "$argon2id$secret").Info("logged in")
+	logger.Debug("below level")
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "logged in", entry["msg"])
+	assert.Equal(t, "req-1", entry[RequestIDField])
+	assert.Equal(t, float64(7), entry[UserIDField])
+	assert.Equal(t, Redacted, entry["password_hash"])
+}
+
+func TestInit_NoFile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	Init()
+	GetLogger().Info("bootstrap")
+
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, len(entries))
+}
+
+func TestConfigure_Invalid(t *testing.T) {
+	testSuites := []struct {
+		testName string
+		cfg      Config
+	}{
+		{
+			testName: "UnknownLevel",
+			cfg:      Config{Level: "loud", Format: FormatText},
+		},
+		{
+			testName: "UnknownFormat",
+			cfg:      Config{Level: "info", Format: "xml"},
+		},
+		{
+			testName: "UnknownOutput",
+			cfg:      Config{Level: "info", Format: FormatText, Outputs: []string{"syslog"}},
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			assert.NotEqual(t, nil, Configure(testSuite.cfg))
+		})
+	}
+}
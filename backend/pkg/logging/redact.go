@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const Redacted = "[REDACTED]"
+
+// sensitiveFields are replaced in every entry, matching is case-insensitive
+// and by substring, so e.g. password_hash and client_secret are covered too
+var sensitiveFields = []string{"password", "secret", "token", "hash"}
+
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(entry *logrus.Entry) error {
+	for k := range entry.Data {
+		if IsSensitive(k) {
+			entry.Data[k] = Redacted
+		}
+	}
+	return nil
+}
+
+func IsSensitive(field string) bool {
+	field = strings.ToLower(field)
+	for _, s := range sensitiveFields {
+		if strings.Contains(field, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"neatly/internal/session"
+	"neatly/pkg/logging"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+	DriverLog  = "log"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(m Message) error
+}
+
+// NewMailer creates mailer configured by driver name
+func NewMailer(cfg session.Mail, logger logging.Logger) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(cfg), nil
+	case DriverFile:
+		return NewFileMailer(cfg.From, cfg.Dir, logger)
+	case DriverLog, "":
+		return NewLogMailer(cfg.From, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(cfg session.Mail) *SMTPMailer {
+	m := &SMTPMailer{
+		addr: fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
+		from: cfg.From,
+	}
+	if cfg.Username != "" {
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return m
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, render(m.from, msg))
+}
+
+// FileMailer stores every message as .eml file in directory
+type FileMailer struct {
+	from   string
+	dir    string
+	logger logging.Logger
+}
+
+func NewFileMailer(from, dir string, logger logging.Logger) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{from: from, dir: dir, logger: logger}, nil
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To))
+	path := filepath.Join(m.dir, name)
+
+	m.logger.Infof("Writing mail to %v", path)
+	return os.WriteFile(path, render(m.from, msg), 0600)
+}
+
+// LogMailer writes messages to application log instead of sending them
+type LogMailer struct {
+	from   string
+	logger logging.Logger
+}
+
+func NewLogMailer(from string, logger logging.Logger) *LogMailer {
+	return &LogMailer{from: from, logger: logger}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	m.logger.Infof("Mail to %v: %s", msg.To, render(m.from, msg))
+	return nil
+}
+
+func render(from string, msg Message) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return b.Bytes()
+}
@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"neatly/pkg/logging"
+)
+
+var ErrQueueFull = errors.New("mail queue is full")
+
+// Queue sends messages through wrapped mailer in background, so callers
+// don't wait for SMTP and response time doesn't depend on whether mail was
+// sent. Sending failures are only logged.
+type Queue struct {
+	mailer   Mailer
+	messages chan Message
+	logger   logging.Logger
+}
+
+func NewQueue(mailer Mailer, size int, logger logging.Logger) *Queue {
+	return &Queue{mailer: mailer, messages: make(chan Message, size), logger: logger}
+}
+
+// Send puts message to queue without waiting for it to be sent
+func (q *Queue) Send(m Message) error {
+	select {
+	case q.messages <- m:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run sends queued messages until ctx is cancelled, then sends the ones
+// which are left in queue
+func (q *Queue) Run(ctx context.Context) {
+	for {
+		select {
+		case m := <-q.messages:
+			q.send(m)
+		case <-ctx.Done():
+			for {
+				select {
+				case m := <-q.messages:
+					q.send(m)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *Queue) send(m Message) {
+	if err := q.mailer.Send(m); err != nil {
+		q.logger.Errorf("Can't send mail %q: %v", m.Subject, err)
+	}
+}
@@ -0,0 +1,45 @@
+//go:build unit
+// +build unit
+
+package mail
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"neatly/pkg/logging"
+	"neatly/pkg/testutils"
+	"testing"
+)
+
+// recorder keeps sent messages in memory
+type recorder struct {
+	sent []Message
+}
+
+func (r *recorder) Send(m Message) error {
+	r.sent = append(r.sent, m)
+	return nil
+}
+
+func TestQueue_Send(t *testing.T) {
+	logging.Init()
+	r := &recorder{}
+	q := NewQueue(r, 2, logging.GetLogger())
+
+	assert.Equal(t, nil, q.Send(Message{To: "first@neat.ly"}))
+	assert.Equal(t, nil, q.Send(Message{To: "second@neat.ly"}))
+	assert.Equal(t, ErrQueueFull, q.Send(Message{To: "third@neat.ly"}))
+	assert.Equal(t, 0, len(r.sent))
+
+	// queued messages are sent even when queue is already stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.Run(ctx)
+
+	assert.Equal(t, []Message{{To: "first@neat.ly"}, {To: "second@neat.ly"}}, r.sent)
+
+	err := testutils.CleanupLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,41 @@
+//go:build unit
+// +build unit
+
+package metrics
+
+import (
+	"github.com/go-playground/assert/v2"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	NotesCreated.Inc()
+	LoginsFailed.WithLabelValues("password").Inc()
+	ObserveQuery("note", "Create", time.Now())
+	HTTPRequests.WithLabelValues("GET", "/api/v1/notes", "200").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testSuites := []string{
+		`neatly_notes_created_total 1`,
+		`neatly_logins_failed_total{method="password"} 1`,
+		`neatly_db_query_duration_seconds_count{method="Create",repository="note"} 1`,
+		`neatly_http_requests_total{method="GET",route="/api/v1/notes",status="200"} 1`,
+		`go_goroutines`,
+	}
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	for _, expected := range testSuites {
+		assert.Equal(t, true, strings.Contains(string(body), expected))
+	}
+}
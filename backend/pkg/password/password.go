@@ -0,0 +1,131 @@
+// Package password hashes passwords with argon2id and enforces password
+// policy. Hashes are stored in PHC string format, so parameters can be
+// changed without breaking existing hashes. Legacy bcrypt hashes are still
+// accepted and reported as needing rehash.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"sync"
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+	saltLength     = 16
+	keyLength      = 32
+)
+
+var (
+	ErrMismatch      = errors.New("password does not match")
+	ErrInvalidHash   = errors.New("password hash has unknown format")
+	encoding         = base64.RawStdEncoding
+	mu               sync.RWMutex
+	configuredParams = DefaultParams
+	configuredPolicy = DefaultPolicy
+)
+
+// Params are argon2id cost parameters, memory is in KiB
+type Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultParams follow OWASP recommendation for argon2id
+var DefaultParams = Params{Memory: 19 * 1024, Iterations: 2, Parallelism: 1}
+
+// Configure replaces parameters used for new hashes and policy used by
+// Validate. It is expected to be called once on startup.
+func Configure(policy Policy, params Params) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	configuredPolicy = policy
+	configuredParams = params
+}
+
+func currentParams() Params {
+	mu.RLock()
+	defer mu.RUnlock()
+	return configuredParams
+}
+
+// Hash returns argon2id hash of password with configured parameters
+func Hash(password string) (string, error) {
+	p := currentParams()
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, keyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism, encoding.EncodeToString(salt), encoding.EncodeToString(key)), nil
+}
+
+// Compare checks password against argon2id or bcrypt hash
+func Compare(hash, password string) error {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+			return ErrMismatch
+		}
+		return nil
+	}
+
+	p, salt, key, err := decode(hash)
+	if err != nil {
+		return err
+	}
+	actual := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, actual) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// NeedsRehash reports whether hash was created by other algorithm or with
+// parameters different from configured ones
+func NeedsRehash(hash string) bool {
+	p, _, _, err := decode(hash)
+	if err != nil {
+		return true
+	}
+	return p != currentParams()
+}
+
+func decode(hash string) (Params, []byte, []byte, error) {
+	var (
+		p       Params
+		version int
+	)
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || !strings.HasPrefix(hash, argon2idPrefix) {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := encoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	key, err := encoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,77 @@
+//go:build unit
+// +build unit
+
+package password
+
+import (
+	"errors"
+	"github.com/go-playground/assert/v2"
+	"golang.org/x/crypto/bcrypt"
+	"neatly/pkg/e"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	hash, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, nil, Compare(hash, "correct horse"))
+	assert.Equal(t, ErrMismatch, Compare(hash, "battery staple"))
+	assert.Equal(t, false, NeedsRehash(hash))
+
+	other, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, hash, other)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, nil, Compare(string(legacy), "correct horse"))
+	assert.Equal(t, true, NeedsRehash(string(legacy)))
+
+	hash, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Configure(DefaultPolicy, Params{Memory: 8 * 1024, Iterations: 1, Parallelism: 1})
+	defer Configure(DefaultPolicy, DefaultParams)
+
+	assert.Equal(t, true, NeedsRehash(hash))
+	assert.Equal(t, nil, Compare(hash, "correct horse"))
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	policy := Policy{MinLength: 8, MinClasses: 3, RejectCommon: true}
+
+	testSuites := []struct {
+		testName string
+		password string
+		valid    bool
+	}{
+		{"Valid", "Neat1y notes", true},
+		{"TooShort", "Ab1!", false},
+		{"LengthCountsCharacters", "Пароль1!", true},
+		{"NotEnoughClasses", "onlyletters", false},
+		{"Common", "Password123", false},
+		{"CommonIgnoresCase", "PASSWORD1!", false},
+	}
+
+	for _, test := range testSuites {
+		t.Run(test.testName, func(t *testing.T) {
+			err := policy.Validate(test.password)
+
+			assert.Equal(t, test.valid, err == nil)
+			if err != nil {
+				assert.Equal(t, true, errors.Is(err, e.ClientPasswordPolicyError))
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package password
+
+import (
+	_ "embed"
+	"fmt"
+	"neatly/pkg/e"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common.txt
+var commonList string
+
+// common contains lowercased passwords from bundled list of most used ones
+var common = func() map[string]struct{} {
+	m := make(map[string]struct{})
+	for _, line := range strings.Split(commonList, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			m[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return m
+}()
+
+// Policy defines requirements for new passwords. Character classes are
+// lowercase and uppercase letters, digits and other symbols.
+type Policy struct {
+	MinLength    int
+	MinClasses   int
+	RejectCommon bool
+}
+
+var DefaultPolicy = Policy{MinLength: 8, MinClasses: 2, RejectCommon: true}
+
+// Validate checks password against configured policy, returned error wraps
+// e.ClientPasswordPolicyError and explains which requirement is not met
+func Validate(password string) error {
+	mu.RLock()
+	p := configuredPolicy
+	mu.RUnlock()
+
+	return p.Validate(password)
+}
+
+func (p Policy) Validate(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: it must be at least %d characters long", e.ClientPasswordPolicyError, p.MinLength)
+	}
+	if classes(password) < p.MinClasses {
+		return fmt.Errorf("%w: it must contain at least %d of lowercase letters, uppercase letters, digits and symbols",
+			e.ClientPasswordPolicyError, p.MinClasses)
+	}
+	if _, ok := common[strings.ToLower(password)]; p.RejectCommon && ok {
+		return fmt.Errorf("%w: it is too common", e.ClientPasswordPolicyError)
+	}
+	return nil
+}
+
+func classes(password string) int {
+	var lower, upper, digit, other int
+
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+
+	return lower + upper + digit + other
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type memoryEntry struct {
+	bucket Bucket
+	limit  Limit
+}
+
+// MemoryStore keeps buckets in process, so every replica limits clients on
+// its own. Full buckets are dropped once a minute.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > sweepInterval {
+		s.sweep(now)
+	}
+
+	e, ok := s.entries[key]
+	if !ok {
+		e.bucket = NewBucket(limit, now)
+	}
+	bucket, res := e.bucket.Take(limit, now)
+	s.entries[key] = memoryEntry{bucket: bucket, limit: limit}
+	return res, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for k, e := range s.entries {
+		if now.Sub(e.bucket.UpdatedAt) >= e.limit.FullAfter() {
+			delete(s.entries, k)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	KeyByUser = "user"
+	KeyByIP   = "ip"
+)
+
+// Limit is a token bucket which holds at most Burst tokens and gets Rate
+// tokens back every second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerPeriod spreads requests evenly over period, e.g. 60 per minute is one
+// token per second
+func PerPeriod(requests int, period time.Duration, burst int) Limit {
+	return Limit{Rate: float64(requests) / period.Seconds(), Burst: burst}
+}
+
+// FullAfter is how long empty bucket takes to refill
+func (l Limit) FullAfter() time.Duration {
+	return l.after(float64(l.Burst))
+}
+
+func (l Limit) after(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	if l.Rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(tokens / l.Rate * float64(time.Second))
+}
+
+// Result of taking a token. Reset is time until bucket is full again,
+// RetryAfter is time until next token is available if request was denied.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type Bucket struct {
+	Tokens    float64   `db:"tokens"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// NewBucket is a full bucket, any key seen for the first time starts with it
+func NewBucket(limit Limit, now time.Time) Bucket {
+	return Bucket{Tokens: float64(limit.Burst), UpdatedAt: now}
+}
+
+// Take refills bucket for time passed since it was updated and takes one
+// token out of it, if there is one. Stores keep returned bucket.
+func (b Bucket) Take(limit Limit, now time.Time) (Bucket, Result) {
+	if elapsed := now.Sub(b.UpdatedAt); elapsed > 0 {
+		b.Tokens = math.Min(float64(limit.Burst), b.Tokens+elapsed.Seconds()*limit.Rate)
+		b.UpdatedAt = now
+	}
+
+	res := Result{Limit: limit.Burst}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = limit.after(1 - b.Tokens)
+	}
+	res.Remaining = int(b.Tokens)
+	res.Reset = limit.after(float64(limit.Burst) - b.Tokens)
+	return b, res
+}
+
+// Store keeps buckets by key. Take has to be atomic, as the same key is
+// taken from concurrently.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// Rule limits requests to routes starting with Path. Methods restrict rule
+// to some methods only, all methods match when it is empty. Requests are
+// counted by authenticated user or client IP, depending on KeyBy.
+type Rule struct {
+	Name    string
+	Path    string
+	Methods []string
+	Limit   Limit
+	KeyBy   string
+}
+
+func (r Rule) matches(method, route string) bool {
+	if !strings.HasPrefix(route, r.Path) {
+		return false
+	}
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+type Limiter struct {
+	store Store
+	rules []Rule
+}
+
+func NewLimiter(store Store, rules ...Rule) *Limiter {
+	return &Limiter{store: store, rules: rules}
+}
+
+// Match returns the first rule for route, so more specific rules have to
+// go before general ones
+func (l *Limiter) Match(method, route string) (Rule, bool) {
+	for _, r := range l.rules {
+		if r.matches(method, route) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Take takes token of client from bucket of rule, every rule has own
+// buckets
+func (l *Limiter) Take(ctx context.Context, rule Rule, client string) (Result, error) {
+	return l.store.Take(ctx, rule.Name+":"+client, rule.Limit, time.Now())
+}
+
+// FullAfter is the longest refill time among rules, buckets idle for longer
+// are full and can be dropped
+func (l *Limiter) FullAfter() time.Duration {
+	var max time.Duration
+	for _, r := range l.rules {
+		if d := r.Limit.FullAfter(); d > max {
+			max = d
+		}
+	}
+	return max
+}
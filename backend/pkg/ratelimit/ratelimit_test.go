@@ -0,0 +1,134 @@
+//go:build unit
+// +build unit
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestBucket_Take(t *testing.T) {
+	limit := PerPeriod(60, time.Minute, 2)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testSuites := []struct {
+		testName string
+		bucket   Bucket
+		now      time.Time
+		expected Result
+	}{
+		{
+			testName: "FullBucket",
+			bucket:   NewBucket(limit, now),
+			now:      now,
+			expected: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second},
+		},
+		{
+			testName: "EmptyBucket",
+			bucket:   Bucket{Tokens: 0.5, UpdatedAt: now},
+			now:      now,
+			expected: Result{Allowed: false, Limit: 2, Remaining: 0, Reset: 1500 * time.Millisecond, RetryAfter: 500 * time.Millisecond},
+		},
+		{
+			testName: "Refilled",
+			bucket:   Bucket{Tokens: 0, UpdatedAt: now},
+			now:      now.Add(time.Second),
+			expected: Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second},
+		},
+		{
+			testName: "RefillCappedAtBurst",
+			bucket:   Bucket{Tokens: 0, UpdatedAt: now},
+			now:      now.Add(time.Hour),
+			expected: Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second},
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			_, res := testSuite.bucket.Take(limit, testSuite.now)
+			assert.Equal(t, testSuite.expected, res)
+		})
+	}
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := NewMemoryStore()
+	limit := PerPeriod(1, time.Minute, 3)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		res, err := store.Take(context.Background(), "ip:10.0.0.1", limit, now)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, res.Allowed)
+		assert.Equal(t, 2-i, res.Remaining)
+	}
+
+	res, _ := store.Take(context.Background(), "ip:10.0.0.1", limit, now)
+	assert.Equal(t, false, res.Allowed)
+	assert.Equal(t, time.Minute, res.RetryAfter)
+
+	res, _ = store.Take(context.Background(), "ip:10.0.0.2", limit, now)
+	assert.Equal(t, true, res.Allowed)
+
+	res, _ = store.Take(context.Background(), "ip:10.0.0.1", limit, now.Add(time.Minute))
+	assert.Equal(t, true, res.Allowed)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	store := NewMemoryStore()
+	limit := PerPeriod(60, time.Minute, 1)
+	now := time.Now()
+
+	store.Take(context.Background(), "ip:10.0.0.1", limit, now)
+	store.Take(context.Background(), "ip:10.0.0.2", limit, now.Add(2*time.Minute))
+
+	assert.Equal(t, 1, len(store.entries))
+}
+
+func TestLimiter_Match(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(),
+		Rule{Name: "register", Path: "/api/v1/accounts/register", Methods: []string{"POST"}},
+		Rule{Name: "api", Path: "/api/v1"},
+	)
+
+	testSuites := []struct {
+		testName string
+		method   string
+		route    string
+		expected string
+		matched  bool
+	}{
+		{
+			testName: "SpecificRule",
+			method:   "POST",
+			route:    "/api/v1/accounts/register",
+			expected: "register",
+			matched:  true,
+		},
+		{
+			testName: "MethodNotCovered",
+			method:   "GET",
+			route:    "/api/v1/accounts/register",
+			expected: "api",
+			matched:  true,
+		},
+		{
+			testName: "NoRule",
+			method:   "GET",
+			route:    "/healthz",
+			matched:  false,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			rule, ok := limiter.Match(testSuite.method, testSuite.route)
+			assert.Equal(t, testSuite.matched, ok)
+			assert.Equal(t, testSuite.expected, rule.Name)
+		})
+	}
+}
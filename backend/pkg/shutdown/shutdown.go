@@ -1,13 +1,23 @@
 package shutdown
 
 import (
-	"io"
+	"context"
 	"neatly/pkg/logging"
 	"os"
 	"os/signal"
+	"time"
 )
 
-func Graceful(signals []os.Signal, closeItems ...io.Closer) {
+// Hook stops one part of application. Its ctx expires when drain timeout is
+// over, so hook has to give up waiting for in-flight work then.
+type Hook struct {
+	Name     string
+	Shutdown func(ctx context.Context) error
+}
+
+// Graceful blocks until one of signals is caught, then runs hooks in given
+// order. All hooks share one deadline of timeout.
+func Graceful(signals []os.Signal, timeout time.Duration, hooks ...Hook) {
 	logger := logging.GetLogger()
 
 	sigc := make(chan os.Signal, 1)
@@ -15,9 +25,14 @@ func Graceful(signals []os.Signal, closeItems ...io.Closer) {
 	sig := <-sigc
 	logger.Infof("Caught signal %s. Shutting down...", sig)
 
-	for _, closer := range closeItems {
-		if err := closer.Close(); err != nil {
-			logger.Errorf("failed to close %v: %v", closer, err)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, hook := range hooks {
+		logger.Infof("Stopping %s", hook.Name)
+		if err := hook.Shutdown(ctx); err != nil {
+			logger.Errorf("failed to stop %s: %v", hook.Name, err)
 		}
 	}
+	logger.Info("Shutdown complete")
 }
@@ -0,0 +1,46 @@
+//go:build unit
+// +build unit
+
+package shutdown
+
+import (
+	"context"
+	"github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+func TestWorkers_Shutdown(t *testing.T) {
+	testSuites := []struct {
+		testName      string
+		worker        func(ctx context.Context)
+		expectedError error
+	}{
+		{
+			testName: "WorkerStopsOnCancel",
+			worker: func(ctx context.Context) {
+				<-ctx.Done()
+			},
+			expectedError: nil,
+		},
+		{
+			testName: "WorkerIgnoresCancel",
+			worker: func(ctx context.Context) {
+				time.Sleep(time.Second)
+			},
+			expectedError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			w := NewWorkers()
+			w.Go(testSuite.worker)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			assert.Equal(t, testSuite.expectedError, w.Shutdown(ctx))
+		})
+	}
+}
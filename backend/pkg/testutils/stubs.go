@@ -0,0 +1,25 @@
+package testutils
+
+import (
+	"context"
+	"neatly/internal/model"
+	"neatly/internal/model/mother"
+	"neatly/pkg/jwt"
+)
+
+// SessionStarter issues access tokens bound to mother session without
+// storing it
+type SessionStarter struct{}
+
+func (SessionStarter) Start(_ context.Context, userID, version int, _ model.Client) (string, error) {
+	return jwt.GenerateAccessToken(userID, version, mother.SessionMother().ID)
+}
+
+// Auditor keeps recorded audit entries in memory
+type Auditor struct {
+	Entries []model.AuditEntry
+}
+
+func (a *Auditor) Record(_ context.Context, entry model.AuditEntry) {
+	a.Entries = append(a.Entries, entry)
+}
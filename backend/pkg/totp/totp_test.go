@@ -0,0 +1,58 @@
+//go:build unit
+// +build unit
+
+package totp
+
+import (
+	"github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+// Test vectors for SHA-1 from RFC 6238 appendix B
+func TestHOTP_RFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	testSuites := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, testSuite := range testSuites {
+		step := Step(time.Unix(testSuite.unix, 0))
+		assert.Equal(t, testSuite.expected, hotp(key, uint64(step), 8))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	code, err := Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := Validate(secret, code, now)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, Step(now), step)
+
+	_, ok = Validate(secret, code, now.Add(Period))
+	assert.Equal(t, true, ok)
+
+	_, ok = Validate(secret, code, now.Add(3*Period))
+	assert.Equal(t, false, ok)
+
+	_, ok = Validate(secret, "12345", now)
+	assert.Equal(t, false, ok)
+}
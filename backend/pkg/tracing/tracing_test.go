@@ -0,0 +1,66 @@
+//go:build unit
+// +build unit
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInit(t *testing.T) {
+	testSuites := []struct {
+		testName string
+		cfg      Config
+		wantErr  bool
+	}{
+		{
+			testName: "Disabled",
+			cfg:      Config{Enabled: false, Exporter: "unknown"},
+		},
+		{
+			testName: "Stdout",
+			cfg:      Config{Enabled: true, Exporter: ExporterStdout, ServiceName: "neatly", SampleRatio: 1},
+		},
+		{
+			testName: "UnknownExporter",
+			cfg:      Config{Enabled: true, Exporter: "zipkin"},
+			wantErr:  true,
+		},
+	}
+
+	for _, testSuite := range testSuites {
+		t.Run(testSuite.testName, func(t *testing.T) {
+			flush, err := Init(testSuite.cfg)
+			assert.Equal(t, testSuite.wantErr, err != nil)
+			if err == nil {
+				assert.Equal(t, nil, flush(context.Background()))
+			}
+		})
+	}
+}
+
+func TestStart_ContinuesIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+
+	_, span := Start(ctx, "note.Create")
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "note.Create", spans[0].Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}